@@ -0,0 +1,5 @@
+// Package controlpb is the generated client and server code of the gRPC
+// control API.
+package controlpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative control.proto
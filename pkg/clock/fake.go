@@ -0,0 +1,170 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake is a Clock whose time only moves when Advance is called. Timers and
+// tickers fire once the time passes their deadline, so a test decides
+// exactly when debounce and retry timers expire.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+	changed chan struct{}
+}
+
+// NewFake returns a Fake clock set to now.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now, changed: make(chan struct{})}
+}
+
+// fakeWaiter is a timer, or a ticker when period is set.
+type fakeWaiter struct {
+	clock    *Fake
+	c        chan time.Time
+	deadline time.Time
+	period   time.Duration
+	active   bool
+}
+
+// Now returns the current fake time.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// NewTimer creates a timer firing when the time reaches Now()+d.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	w := &fakeWaiter{clock: f, c: make(chan time.Time, 1)}
+	w.Reset(d)
+	return w
+}
+
+// NewTicker creates a ticker firing every d.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	w := &fakeWaiter{clock: f, c: make(chan time.Time, 1), period: d}
+	w.Reset(d)
+	return fakeTicker{w}
+}
+
+// fakeTicker is a fakeWaiter whose Stop returns nothing, like time.Ticker.
+type fakeTicker struct {
+	*fakeWaiter
+}
+
+func (t fakeTicker) Stop() {
+	t.fakeWaiter.Stop()
+}
+
+// Advance moves the time forward by d, firing the timers and tickers whose
+// deadline has passed. Like time.Ticker, a ticker that falls behind drops
+// ticks instead of queueing them.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+	f.fire()
+}
+
+// Active returns how many timers and tickers are waiting to fire.
+func (f *Fake) Active() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// BlockUntil waits until n timers and tickers are waiting to fire, e.g.
+// until the code under test has started the timer a test wants to expire.
+func (f *Fake) BlockUntil(n int) {
+	for {
+		f.mu.Lock()
+		active, changed := len(f.waiters), f.changed
+		f.mu.Unlock()
+		if active == n {
+			return
+		}
+		<-changed
+	}
+}
+
+// fire sends the current time on every waiter that is due. f.mu is held.
+func (f *Fake) fire() {
+	remaining := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.deadline.After(f.now) {
+			remaining = append(remaining, w)
+			continue
+		}
+		select {
+		case w.c <- f.now:
+		default:
+		}
+		if w.period > 0 {
+			for !w.deadline.After(f.now) {
+				w.deadline = w.deadline.Add(w.period)
+			}
+			remaining = append(remaining, w)
+		} else {
+			w.active = false
+		}
+	}
+	clear(f.waiters[len(remaining):])
+	f.waiters = remaining
+	f.notify()
+}
+
+// notify wakes up BlockUntil after the waiters changed. f.mu is held.
+func (f *Fake) notify() {
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (w *fakeWaiter) C() <-chan time.Time {
+	return w.c
+}
+
+// Reset restarts the timer or ticker to fire after d.
+func (w *fakeWaiter) Reset(d time.Duration) bool {
+	f := w.clock
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	wasActive := w.remove()
+	w.deadline = f.now.Add(d)
+	w.active = true
+	f.waiters = append(f.waiters, w)
+	f.fire()
+	return wasActive
+}
+
+// Stop keeps the timer or ticker from firing.
+func (w *fakeWaiter) Stop() bool {
+	f := w.clock
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	wasActive := w.remove()
+	f.notify()
+	return wasActive
+}
+
+// remove takes w off the waiters and reports whether it was on them.
+// f.mu is held.
+func (w *fakeWaiter) remove() bool {
+	if !w.active {
+		return false
+	}
+	w.active = false
+	f := w.clock
+	for i, other := range f.waiters {
+		if other == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			break
+		}
+	}
+	return true
+}
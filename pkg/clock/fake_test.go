@@ -0,0 +1,113 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+// fired reports whether c has a pending tick.
+func fired(c <-chan time.Time) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestFakeTimer(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := NewFake(start)
+	timer := clk.NewTimer(time.Second)
+
+	clk.Advance(999 * time.Millisecond)
+	if fired(timer.C()) {
+		t.Error("Expected the timer not to fire before its deadline")
+	}
+	clk.Advance(time.Millisecond)
+	if !fired(timer.C()) {
+		t.Error("Expected the timer to fire at its deadline")
+	}
+	if clk.Active() != 0 {
+		t.Errorf("Expected no active timers after firing, got %d", clk.Active())
+	}
+	if got := clk.Now(); !got.Equal(start.Add(time.Second)) {
+		t.Errorf("Expected the time to be %s, got %s", start.Add(time.Second), got)
+	}
+
+	// Reset postpones the deadline, Stop cancels it
+	if timer.Reset(time.Second) {
+		t.Error("Expected Reset of a fired timer to report it inactive")
+	}
+	clk.Advance(500 * time.Millisecond)
+	if !timer.Reset(time.Second) {
+		t.Error("Expected Reset of a pending timer to report it active")
+	}
+	clk.Advance(500 * time.Millisecond)
+	if fired(timer.C()) {
+		t.Error("Expected Reset to postpone the timer")
+	}
+	if !timer.Stop() {
+		t.Error("Expected Stop of a pending timer to report it active")
+	}
+	clk.Advance(time.Hour)
+	if fired(timer.C()) {
+		t.Error("Expected a stopped timer not to fire")
+	}
+
+	// A timer of 0 fires right away
+	if !fired(clk.NewTimer(0).C()) {
+		t.Error("Expected a timer of 0 to fire right away")
+	}
+}
+
+func TestFakeTicker(t *testing.T) {
+	clk := NewFake(time.Now())
+	ticker := clk.NewTicker(time.Second)
+
+	testCases := []struct {
+		advance  time.Duration
+		expected bool
+	}{
+		{500 * time.Millisecond, false},
+		{500 * time.Millisecond, true},
+		{time.Second, true},
+		// Ticks that are not received are dropped
+		{5 * time.Second, true},
+		{500 * time.Millisecond, false},
+	}
+	for i, tc := range testCases {
+		clk.Advance(tc.advance)
+		if got := fired(ticker.C()); got != tc.expected {
+			t.Errorf("Step %d: expected tick %v, got %v", i, tc.expected, got)
+		}
+	}
+
+	ticker.Stop()
+	clk.Advance(time.Hour)
+	if fired(ticker.C()) || clk.Active() != 0 {
+		t.Error("Expected a stopped ticker not to tick")
+	}
+}
+
+func TestFakeBlockUntil(t *testing.T) {
+	clk := NewFake(time.Now())
+	done := make(chan struct{})
+	go func() {
+		clk.BlockUntil(2)
+		close(done)
+	}()
+
+	clk.NewTimer(time.Second)
+	select {
+	case <-done:
+		t.Fatal("Expected BlockUntil to wait for the second timer")
+	case <-time.After(50 * time.Millisecond):
+	}
+	clk.NewTicker(time.Second)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected BlockUntil to return once two timers are active")
+	}
+}
@@ -0,0 +1,71 @@
+// Package redact hides the names of synced files and keys in logs and
+// error messages, for environments where even the names are sensitive.
+// Redaction is off until Enable is called.
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"sync/atomic"
+)
+
+var (
+	enabled atomic.Bool
+	// Key of the placeholders, random so they cannot be reversed by
+	// hashing guessed names
+	key = newKey()
+)
+
+func newKey() []byte {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+}
+
+// Enable turns redaction on or off for the whole process.
+func Enable(on bool) {
+	enabled.Store(on)
+}
+
+// Enabled reports whether names are redacted.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Name returns name, or when redaction is enabled a placeholder such as
+// "redacted-1a2b3c4d". A name always gets the same placeholder within one
+// run of the process, so log lines about it can still be correlated.
+func Name(name string) string {
+	if !enabled.Load() || name == "" {
+		return name
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(name))
+	return "redacted-" + hex.EncodeToString(mac.Sum(nil))[:8]
+}
+
+// Names returns names with every name redacted.
+func Names(names []string) []string {
+	if !enabled.Load() {
+		return names
+	}
+	redacted := make([]string, len(names))
+	for i, name := range names {
+		redacted[i] = Name(name)
+	}
+	return redacted
+}
+
+// Error returns err, with the path redacted when it is an *fs.PathError,
+// as returned when opening or reading a file fails.
+func Error(err error) error {
+	var pathErr *fs.PathError
+	if !enabled.Load() || !errors.As(err, &pathErr) || pathErr != err {
+		return err
+	}
+	return &fs.PathError{Op: pathErr.Op, Path: Name(pathErr.Path), Err: pathErr.Err}
+}
@@ -0,0 +1,51 @@
+package redact
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestName(t *testing.T) {
+	Enable(false)
+	if got := Name("db-password"); got != "db-password" {
+		t.Errorf("Expected the name while disabled, got %q", got)
+	}
+
+	Enable(true)
+	defer Enable(false)
+	redacted := Name("db-password")
+	if !strings.HasPrefix(redacted, "redacted-") || strings.Contains(redacted, "password") {
+		t.Errorf("Expected a placeholder, got %q", redacted)
+	}
+	if Name("db-password") != redacted {
+		t.Errorf("Expected the same placeholder for the same name")
+	}
+	if Name("api-token") == redacted {
+		t.Errorf("Expected different placeholders for different names")
+	}
+	if Name("") != "" {
+		t.Errorf("Expected an empty name to stay empty")
+	}
+}
+
+func TestError(t *testing.T) {
+	pathErr := &fs.PathError{Op: "open", Path: "/secrets/db-password", Err: fs.ErrPermission}
+	other := errors.New("boom")
+
+	Enable(false)
+	if Error(pathErr) != pathErr {
+		t.Errorf("Expected the error unchanged while disabled")
+	}
+
+	Enable(true)
+	defer Enable(false)
+	redacted := Error(pathErr)
+	if strings.Contains(redacted.Error(), "db-password") || !errors.Is(redacted, fs.ErrPermission) {
+		t.Errorf("Expected the path redacted and the cause kept, got %v", redacted)
+	}
+	if Error(other) != other {
+		t.Errorf("Expected other errors unchanged")
+	}
+}
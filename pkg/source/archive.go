@@ -0,0 +1,123 @@
+package source
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"go-file-secret-sync/pkg/redact"
+)
+
+// maxExtractedSize caps how much an archive may expand to. Anything larger
+// could never fit into a Secret, so it is treated as an error rather than
+// being decompressed into memory.
+const maxExtractedSize = 1024 * 1024
+
+// IsArchive reports whether name is an archive that can be extracted.
+func IsArchive(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz") || strings.HasSuffix(lower, ".zip")
+}
+
+// ExtractArchive extracts the regular files of a .tar.gz/.tgz or .zip
+// archive in memory, keyed by their slash-separated path in the archive.
+func ExtractArchive(name string, content []byte) (map[string][]byte, error) {
+	if strings.HasSuffix(strings.ToLower(name), ".zip") {
+		return extractZip(content)
+	}
+	return extractTarGz(content)
+}
+
+func extractTarGz(content []byte) (map[string][]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open gzip stream: %w", err)
+	}
+	defer gz.Close()
+
+	files := make(map[string][]byte)
+	var total int64
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read tar entry: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name, ok := cleanArchivePath(header.Name)
+		if !ok {
+			continue
+		}
+		data, err := readLimited(tr, &total)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", redact.Name(header.Name), err)
+		}
+		files[name] = data
+	}
+	return files, nil
+}
+
+func extractZip(content []byte) (map[string][]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip archive: %w", err)
+	}
+
+	files := make(map[string][]byte)
+	var total int64
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		name, ok := cleanArchivePath(f.Name)
+		if !ok {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", redact.Name(f.Name), err)
+		}
+		data, err := readLimited(rc, &total)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", redact.Name(f.Name), err)
+		}
+		files[name] = data
+	}
+	return files, nil
+}
+
+// cleanArchivePath normalizes an entry path and rejects entries that would
+// escape the archive root.
+func cleanArchivePath(name string) (string, bool) {
+	cleaned := path.Clean(strings.TrimPrefix(name, "/"))
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", false
+	}
+	return cleaned, true
+}
+
+// readLimited reads r while keeping the running total of extracted bytes
+// below maxExtractedSize.
+func readLimited(r io.Reader, total *int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxExtractedSize-*total+1))
+	if err != nil {
+		return nil, err
+	}
+	*total += int64(len(data))
+	if *total > maxExtractedSize {
+		return nil, fmt.Errorf("archive expands to more than %d bytes", maxExtractedSize)
+	}
+	return data, nil
+}
@@ -0,0 +1,103 @@
+package source
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func buildTarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "certs/", Typeflag: tar.TypeDir, Mode: 0755})
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func buildZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to create zip entry: %v", err)
+		}
+		w.Write([]byte(content))
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestReadDirExtractsArchives(t *testing.T) {
+	tempDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tempDir, "nested"), 0755)
+
+	tarGz := buildTarGz(t, map[string]string{
+		"certs/ca.pem": "CA",
+		"../escape":    "nope",
+	})
+	zipped := buildZip(t, map[string]string{
+		"app.conf": "debug=true",
+	})
+	os.WriteFile(filepath.Join(tempDir, "bundle.tar.gz"), tarGz, 0644)
+	os.WriteFile(filepath.Join(tempDir, "nested", "config.zip"), zipped, 0644)
+	os.WriteFile(filepath.Join(tempDir, "plain.txt"), []byte("plain"), 0644)
+
+	r := &Reader{ExtractArchives: true}
+	files, err := r.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	data := Contents(files)
+
+	expected := map[string]string{
+		"certs.ca.pem":    "CA",
+		"nested.app.conf": "debug=true",
+		"plain.txt":       "plain",
+	}
+	if len(data) != len(expected) {
+		t.Errorf("Expected keys %v, got %v", expected, data)
+	}
+	for key, value := range expected {
+		if string(data[key]) != value {
+			t.Errorf("Expected %s=%q, got %q", key, value, data[key])
+		}
+	}
+
+	// Without the option archives are stored opaquely
+	r.ExtractArchives = false
+	files, err = r.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	data = Contents(files)
+	if !bytes.Equal(data["bundle.tar.gz"], tarGz) {
+		t.Error("Expected archive to be stored as-is when extraction is disabled")
+	}
+}
+
+func TestExtractArchiveSizeLimit(t *testing.T) {
+	tarGz := buildTarGz(t, map[string]string{
+		"big": strings.Repeat("x", maxExtractedSize+1),
+	})
+	if _, err := ExtractArchive("big.tgz", tarGz); err == nil {
+		t.Error("Expected extraction to fail for an archive exceeding the size limit")
+	}
+}
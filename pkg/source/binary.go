@@ -0,0 +1,66 @@
+package source
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+
+	"go-file-secret-sync/pkg/redact"
+)
+
+// Policies for files with binary content.
+const (
+	// Sync binary files like any other file
+	BinaryInclude = "include"
+	// Leave binary files out, logging a warning
+	BinarySkip = "skip"
+	// Fail the read on binary files that are not allowlisted
+	BinaryAllowlist = "allowlist"
+)
+
+// binarySniffLen is how much of a file IsBinary looks at, like git.
+const binarySniffLen = 8000
+
+// BinaryPolicy decides what happens to files with binary content, so
+// core dumps or databases that end up in the folder are not synced by
+// accident.
+type BinaryPolicy struct {
+	// BinaryInclude, BinarySkip or BinaryAllowlist; empty includes them
+	Mode string
+	// Binary files that may be synced with BinaryAllowlist, as patterns
+	// matched by MatchesAny
+	Allow []string
+}
+
+// Validate checks the mode and the allowlist patterns.
+func (p BinaryPolicy) Validate() error {
+	switch p.Mode {
+	case "", BinaryInclude, BinarySkip, BinaryAllowlist:
+	default:
+		return fmt.Errorf("unknown binary file policy %q", p.Mode)
+	}
+	return ValidatePatterns(p.Allow)
+}
+
+// admits reports whether the file at relPath, a slash-separated path
+// relative to the folder, is synced given its content. It fails for
+// binary files that are not allowlisted.
+func (p BinaryPolicy) admits(relPath string, content []byte) (bool, error) {
+	if p.Mode == "" || p.Mode == BinaryInclude || !IsBinary(content) {
+		return true, nil
+	}
+	if p.Mode == BinarySkip {
+		log.Printf("Warning: skipping binary file %s", redact.Name(relPath))
+		return false, nil
+	}
+	if !MatchesAny(p.Allow, relPath) {
+		return false, fmt.Errorf("binary file %s is not allowlisted", redact.Name(relPath))
+	}
+	return true, nil
+}
+
+// IsBinary reports whether content looks binary: like git, content with a
+// NUL byte in its first 8000 bytes is binary.
+func IsBinary(content []byte) bool {
+	return bytes.IndexByte(content[:min(len(content), binarySniffLen)], 0) >= 0
+}
@@ -0,0 +1,107 @@
+package source
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestIsBinary(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  []byte
+		expected bool
+	}{
+		{"empty", nil, false},
+		{"text", []byte("key: value\n"), false},
+		{"utf-8 text", []byte("greeting: grüß dich\n"), false},
+		{"nul byte", []byte("SQLite format 3\x00"), true},
+		{"nul byte past the sniffed prefix", append([]byte(strings.Repeat("a", binarySniffLen)), 0), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := IsBinary(tc.content); got != tc.expected {
+				t.Errorf("IsBinary() = %v, expected %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestReadDirBinaryPolicy(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config.yaml":  {Data: []byte("key: value\n")},
+		"keystore.p12": {Data: []byte("0\x82\x00\x01")},
+		"core":         {Data: []byte("\x7fELF\x00\x00")},
+	}
+
+	testCases := []struct {
+		name      string
+		policy    BinaryPolicy
+		expected  []string
+		expectErr bool
+	}{
+		{"default", BinaryPolicy{}, []string{"config.yaml", "core", "keystore.p12"}, false},
+		{"include", BinaryPolicy{Mode: BinaryInclude}, []string{"config.yaml", "core", "keystore.p12"}, false},
+		{"skip", BinaryPolicy{Mode: BinarySkip}, []string{"config.yaml"}, false},
+		{"allowlist", BinaryPolicy{Mode: BinaryAllowlist, Allow: []string{"*.p12", "core"}}, []string{"config.yaml", "core", "keystore.p12"}, false},
+		{"not allowlisted", BinaryPolicy{Mode: BinaryAllowlist, Allow: []string{"*.p12"}}, nil, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &Reader{FS: fsys, Binary: tc.policy}
+			files, err := r.ReadDir(".")
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("ReadDir() error = %v, expectErr %v", err, tc.expectErr)
+			}
+			if tc.expectErr {
+				return
+			}
+			var keys []string
+			for key := range files {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			if strings.Join(keys, ",") != strings.Join(tc.expected, ",") {
+				t.Errorf("Expected keys %v, got %v", tc.expected, keys)
+			}
+		})
+	}
+}
+
+func TestReadDirSkipsBinaryFilesInArchives(t *testing.T) {
+	archive := buildTarGz(t, map[string]string{"certs/ca.pem": "CA", "certs/db.sqlite": "SQLite format 3\x00"})
+	fsys := fstest.MapFS{"bundle.tar.gz": {Data: archive}}
+
+	r := &Reader{FS: fsys, ExtractArchives: true, Binary: BinaryPolicy{Mode: BinarySkip}}
+	files, err := r.ReadDir(".")
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if _, ok := files["certs.ca.pem"]; !ok || len(files) != 1 {
+		t.Errorf("Expected only certs.ca.pem, got %v", files)
+	}
+}
+
+func TestBinaryPolicyValidate(t *testing.T) {
+	testCases := []struct {
+		name      string
+		policy    BinaryPolicy
+		expectErr bool
+	}{
+		{"empty", BinaryPolicy{}, false},
+		{"allowlist", BinaryPolicy{Mode: BinaryAllowlist, Allow: []string{"*.p12"}}, false},
+		{"unknown mode", BinaryPolicy{Mode: "maybe"}, true},
+		{"invalid pattern", BinaryPolicy{Mode: BinaryAllowlist, Allow: []string{"["}}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.policy.Validate(); (err != nil) != tc.expectErr {
+				t.Errorf("Validate() error = %v, expectErr %v", err, tc.expectErr)
+			}
+		})
+	}
+}
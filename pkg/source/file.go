@@ -0,0 +1,24 @@
+// Package source reads the files that are synced to Secrets: the files
+// below a folder, filtered by glob patterns, decrypted with SOPS or GPG and
+// with archives optionally extracted, and documents fetched from URLs.
+package source
+
+import "io/fs"
+
+// File is a file read from a source, kept with its path and permissions so
+// it can be packed into a bundle.
+type File struct {
+	// Slash-separated path relative to the source root
+	Path    string
+	Mode    fs.FileMode
+	Content []byte
+}
+
+// Contents returns the content of every file keyed like files.
+func Contents(files map[string]File) map[string][]byte {
+	data := make(map[string][]byte, len(files))
+	for key, file := range files {
+		data[key] = file.Content
+	}
+	return data
+}
@@ -0,0 +1,82 @@
+package source
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// DefaultIgnorePatterns leaves out editor swap and backup files, lock files
+// and other temporary files, which would otherwise become keys and
+// trigger syncs while someone edits the folder.
+var DefaultIgnorePatterns = []string{
+	"*.swp", "*.swo", "*.swx", "4913", // vim
+	"*~", "#*#", ".#*", // emacs and other editors
+	"*.tmp", "*.temp", "*.bak",
+	".DS_Store",
+}
+
+// Filter selects which files below a folder are synced, using glob
+// patterns matched by MatchesAny.
+type Filter struct {
+	// Files that never change the synced data, e.g. editor swap files
+	Ignore []string
+	// Files to sync; all files when empty
+	Include []string
+	// Files left out even when they are included
+	Exclude []string
+	// Levels of directories read, 1 for only the files directly in the
+	// folder; 0 reads all of them
+	MaxDepth int
+}
+
+// Excludes reports whether the file at relPath, a slash-separated path
+// relative to the folder, is left out by the ignore, include and exclude
+// patterns.
+func (f Filter) Excludes(relPath string) bool {
+	if MatchesAny(f.Ignore, relPath) {
+		return true
+	}
+	if len(f.Include) > 0 && !MatchesAny(f.Include, relPath) {
+		return true
+	}
+	return MatchesAny(f.Exclude, relPath)
+}
+
+// Descends reports whether the files in the directory at relDir, a
+// slash-separated path relative to the folder, are within MaxDepth.
+func (f Filter) Descends(relDir string) bool {
+	return f.MaxDepth <= 0 || strings.Count(relDir, "/")+1 < f.MaxDepth
+}
+
+// Ignores reports whether relPath matches an ignore pattern, so changes to
+// it cannot change the synced data.
+func (f Filter) Ignores(relPath string) bool {
+	return MatchesAny(f.Ignore, relPath)
+}
+
+// MatchesAny reports whether relPath, a slash-separated path relative to
+// the folder, matches any of patterns. Patterns containing a slash are
+// matched against the whole path, other patterns against the file name.
+func MatchesAny(patterns []string, relPath string) bool {
+	for _, pattern := range patterns {
+		name := relPath
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(relPath)
+		}
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidatePatterns checks that every pattern is a valid glob.
+func ValidatePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMatchesAny(t *testing.T) {
+	testCases := []struct {
+		patterns []string
+		relPath  string
+		expected bool
+	}{
+		{[]string{"*.pem"}, "ca.pem", true},
+		{[]string{"*.pem"}, "certs/ca.pem", true},
+		{[]string{"certs/*.pem"}, "certs/ca.pem", true},
+		{[]string{"certs/*.pem"}, "other/ca.pem", false},
+		{[]string{"*.tmp", "*.swp"}, "config.yaml", false},
+		{nil, "config.yaml", false},
+	}
+
+	for _, tc := range testCases {
+		if got := MatchesAny(tc.patterns, tc.relPath); got != tc.expected {
+			t.Errorf("MatchesAny(%v, %q) = %v, expected %v", tc.patterns, tc.relPath, got, tc.expected)
+		}
+	}
+}
+
+func TestReadDirFilters(t *testing.T) {
+	tempDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tempDir, "certs"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "certs", "ca.pem"), []byte("CA"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "certs", "old.pem"), []byte("OLD"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("test: value"), 0644)
+
+	r := &Reader{Filter: Filter{Include: []string{"*.pem"}, Exclude: []string{"old.*"}}}
+	files, err := r.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if len(files) != 1 || string(files["certs.ca.pem"].Content) != "CA" {
+		t.Errorf("Expected only certs.ca.pem, got %v", files)
+	}
+}
+
+func TestReadDirMaxDepth(t *testing.T) {
+	fsys := fstest.MapFS{
+		"top":         {Data: []byte("0")},
+		"a/one":       {Data: []byte("1")},
+		"a/b/two":     {Data: []byte("2")},
+		"a/b/c/three": {Data: []byte("3")},
+	}
+
+	testCases := []struct {
+		maxDepth int
+		expected int
+	}{
+		{0, 4},
+		{1, 1},
+		{2, 2},
+		{3, 3},
+		{4, 4},
+	}
+
+	for _, tc := range testCases {
+		r := &Reader{FS: fsys, Filter: Filter{MaxDepth: tc.maxDepth}}
+		files, err := r.ReadDir(".")
+		if err != nil {
+			t.Fatalf("ReadDir failed: %v", err)
+		}
+		if len(files) != tc.expected {
+			t.Errorf("MaxDepth %d: expected %d files, got %v", tc.maxDepth, tc.expected, files)
+		}
+		states, err := r.FileStates(".")
+		if err != nil {
+			t.Fatalf("FileStates failed: %v", err)
+		}
+		if len(states) != tc.expected {
+			t.Errorf("MaxDepth %d: expected %d file states, got %v", tc.maxDepth, tc.expected, states)
+		}
+	}
+}
+
+func TestDefaultIgnorePatterns(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"config.yaml", ".config.yaml.swp", "config.yaml~", ".#config.yaml", "upload.tmp", "4913"} {
+		os.WriteFile(filepath.Join(tempDir, name), []byte("x"), 0644)
+	}
+
+	r := &Reader{Filter: Filter{Ignore: DefaultIgnorePatterns}}
+	files, err := r.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if len(files) != 1 || files["config.yaml"].Content == nil {
+		t.Errorf("Expected only config.yaml, got %v", files)
+	}
+
+	if !r.Filter.Ignores(".config.yaml.swp") {
+		t.Error("Expected swap file to be ignored")
+	}
+	if r.Filter.Ignores("config.yaml") {
+		t.Error("Expected config.yaml not to be ignored")
+	}
+}
+
+func TestValidatePatterns(t *testing.T) {
+	if err := ValidatePatterns([]string{"*.pem", "certs/*"}); err != nil {
+		t.Errorf("Expected valid patterns, got %v", err)
+	}
+	if err := ValidatePatterns([]string{"["}); err == nil {
+		t.Error("Expected invalid pattern to fail")
+	}
+}
@@ -0,0 +1,36 @@
+package source
+
+import (
+	"io/fs"
+	"os"
+)
+
+// osFS is the operating system's filesystem. Unlike os.DirFS it takes the
+// absolute and relative OS paths the folder is configured with, so paths
+// in logs and errors stay the same as without a filesystem abstraction.
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+func (osFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (osFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+// FileSystem returns the filesystem files are read from: FS, or the
+// operating system's when FS is nil.
+func (r *Reader) FileSystem() fs.FS {
+	if r.FS == nil {
+		return osFS{}
+	}
+	return r.FS
+}
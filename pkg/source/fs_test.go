@@ -0,0 +1,62 @@
+package source
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestReaderFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"certs/ca.pem":         {Data: []byte("CA"), Mode: 0644},
+		"certs/nested/key.pem": {Data: []byte("KEY"), Mode: 0600},
+		"certs/key.pem.swp":    {Data: []byte("swap")},
+		"other.txt":            {Data: []byte("other")},
+	}
+	r := &Reader{FS: fsys, Filter: Filter{Ignore: DefaultIgnorePatterns}}
+
+	testCases := []struct {
+		name     string
+		root     string
+		expected map[string]File
+	}{
+		{"subdirectory", "certs", map[string]File{
+			"ca.pem":         {Path: "ca.pem", Mode: 0644, Content: []byte("CA")},
+			"nested.key.pem": {Path: "nested/key.pem", Mode: 0600, Content: []byte("KEY")},
+		}},
+		{"root", ".", map[string]File{
+			"certs.ca.pem":         {Path: "certs/ca.pem", Mode: 0644, Content: []byte("CA")},
+			"certs.nested.key.pem": {Path: "certs/nested/key.pem", Mode: 0600, Content: []byte("KEY")},
+			"other.txt":            {Path: "other.txt", Mode: 0, Content: []byte("other")},
+		}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			files, err := r.ReadDir(tc.root)
+			if err != nil {
+				t.Fatalf("ReadDir failed: %v", err)
+			}
+			if len(files) != len(tc.expected) {
+				t.Errorf("Expected %d files, got %v", len(tc.expected), files)
+			}
+			for key, want := range tc.expected {
+				got, ok := files[key]
+				if !ok {
+					t.Errorf("Missing key %s", key)
+					continue
+				}
+				if got.Path != want.Path || got.Mode != want.Mode || string(got.Content) != string(want.Content) {
+					t.Errorf("Expected %s to be %+v, got %+v", key, want, got)
+				}
+			}
+		})
+	}
+
+	before, err := r.Fingerprint("certs")
+	if err != nil {
+		t.Fatalf("Fingerprint failed: %v", err)
+	}
+	fsys["certs/ca.pem"] = &fstest.MapFile{Data: []byte("NEW"), Mode: 0644}
+	if after, _ := r.Fingerprint("certs"); after == before {
+		t.Error("Expected a content change to change the fingerprint")
+	}
+}
@@ -0,0 +1,149 @@
+package source
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+
+	"go-file-secret-sync/pkg/redact"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	pgparmor "github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+// Detached signature files that are looked up next to each synced file.
+var gpgSignatureSuffixes = []string{".sig", ".asc"}
+
+// GPGProcessor decrypts *.gpg files and verifies detached signatures
+// against a trusted keyring.
+type GPGProcessor struct {
+	decryptionKeys openpgp.EntityList
+	verifyKeyring  openpgp.EntityList
+}
+
+// LoadGPGProcessor loads the private keys used to decrypt *.gpg files from
+// keyFile and the keyring that detached signatures are verified against
+// from verifyKeyringFile, skipping those that are empty. It returns nil
+// when neither is given.
+func LoadGPGProcessor(keyFile, verifyKeyringFile string) (*GPGProcessor, error) {
+	g := &GPGProcessor{}
+
+	if keyFile != "" {
+		keyring, err := readKeyRing(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load GPG decryption keys: %w", err)
+		}
+		g.decryptionKeys = keyring
+	}
+
+	if verifyKeyringFile != "" {
+		keyring, err := readKeyRing(verifyKeyringFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load GPG verification keyring: %w", err)
+		}
+		g.verifyKeyring = keyring
+	}
+
+	if len(g.decryptionKeys) == 0 && len(g.verifyKeyring) == 0 {
+		return nil, nil
+	}
+	return g, nil
+}
+
+// readKeyRing reads an armored or binary OpenPGP keyring from path.
+func readKeyRing(path string) (openpgp.EntityList, error) {
+	keyBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(keyBytes))
+	if err != nil {
+		keyring, err = openpgp.ReadKeyRing(bytes.NewReader(keyBytes))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse keyring %s: %w", path, err)
+	}
+	return keyring, nil
+}
+
+// IsSignatureFile reports whether path in fsys is a detached signature that
+// should be consumed by verification rather than synced itself: a file
+// with a signature suffix next to the file it signs. Others, such as an
+// armored public key in key.asc, are synced like any file.
+func (g *GPGProcessor) IsSignatureFile(fsys fs.FS, path string) bool {
+	if !g.CanVerify() {
+		return false
+	}
+	for _, suffix := range gpgSignatureSuffixes {
+		signed, ok := strings.CutSuffix(path, suffix)
+		if !ok {
+			continue
+		}
+		if info, err := fs.Stat(fsys, signed); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// CanVerify reports whether signature verification is enabled.
+func (g *GPGProcessor) CanVerify() bool {
+	return len(g.verifyKeyring) > 0
+}
+
+// Verify checks content against the detached signature stored next to
+// path in fsys. A missing or invalid signature is an error.
+func (g *GPGProcessor) Verify(fsys fs.FS, path string, content []byte) error {
+	for _, suffix := range gpgSignatureSuffixes {
+		signature, err := fs.ReadFile(fsys, path+suffix)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to read signature %s: %w", redact.Name(path+suffix), redact.Error(err))
+		}
+
+		var signer *openpgp.Entity
+		if bytes.HasPrefix(bytes.TrimSpace(signature), []byte("-----BEGIN PGP SIGNATURE-----")) {
+			signer, err = openpgp.CheckArmoredDetachedSignature(g.verifyKeyring, bytes.NewReader(content), bytes.NewReader(signature), nil)
+		} else {
+			signer, err = openpgp.CheckDetachedSignature(g.verifyKeyring, bytes.NewReader(content), bytes.NewReader(signature), nil)
+		}
+		if err != nil {
+			return fmt.Errorf("signature check failed for %s: %w", redact.Name(path), err)
+		}
+
+		log.Printf("Verified signature for %s (key %X)", redact.Name(path), signer.PrimaryKey.Fingerprint)
+		return nil
+	}
+
+	return fmt.Errorf("no detached signature found for %s", redact.Name(path))
+}
+
+// CanDecrypt reports whether path is a GPG-encrypted file this processor
+// holds keys for.
+func (g *GPGProcessor) CanDecrypt(path string) bool {
+	return len(g.decryptionKeys) > 0 && strings.HasSuffix(path, ".gpg")
+}
+
+// Decrypt decrypts an armored or binary OpenPGP message.
+func (g *GPGProcessor) Decrypt(content []byte) ([]byte, error) {
+	var r io.Reader = bytes.NewReader(content)
+	if block, err := pgparmor.Decode(bytes.NewReader(content)); err == nil {
+		r = block.Body
+	}
+
+	md, err := openpgp.ReadMessage(r, g.decryptionKeys, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt message: %w", err)
+	}
+	plaintext, err := io.ReadAll(md.UnverifiedBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read decrypted message: %w", err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,113 @@
+package source
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+func newGPGTestEntity(t *testing.T) *openpgp.Entity {
+	t.Helper()
+
+	entity, err := openpgp.NewEntity("file-secret-sync", "test", "test@example.com", nil)
+	if err != nil {
+		t.Fatalf("Failed to create PGP entity: %v", err)
+	}
+	return entity
+}
+
+func TestGPGDecrypt(t *testing.T) {
+	entity := newGPGTestEntity(t)
+
+	var encrypted bytes.Buffer
+	w, err := openpgp.Encrypt(&encrypted, []*openpgp.Entity{entity}, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	w.Write([]byte("s3cr3t"))
+	w.Close()
+
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "password.txt.gpg"), encrypted.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	r := &Reader{GPG: &GPGProcessor{decryptionKeys: openpgp.EntityList{entity}}}
+
+	files, err := r.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	data := Contents(files)
+
+	if string(data["password.txt"]) != "s3cr3t" {
+		t.Errorf("Expected decrypted content under password.txt, got %v", data)
+	}
+}
+
+func TestGPGVerifyDetachedSignature(t *testing.T) {
+	signer := newGPGTestEntity(t)
+	stranger := newGPGTestEntity(t)
+
+	tempDir := t.TempDir()
+	writeSigned := func(name, content string, key *openpgp.Entity) {
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+		var signature bytes.Buffer
+		if err := openpgp.ArmoredDetachSign(&signature, key, bytes.NewReader([]byte(content)), nil); err != nil {
+			t.Fatalf("Failed to sign: %v", err)
+		}
+		if err := os.WriteFile(path+".asc", signature.Bytes(), 0644); err != nil {
+			t.Fatalf("Failed to write signature: %v", err)
+		}
+	}
+
+	writeSigned("config.yaml", "trusted: true", signer)
+
+	r := &Reader{GPG: &GPGProcessor{verifyKeyring: openpgp.EntityList{signer}}}
+
+	files, err := r.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	data := Contents(files)
+	if len(data) != 1 || string(data["config.yaml"]) != "trusted: true" {
+		t.Errorf("Expected only the verified file to be synced, got %v", data)
+	}
+
+	// An armored public key is not a signature of a file next to it
+	writeSigned("key.asc", "-----BEGIN PGP PUBLIC KEY BLOCK-----", signer)
+	files, err = r.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if data := Contents(files); len(data) != 2 || string(data["key.asc"]) != "-----BEGIN PGP PUBLIC KEY BLOCK-----" {
+		t.Errorf("Expected the public key to be synced, got %v", data)
+	}
+	os.Remove(filepath.Join(tempDir, "key.asc"))
+	os.Remove(filepath.Join(tempDir, "key.asc.asc"))
+
+	// Signature from an untrusted key
+	writeSigned("other.yaml", "trusted: false", stranger)
+	if _, err := r.ReadDir(tempDir); err == nil {
+		t.Error("Expected sync to be refused for an untrusted signature")
+	}
+
+	// Unsigned file
+	os.Remove(filepath.Join(tempDir, "other.yaml.asc"))
+	if _, err := r.ReadDir(tempDir); err == nil {
+		t.Error("Expected sync to be refused for an unsigned file")
+	}
+
+	// Tampered content
+	os.Remove(filepath.Join(tempDir, "other.yaml"))
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("trusted: maybe"), 0644)
+	if _, err := r.ReadDir(tempDir); err == nil {
+		t.Error("Expected sync to be refused for a modified file")
+	}
+}
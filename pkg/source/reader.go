@@ -0,0 +1,245 @@
+package source
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go-file-secret-sync/pkg/redact"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Reader reads the files below a folder into Secret data.
+type Reader struct {
+	// Filesystem the files are read from, e.g. an in-memory fstest.MapFS
+	// or a zip.Reader; nil reads the operating system's. Paths are then
+	// slash-separated paths within FS, with "." for its root.
+	FS fs.FS
+	// Files that are left out of the data
+	Filter Filter
+	// What happens to files with binary content
+	Binary BinaryPolicy
+	// Changes to the content of matching files, applied in order
+	Transforms []Transform
+	// Checks of the transformed content of matching files
+	Validation Validation
+	// Decrypt SOPS-encrypted files; nil leaves them as they are
+	SOPS *SOPSDecryptor
+	// Decrypt *.gpg files and verify detached signatures; nil disables both
+	GPG *GPGProcessor
+	// Sync the files inside .tar.gz, .tgz and .zip archives as if they
+	// were unpacked in place
+	ExtractArchives bool
+	// How long files must stay unchanged before they are read; 0 reads
+	// them right away
+	StabilityInterval time.Duration
+}
+
+// ReadDir reads all files below root, keyed by their path relative to root
+// with separators replaced by dots, keeping each file's path and
+// permissions for packing into a bundle.
+func (r *Reader) ReadDir(root string) (map[string]File, error) {
+	files := make(map[string]File)
+
+	// Do not read files that are still being written
+	if err := r.WaitForStable(root); err != nil {
+		return nil, err
+	}
+
+	fsys := r.FileSystem()
+	err := fs.WalkDir(fsys, root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip directories, and do not descend below MaxDepth
+		if d.IsDir() {
+			return r.skipDir(root, path)
+		}
+
+		// Detached signatures are consumed by verification, not synced
+		if r.GPG != nil && r.GPG.IsSignatureFile(fsys, path) {
+			return nil
+		}
+
+		// Use relative path as key, in NFC so names copied from macOS
+		// (NFD) produce the same keys as on Linux
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path for %s: %w", redact.Name(path), err)
+		}
+		relPath = norm.NFC.String(relPath)
+
+		// Leave out files that do not pass the include/exclude patterns
+		if r.Filter.Excludes(filepath.ToSlash(relPath)) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return fmt.Errorf("failed to stat file %s: %w", redact.Name(path), redact.Error(err))
+		}
+
+		// Read file content
+		content, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return fmt.Errorf("failed to read file %s: %w", redact.Name(path), redact.Error(err))
+		}
+
+		// Refuse files whose detached signature does not verify
+		if r.GPG != nil && r.GPG.CanVerify() {
+			if err := r.GPG.Verify(fsys, path, content); err != nil {
+				return err
+			}
+		}
+
+		// Decrypt GPG-encrypted files and drop the .gpg suffix from the key
+		if r.GPG != nil && r.GPG.CanDecrypt(path) {
+			content, err = r.GPG.Decrypt(content)
+			if err != nil {
+				return fmt.Errorf("failed to decrypt GPG file %s: %w", redact.Name(path), err)
+			}
+			relPath = strings.TrimSuffix(relPath, ".gpg")
+		}
+
+		// Decrypt SOPS-encrypted files when key material is configured
+		if r.SOPS != nil && IsSOPSEncrypted(content) {
+			content, err = r.SOPS.Decrypt(relPath, content)
+			if err != nil {
+				return fmt.Errorf("failed to decrypt SOPS file %s: %w", redact.Name(path), err)
+			}
+		}
+
+		// Sync the files inside archives as if they were unpacked in place
+		if r.ExtractArchives && IsArchive(relPath) {
+			extracted, err := ExtractArchive(relPath, content)
+			if err != nil {
+				return fmt.Errorf("failed to extract archive %s: %w", redact.Name(path), err)
+			}
+			dir := filepath.Dir(relPath)
+			for name, fileContent := range extracted {
+				filePath := filepath.Join(dir, filepath.FromSlash(norm.NFC.String(name)))
+				fileContent = r.transform(filepath.ToSlash(filePath), fileContent)
+				if ok, err := r.admits(filepath.ToSlash(filePath), fileContent); !ok {
+					if err != nil {
+						return fmt.Errorf("archive %s: %w", redact.Name(path), err)
+					}
+					continue
+				}
+				key := strings.ReplaceAll(filePath, string(filepath.Separator), ".")
+				if _, exists := files[key]; exists {
+					return fmt.Errorf("archive %s contains %s which conflicts with an existing key", redact.Name(path), redact.Name(name))
+				}
+				files[key] = File{Path: filepath.ToSlash(filePath), Mode: 0644, Content: fileContent}
+				log.Printf("Extracted file: %s:%s -> %s (%d bytes)", redact.Name(path), redact.Name(name), redact.Name(key), len(fileContent))
+			}
+			return nil
+		}
+
+		content = r.transform(filepath.ToSlash(relPath), content)
+
+		// Leave out or refuse binary and invalid files, depending on the
+		// policies
+		if ok, err := r.admits(filepath.ToSlash(relPath), content); !ok {
+			return err
+		}
+
+		// Replace path separators with dots for secret key naming
+		key := strings.ReplaceAll(relPath, string(filepath.Separator), ".")
+		if _, exists := files[key]; exists {
+			return fmt.Errorf("file %s conflicts with an existing key %s", redact.Name(path), redact.Name(key))
+		}
+		files[key] = File{Path: filepath.ToSlash(relPath), Mode: info.Mode().Perm(), Content: content}
+
+		log.Printf("Read file: %s -> %s (%d bytes)", redact.Name(path), redact.Name(key), len(content))
+		return nil
+	})
+
+	return files, err
+}
+
+// admits reports whether the file at relPath is synced given its content,
+// applying the binary file policy and validation.
+func (r *Reader) admits(relPath string, content []byte) (bool, error) {
+	if ok, err := r.Binary.admits(relPath, content); !ok {
+		return false, err
+	}
+	return r.Validation.admits(relPath, content)
+}
+
+// skipDir returns fs.SkipDir for the directory at path when its files are
+// deeper below root than the filter's MaxDepth.
+func (r *Reader) skipDir(root, path string) error {
+	if path == root {
+		return nil
+	}
+	relPath, err := filepath.Rel(root, path)
+	if err != nil {
+		return err
+	}
+	if !r.Filter.Descends(filepath.ToSlash(relPath)) {
+		return fs.SkipDir
+	}
+	return nil
+}
+
+// Fingerprint returns a digest over the path, permissions and content of
+// every file below root that passes the filter. Files are streamed through
+// the hash rather than read into memory, so an unchanged folder of large
+// files can be recognized without loading them.
+func (r *Reader) Fingerprint(root string) (string, error) {
+	h := sha256.New()
+	var length [8]byte
+	fsys := r.FileSystem()
+	err := fs.WalkDir(fsys, root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return r.skipDir(root, path)
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		relPath = norm.NFC.String(relPath)
+		if r.Filter.Excludes(filepath.ToSlash(relPath)) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		// Length-prefix the path so entries cannot run into each other
+		binary.BigEndian.PutUint64(length[:], uint64(len(relPath)))
+		h.Write(length[:])
+		h.Write([]byte(relPath))
+		binary.BigEndian.PutUint32(length[:4], uint32(info.Mode().Perm()))
+		h.Write(length[:4])
+
+		f, err := fsys.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		content := sha256.New()
+		if _, err := io.Copy(content, f); err != nil {
+			return err
+		}
+		h.Write(content.Sum(nil))
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to fingerprint %s: %w", root, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,125 @@
+package source
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"go-file-secret-sync/pkg/redact"
+)
+
+func TestFingerprint(t *testing.T) {
+	tempDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tempDir, "nested"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "nested", "ca.pem"), []byte("CA"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "key.pem"), []byte("KEY"), 0600)
+
+	r := &Reader{Filter: Filter{Ignore: DefaultIgnorePatterns}}
+	fingerprint := func() string {
+		t.Helper()
+		fp, err := r.Fingerprint(tempDir)
+		if err != nil {
+			t.Fatalf("Fingerprint failed: %v", err)
+		}
+		return fp
+	}
+
+	original := fingerprint()
+	if original == "" || fingerprint() != original {
+		t.Fatalf("Expected a stable fingerprint, got %q", original)
+	}
+
+	// Ignored files do not change the fingerprint
+	os.WriteFile(filepath.Join(tempDir, "key.pem.swp"), []byte("swap"), 0644)
+	if fingerprint() != original {
+		t.Error("Expected ignored files not to change the fingerprint")
+	}
+
+	testCases := []struct {
+		name   string
+		change func()
+	}{
+		{"content", func() { os.WriteFile(filepath.Join(tempDir, "key.pem"), []byte("NEW"), 0600) }},
+		{"mode", func() { os.Chmod(filepath.Join(tempDir, "key.pem"), 0644) }},
+		{"rename", func() { os.Rename(filepath.Join(tempDir, "nested"), filepath.Join(tempDir, "moved")) }},
+		{"added file", func() { os.WriteFile(filepath.Join(tempDir, "new.pem"), []byte(""), 0644) }},
+	}
+	previous := original
+	for _, tc := range testCases {
+		tc.change()
+		if current := fingerprint(); current == previous {
+			t.Errorf("Expected a %s change to change the fingerprint", tc.name)
+		} else {
+			previous = current
+		}
+	}
+}
+
+func TestReadDirNormalizesNames(t *testing.T) {
+	// The same name precomposed, as on Linux, and decomposed, as on macOS
+	nfc, nfd := "caf\u00e9.txt", "cafe\u0301.txt"
+
+	testCases := []struct {
+		name      string
+		fsys      fstest.MapFS
+		expectErr bool
+	}{
+		{"nfc", fstest.MapFS{nfc: {Data: []byte("v")}}, false},
+		{"nfd", fstest.MapFS{nfd: {Data: []byte("v")}}, false},
+		{"both", fstest.MapFS{nfc: {Data: []byte("v")}, nfd: {Data: []byte("v")}}, true},
+	}
+
+	var fingerprints []string
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &Reader{FS: tc.fsys}
+			files, err := r.ReadDir(".")
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("ReadDir() error = %v, expectErr %v", err, tc.expectErr)
+			}
+			if tc.expectErr {
+				return
+			}
+			if file, ok := files[nfc]; !ok || file.Path != nfc || len(files) != 1 {
+				t.Errorf("Expected the NFC key %q, got %v", nfc, files)
+			}
+			fingerprint, err := r.Fingerprint(".")
+			if err != nil {
+				t.Fatalf("Fingerprint failed: %v", err)
+			}
+			fingerprints = append(fingerprints, fingerprint)
+		})
+	}
+	if len(fingerprints) == 2 && fingerprints[0] != fingerprints[1] {
+		t.Error("Expected NFC and NFD names to have the same fingerprint")
+	}
+}
+
+func TestReadDirRedactsNames(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	redact.Enable(true)
+	defer redact.Enable(false)
+
+	fsys := fstest.MapFS{
+		"db-password":      {Data: []byte("hunter2")},
+		"conflict/api.key": {Data: []byte("token")},
+		"conflict.api.key": {Data: []byte("token")},
+	}
+	r := &Reader{FS: fsys}
+	_, err := r.ReadDir(".")
+	if err == nil {
+		t.Fatal("Expected a conflict error")
+	}
+
+	for _, name := range []string{"db-password", "hunter2", "api.key", "token"} {
+		if strings.Contains(logs.String(), name) || strings.Contains(err.Error(), name) {
+			t.Errorf("Expected %q to be redacted, got logs %q and error %v", name, logs.String(), err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package source
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	openapierrors "k8s.io/kube-openapi/pkg/validation/errors"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+	"k8s.io/kube-openapi/pkg/validation/strfmt"
+	"k8s.io/kube-openapi/pkg/validation/validate"
+)
+
+// SchemaRule returns a rule requiring every document of the JSON or YAML
+// files matching patterns to validate against the JSON Schema in schema,
+// itself written in JSON or YAML. name identifies the schema in errors.
+func SchemaRule(patterns []string, name string, schema []byte) (ValidationRule, error) {
+	documents, err := decodeDocuments(schema)
+	if err != nil {
+		return ValidationRule{}, fmt.Errorf("failed to parse schema %s: %w", name, err)
+	}
+	if len(documents) != 1 {
+		return ValidationRule{}, fmt.Errorf("schema %s must hold a single document, found %d", name, len(documents))
+	}
+	raw, err := json.Marshal(documents[0])
+	if err != nil {
+		return ValidationRule{}, fmt.Errorf("failed to parse schema %s: %w", name, err)
+	}
+	var parsed spec.Schema
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return ValidationRule{}, fmt.Errorf("failed to parse schema %s: %w", name, err)
+	}
+
+	check := func(content []byte) error {
+		documents, err := decodeDocuments(content)
+		if err != nil {
+			return err
+		}
+		for i, document := range documents {
+			if err := validate.AgainstSchema(&parsed, document, strfmt.Default); err != nil {
+				err = maskValues(err)
+				if len(documents) > 1 {
+					return fmt.Errorf("document %d: %w", i+1, err)
+				}
+				return err
+			}
+		}
+		return nil
+	}
+	return ValidationRule{Patterns: patterns, Description: "valid against schema " + name, Check: check}, nil
+}
+
+// maskValues returns err with the values it quotes, as format errors do,
+// replaced by "<value>", so validation errors never reveal file content.
+func maskValues(err error) error {
+	message := err.Error()
+	var mask func(err error)
+	mask = func(err error) {
+		switch e := err.(type) {
+		case *openapierrors.CompositeError:
+			for _, inner := range e.Errors {
+				mask(inner)
+			}
+		case *openapierrors.Validation:
+			if value, ok := e.Value.(string); ok && value != "" {
+				message = strings.ReplaceAll(message, strconv.Quote(value), `"<value>"`)
+			}
+		}
+	}
+	mask(err)
+	return errors.New(message)
+}
+
+// decodeDocuments parses every document of a YAML stream, which includes
+// JSON, into the values encoding/json would produce.
+func decodeDocuments(content []byte) ([]any, error) {
+	var documents []any
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var document any
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			return documents, nil
+		} else if err != nil {
+			return nil, err
+		}
+		// Round-trip through JSON, so numbers are float64 and keys strings
+		raw, err := json.Marshal(document)
+		if err != nil {
+			return nil, err
+		}
+		var value any
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		documents = append(documents, value)
+	}
+}
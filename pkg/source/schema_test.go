@@ -0,0 +1,103 @@
+package source
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const testSchema = `
+type: object
+required: [host, port]
+properties:
+  host:
+    type: string
+  port:
+    type: integer
+    minimum: 1
+    maximum: 65535
+`
+
+func TestSchemaRule(t *testing.T) {
+	rule, err := SchemaRule(nil, "app.schema.yaml", []byte(testSchema))
+	if err != nil {
+		t.Fatalf("SchemaRule failed: %v", err)
+	}
+
+	testCases := []struct {
+		name      string
+		content   string
+		expectErr bool
+	}{
+		{"yaml", "host: db\nport: 5432\n", false},
+		{"json", `{"host": "db", "port": 5432}`, false},
+		{"yaml stream", "host: a\nport: 1\n---\nhost: b\nport: 2\n", false},
+		{"missing property", "host: db\n", true},
+		{"wrong type", "host: db\nport: \"5432\"\n", true},
+		{"out of range", "host: db\nport: 70000\n", true},
+		{"invalid document in stream", "host: a\nport: 1\n---\nhost: b\n", true},
+		{"not yaml", "host: [unclosed\n", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := rule.Check([]byte(tc.content)); (err != nil) != tc.expectErr {
+				t.Errorf("Check() error = %v, expectErr %v", err, tc.expectErr)
+			}
+		})
+	}
+}
+
+func TestSchemaRuleInvalidSchema(t *testing.T) {
+	testCases := []struct {
+		name   string
+		schema string
+	}{
+		{"not yaml", "type: [unclosed"},
+		{"several documents", "type: object\n---\ntype: string\n"},
+		{"wrong field type", "required: true\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := SchemaRule(nil, "schema.yaml", []byte(tc.schema)); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestReadDirSchemaValidation(t *testing.T) {
+	rule, err := SchemaRule([]string{"*.yaml"}, "app.schema.yaml", []byte(testSchema))
+	if err != nil {
+		t.Fatalf("SchemaRule failed: %v", err)
+	}
+	fsys := fstest.MapFS{"app.yaml": {Data: []byte("host: db\n")}}
+	r := &Reader{FS: fsys, Validation: Validation{Rules: []ValidationRule{rule}}}
+
+	_, err = r.ReadDir(".")
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Expected a ValidationError, got %v", err)
+	}
+	if invalid.Path != "app.yaml" || invalid.Description != "valid against schema app.schema.yaml" {
+		t.Errorf("Unexpected error %+v", invalid)
+	}
+}
+
+func TestSchemaRuleMasksValues(t *testing.T) {
+	schema := "type: object\nproperties:\n  expires:\n    type: string\n    format: date-time\n"
+	rule, err := SchemaRule(nil, "schema.yaml", []byte(schema))
+	if err != nil {
+		t.Fatalf("SchemaRule failed: %v", err)
+	}
+
+	err = rule.Check([]byte("expires: hunter2\n"))
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	if strings.Contains(err.Error(), "hunter2") || !strings.Contains(err.Error(), "<value>") {
+		t.Errorf("Expected the value masked, got %v", err)
+	}
+}
@@ -0,0 +1,391 @@
+package source
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-file-secret-sync/pkg/redact"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	pgparmor "github.com/ProtonMail/go-crypto/openpgp/armor"
+	"gopkg.in/yaml.v3"
+)
+
+// sopsValueRegexp matches a single value encrypted by SOPS.
+var sopsValueRegexp = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.*),iv:(.*),tag:(.*),type:(.*)\]$`)
+
+// SOPSDecryptor decrypts SOPS-encrypted files in-process using local age
+// identities and PGP private keys.
+type SOPSDecryptor struct {
+	ageIdentities []age.Identity
+	pgpKeyring    openpgp.EntityList
+}
+
+// LoadSOPSDecryptor loads the age identities in ageKeyFile and ageKey and
+// the PGP private keys in pgpKeyFile, skipping those that are empty. It
+// returns nil when no key material is given.
+func LoadSOPSDecryptor(ageKeyFile, ageKey, pgpKeyFile string) (*SOPSDecryptor, error) {
+	d := &SOPSDecryptor{}
+
+	// age identities
+	var ageKeys []string
+	if ageKeyFile != "" {
+		keyBytes, err := os.ReadFile(ageKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read age key file: %w", err)
+		}
+		ageKeys = append(ageKeys, string(keyBytes))
+	}
+	if ageKey != "" {
+		ageKeys = append(ageKeys, ageKey)
+	}
+	for _, keys := range ageKeys {
+		identities, err := age.ParseIdentities(strings.NewReader(keys))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse age identities: %w", err)
+		}
+		d.ageIdentities = append(d.ageIdentities, identities...)
+	}
+
+	// PGP private keys
+	if pgpKeyFile != "" {
+		keyring, err := readKeyRing(pgpKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load PGP key file: %w", err)
+		}
+		d.pgpKeyring = keyring
+	}
+
+	if len(d.ageIdentities) == 0 && len(d.pgpKeyring) == 0 {
+		return nil, nil
+	}
+	return d, nil
+}
+
+// IsSOPSEncrypted reports whether content looks like a SOPS-encrypted
+// YAML, JSON or binary file.
+func IsSOPSEncrypted(content []byte) bool {
+	if !bytes.Contains(content, []byte("ENC[AES256_GCM,")) || !bytes.Contains(content, []byte("sops")) {
+		return false
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return false
+	}
+	metadata := sopsMetadata(&doc)
+	return metadata != nil && mappingValue(metadata, "mac") != nil
+}
+
+// Decrypt returns the plaintext of a SOPS-encrypted file. The output format
+// follows the file extension the same way the sops CLI does: YAML and JSON
+// files are re-serialized without the sops metadata, anything else is
+// treated as a binary file and its data value is returned as-is.
+//
+// Every value is authenticated by AES-GCM against its key path, and the
+// document MAC over all values is verified, so values cannot be dropped,
+// added or reordered without the file being rejected.
+func (d *SOPSDecryptor) Decrypt(path string, content []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse SOPS file: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("SOPS file is not a mapping")
+	}
+	root := doc.Content[0]
+
+	metadata := sopsMetadata(&doc)
+	if metadata == nil {
+		return nil, fmt.Errorf("SOPS metadata not found")
+	}
+
+	dataKey, err := d.dataKey(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	// Drop the metadata and decrypt the remaining tree in place
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "sops" {
+			root.Content = append(root.Content[:i], root.Content[i+2:]...)
+			break
+		}
+	}
+	mac := &sopsMAC{hash: sha512.New(), onlyEncrypted: isTrue(mappingValue(metadata, "mac_only_encrypted"))}
+	if err := decryptSOPSNode(root, nil, dataKey, mac); err != nil {
+		return nil, err
+	}
+	if err := mac.verify(metadata, dataKey); err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return yaml.Marshal(&doc)
+	case ".json":
+		var value interface{}
+		if err := root.Decode(&value); err != nil {
+			return nil, fmt.Errorf("failed to decode decrypted JSON: %w", err)
+		}
+		return json.MarshalIndent(value, "", "  ")
+	default:
+		data := mappingValue(root, "data")
+		if data == nil {
+			return nil, fmt.Errorf("SOPS binary file has no data key")
+		}
+		return []byte(data.Value), nil
+	}
+}
+
+// dataKey recovers the SOPS data key using the first key group entry that
+// one of the configured identities can decrypt.
+func (d *SOPSDecryptor) dataKey(metadata *yaml.Node) ([]byte, error) {
+	for _, entry := range sequenceEntries(mappingValue(metadata, "age")) {
+		enc := mappingValue(entry, "enc")
+		if enc == nil || len(d.ageIdentities) == 0 {
+			continue
+		}
+		r, err := age.Decrypt(armor.NewReader(strings.NewReader(enc.Value)), d.ageIdentities...)
+		if err != nil {
+			continue
+		}
+		if key, err := io.ReadAll(r); err == nil {
+			return key, nil
+		}
+	}
+
+	for _, entry := range sequenceEntries(mappingValue(metadata, "pgp")) {
+		enc := mappingValue(entry, "enc")
+		if enc == nil || len(d.pgpKeyring) == 0 {
+			continue
+		}
+		block, err := pgparmor.Decode(strings.NewReader(enc.Value))
+		if err != nil {
+			continue
+		}
+		md, err := openpgp.ReadMessage(block.Body, d.pgpKeyring, nil, nil)
+		if err != nil {
+			continue
+		}
+		if key, err := io.ReadAll(md.UnverifiedBody); err == nil {
+			return key, nil
+		}
+	}
+
+	if unsupported := unsupportedKeyGroups(metadata); len(unsupported) > 0 {
+		return nil, fmt.Errorf("the SOPS data key is only encrypted with %s, which cannot be decrypted here; add an age or PGP recipient to the file", strings.Join(unsupported, ", "))
+	}
+	return nil, fmt.Errorf("no configured age or PGP key can decrypt the SOPS data key")
+}
+
+// unsupportedKeyGroups returns the key types of metadata that cannot be
+// decrypted in-process, when the file has no age or PGP key at all.
+func unsupportedKeyGroups(metadata *yaml.Node) []string {
+	if len(sequenceEntries(mappingValue(metadata, "age"))) > 0 || len(sequenceEntries(mappingValue(metadata, "pgp"))) > 0 {
+		return nil
+	}
+	var unsupported []string
+	for _, keyType := range []string{"kms", "gcp_kms", "azure_kv", "hc_vault", "key_groups"} {
+		if len(sequenceEntries(mappingValue(metadata, keyType))) > 0 {
+			unsupported = append(unsupported, keyType)
+		}
+	}
+	return unsupported
+}
+
+// sopsMAC computes the MAC of a SOPS document the way sops does: a
+// SHA-512 over the plaintext of every value in document order, or of the
+// encrypted ones only with mac_only_encrypted.
+type sopsMAC struct {
+	hash          hash.Hash
+	onlyEncrypted bool
+}
+
+// addEncrypted adds the plaintext of an encrypted value.
+func (m *sopsMAC) addEncrypted(plaintext string) {
+	m.hash.Write([]byte(plaintext))
+}
+
+// addPlain adds a value that was not encrypted, in the representation
+// sops gives the decoded value.
+func (m *sopsMAC) addPlain(node *yaml.Node) {
+	if m.onlyEncrypted {
+		return
+	}
+	value := node.Value
+	switch node.ShortTag() {
+	case "!!int":
+		var i int
+		if err := node.Decode(&i); err == nil {
+			value = strconv.Itoa(i)
+		}
+	case "!!float":
+		var f float64
+		if err := node.Decode(&f); err == nil {
+			value = strconv.FormatFloat(f, 'f', -1, 64)
+		}
+	case "!!bool":
+		value = "False"
+		if isTrue(node) {
+			value = "True"
+		}
+	case "!!null":
+		value = ""
+	}
+	m.hash.Write([]byte(value))
+}
+
+// verify compares the computed MAC with the one stored encrypted in
+// metadata, authenticated against the lastmodified timestamp.
+func (m *sopsMAC) verify(metadata *yaml.Node, dataKey []byte) error {
+	stored, lastModified := mappingValue(metadata, "mac"), mappingValue(metadata, "lastmodified")
+	if stored == nil || lastModified == nil {
+		return fmt.Errorf("SOPS file has no MAC")
+	}
+	modified, err := time.Parse(time.RFC3339, lastModified.Value)
+	if err != nil {
+		return fmt.Errorf("invalid SOPS lastmodified %q: %w", lastModified.Value, err)
+	}
+	expected, _, err := decryptSOPSValue(stored.Value, dataKey, modified.Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("failed to decrypt SOPS MAC: %w", err)
+	}
+	computed := fmt.Sprintf("%X", m.hash.Sum(nil))
+	if subtle.ConstantTimeCompare([]byte(computed), []byte(expected)) != 1 {
+		return fmt.Errorf("SOPS MAC mismatch: values were added, removed or reordered after encryption")
+	}
+	return nil
+}
+
+// isTrue reports whether node is a YAML true.
+func isTrue(node *yaml.Node) bool {
+	var b bool
+	return node != nil && node.Decode(&b) == nil && b
+}
+
+// decryptSOPSNode walks node and replaces every encrypted scalar with its
+// plaintext. Sequence items share the key path of their parent, matching
+// how sops builds the additional authenticated data.
+func decryptSOPSNode(node *yaml.Node, path []string, dataKey []byte, mac *sopsMAC) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := decryptSOPSNode(node.Content[i+1], append(path, node.Content[i].Value), dataKey, mac); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if err := decryptSOPSNode(item, path, dataKey, mac); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		if !sopsValueRegexp.MatchString(node.Value) {
+			mac.addPlain(node)
+			return nil
+		}
+		value, valueType, err := decryptSOPSValue(node.Value, dataKey, strings.Join(path, ":")+":")
+		if err != nil {
+			return fmt.Errorf("failed to decrypt %s: %w", redact.Name(strings.Join(path, ".")), err)
+		}
+		mac.addEncrypted(value)
+		node.Value = value
+		node.Style = 0
+		switch valueType {
+		case "int":
+			node.Tag = "!!int"
+		case "float":
+			node.Tag = "!!float"
+		case "bool":
+			node.Tag = "!!bool"
+		default:
+			node.Tag = "!!str"
+		}
+	}
+	return nil
+}
+
+// decryptSOPSValue decrypts a single ENC[AES256_GCM,...] value and returns
+// the plaintext together with its declared type.
+func decryptSOPSValue(value string, dataKey []byte, additionalData string) (string, string, error) {
+	matches := sopsValueRegexp.FindStringSubmatch(value)
+	if matches == nil {
+		return "", "", fmt.Errorf("value is not SOPS-encrypted")
+	}
+
+	var parts [3][]byte
+	for i := range parts {
+		decoded, err := base64.StdEncoding.DecodeString(matches[i+1])
+		if err != nil {
+			return "", "", fmt.Errorf("invalid base64 in encrypted value: %w", err)
+		}
+		parts[i] = decoded
+	}
+	data, iv, tag := parts[0], parts[1], parts[2]
+
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid data key: %w", err)
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create cipher: %w", err)
+	}
+	plaintext, err := gcm.Open(nil, iv, append(data, tag...), []byte(additionalData))
+	if err != nil {
+		return "", "", fmt.Errorf("authentication failed: %w", err)
+	}
+
+	return string(plaintext), matches[4], nil
+}
+
+// sopsMetadata returns the top-level sops mapping of a parsed document.
+func sopsMetadata(doc *yaml.Node) *yaml.Node {
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	metadata := mappingValue(doc.Content[0], "sops")
+	if metadata == nil || metadata.Kind != yaml.MappingNode {
+		return nil
+	}
+	return metadata
+}
+
+// mappingValue returns the value node for key in a mapping node.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// sequenceEntries returns the items of a sequence node.
+func sequenceEntries(node *yaml.Node) []*yaml.Node {
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	return node.Content
+}
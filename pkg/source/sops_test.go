@@ -0,0 +1,218 @@
+package source
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// encryptSOPSValue encrypts a value the same way sops does.
+func encryptSOPSValue(t *testing.T, dataKey []byte, value, additionalData, valueType string) string {
+	t.Helper()
+
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		t.Fatalf("Failed to create cipher: %v", err)
+	}
+	iv := make([]byte, 32)
+	if _, err := rand.Read(iv); err != nil {
+		t.Fatalf("Failed to generate iv: %v", err)
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		t.Fatalf("Failed to create GCM: %v", err)
+	}
+	sealed := gcm.Seal(nil, iv, []byte(value), []byte(additionalData))
+	data, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]",
+		base64.StdEncoding.EncodeToString(data),
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(tag),
+		valueType)
+}
+
+// newSOPSTestKey returns an age identity, a fresh data key and a function
+// returning the SOPS metadata block holding the data key encrypted to the
+// identity and the MAC over values, the plaintext of every value of the
+// document in order.
+func newSOPSTestKey(t *testing.T) (*age.X25519Identity, []byte, func(values ...string) string) {
+	t.Helper()
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("Failed to generate age identity: %v", err)
+	}
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		t.Fatalf("Failed to generate data key: %v", err)
+	}
+
+	var enc bytes.Buffer
+	armorWriter := armor.NewWriter(&enc)
+	w, err := age.Encrypt(armorWriter, identity.Recipient())
+	if err != nil {
+		t.Fatalf("Failed to encrypt data key: %v", err)
+	}
+	w.Write(dataKey)
+	w.Close()
+	armorWriter.Close()
+
+	indented := "            " + strings.ReplaceAll(strings.TrimSpace(enc.String()), "\n", "\n            ")
+	metadata := func(values ...string) string {
+		mac := sha512.Sum512([]byte(strings.Join(values, "")))
+		return fmt.Sprintf(`sops:
+    age:
+        - recipient: %s
+          enc: |
+%s
+    lastmodified: "2024-01-01T00:00:00Z"
+    mac: %s
+    version: 3.8.1
+`, identity.Recipient(), indented, encryptSOPSValue(t, dataKey, fmt.Sprintf("%X", mac), "2024-01-01T00:00:00Z", "str"))
+	}
+
+	return identity, dataKey, metadata
+}
+
+func TestSOPSDecryptYAML(t *testing.T) {
+	identity, dataKey, metadata := newSOPSTestKey(t)
+
+	content := fmt.Sprintf(`database:
+    user: %s
+    port: %s
+    hosts:
+        - %s
+plain: value
+%s`,
+		encryptSOPSValue(t, dataKey, "admin", "database:user:", "str"),
+		encryptSOPSValue(t, dataKey, "5432", "database:port:", "int"),
+		encryptSOPSValue(t, dataKey, "db.local", "database:hosts:", "str"),
+		metadata("admin", "5432", "db.local", "value"))
+
+	if !IsSOPSEncrypted([]byte(content)) {
+		t.Fatal("Expected content to be detected as SOPS-encrypted")
+	}
+
+	d := &SOPSDecryptor{ageIdentities: []age.Identity{identity}}
+	plaintext, err := d.Decrypt("secrets.yaml", []byte(content))
+	if err != nil {
+		t.Fatalf("decrypt failed: %v", err)
+	}
+
+	expected := "database:\n    user: admin\n    port: 5432\n    hosts:\n        - db.local\nplain: value\n"
+	if string(plaintext) != expected {
+		t.Errorf("Expected decrypted YAML %q, got %q", expected, string(plaintext))
+	}
+
+	// A wrong identity must not be able to decrypt the data key
+	other, _ := age.GenerateX25519Identity()
+	d = &SOPSDecryptor{ageIdentities: []age.Identity{other}}
+	if _, err := d.Decrypt("secrets.yaml", []byte(content)); err == nil {
+		t.Error("Expected decrypt to fail with a foreign identity")
+	}
+}
+
+func TestSOPSDecryptTamperedPath(t *testing.T) {
+	identity, dataKey, metadata := newSOPSTestKey(t)
+
+	// Value encrypted for a different key path must fail authentication
+	content := fmt.Sprintf("password: %s\n%s",
+		encryptSOPSValue(t, dataKey, "hunter2", "username:", "str"), metadata("hunter2"))
+
+	d := &SOPSDecryptor{ageIdentities: []age.Identity{identity}}
+	if _, err := d.Decrypt("secrets.yaml", []byte(content)); err == nil {
+		t.Error("Expected decrypt to fail for a value moved to another key")
+	}
+}
+
+func TestSOPSDecryptBinaryInFolder(t *testing.T) {
+	identity, dataKey, metadata := newSOPSTestKey(t)
+
+	tempDir := t.TempDir()
+	encrypted := fmt.Sprintf("data: %s\n%s",
+		encryptSOPSValue(t, dataKey, "-----BEGIN CERTIFICATE-----\n", "data:", "str"), metadata("-----BEGIN CERTIFICATE-----\n"))
+	if err := os.WriteFile(filepath.Join(tempDir, "tls.crt"), []byte(encrypted), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "plain.txt"), []byte("ENC[AES256_GCM, sops"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	r := &Reader{SOPS: &SOPSDecryptor{ageIdentities: []age.Identity{identity}}}
+
+	files, err := r.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	data := Contents(files)
+
+	if string(data["tls.crt"]) != "-----BEGIN CERTIFICATE-----\n" {
+		t.Errorf("Expected decrypted binary content, got %q", string(data["tls.crt"]))
+	}
+	if string(data["plain.txt"]) != "ENC[AES256_GCM, sops" {
+		t.Errorf("Expected plain file to be synced as-is, got %q", string(data["plain.txt"]))
+	}
+}
+
+func TestSOPSDecryptMAC(t *testing.T) {
+	identity, dataKey, metadata := newSOPSTestKey(t)
+	first := encryptSOPSValue(t, dataKey, "first", "tokens:", "str")
+	second := encryptSOPSValue(t, dataKey, "second", "tokens:", "str")
+	enabled := encryptSOPSValue(t, dataKey, "True", "enabled:", "bool")
+
+	tests := []struct {
+		name      string
+		content   string
+		expectErr bool
+	}{
+		{"intact", fmt.Sprintf("tokens:\n    - %s\n    - %s\nenabled: %s\nport: 0x10\n%s", first, second, enabled,
+			metadata("first", "second", "True", "16")), false},
+		// Both items share a key path, so only the MAC catches the swap
+		{"reordered", fmt.Sprintf("tokens:\n    - %s\n    - %s\nenabled: %s\nport: 0x10\n%s", second, first, enabled,
+			metadata("first", "second", "True", "16")), true},
+		{"value dropped", fmt.Sprintf("tokens:\n    - %s\nenabled: %s\nport: 0x10\n%s", first, enabled,
+			metadata("first", "second", "True", "16")), true},
+		{"plain value changed", fmt.Sprintf("tokens:\n    - %s\n    - %s\nenabled: %s\nport: 17\n%s", first, second, enabled,
+			metadata("first", "second", "True", "16")), true},
+	}
+
+	d := &SOPSDecryptor{ageIdentities: []age.Identity{identity}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := d.Decrypt("secrets.yaml", []byte(tt.content))
+			if (err != nil) != tt.expectErr {
+				t.Errorf("Decrypt() error = %v, expectErr %v", err, tt.expectErr)
+			}
+		})
+	}
+}
+
+func TestSOPSDecryptKMSOnly(t *testing.T) {
+	identity, _ := age.GenerateX25519Identity()
+	content := `password: ENC[AES256_GCM,data:AAAA,iv:AAAA,tag:AAAA,type:str]
+sops:
+    kms:
+        - arn: arn:aws:kms:eu-west-1:111122223333:key/example
+          enc: AQICAHg=
+    lastmodified: "2024-01-01T00:00:00Z"
+    mac: ENC[AES256_GCM,data:AAAA,iv:AAAA,tag:AAAA,type:str]
+    version: 3.8.1
+`
+	d := &SOPSDecryptor{ageIdentities: []age.Identity{identity}}
+	_, err := d.Decrypt("secrets.yaml", []byte(content))
+	if err == nil || !strings.Contains(err.Error(), "only encrypted with kms") {
+		t.Errorf("Expected an error naming the unsupported KMS key, got %v", err)
+	}
+}
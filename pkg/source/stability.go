@@ -0,0 +1,89 @@
+package source
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"time"
+
+	"go-file-secret-sync/pkg/redact"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxStabilityChecks bounds how often WaitForStable re-checks a
+// folder before giving up on files that keep changing.
+const maxStabilityChecks = 10
+
+// FileState is the size and modification time of a file.
+type FileState struct {
+	Size    int64
+	ModTime time.Time
+}
+
+// WaitForStable waits until no file below root has changed size or
+// modification time for StabilityInterval, so files written non-atomically
+// are not read half-written. It fails when files are still changing after
+// maxStabilityChecks intervals.
+func (r *Reader) WaitForStable(root string) error {
+	if r.StabilityInterval <= 0 {
+		return nil
+	}
+
+	previous, err := r.FileStates(root)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < maxStabilityChecks; i++ {
+		time.Sleep(r.StabilityInterval)
+		current, err := r.FileStates(root)
+		if err != nil {
+			return err
+		}
+
+		var changed []string
+		for path, state := range current {
+			if previous[path] != state {
+				changed = append(changed, path)
+			}
+		}
+		if len(changed) == 0 {
+			return nil
+		}
+		log.Printf("Waiting for %d files that are still being written, e.g. %s", len(changed), redact.Name(changed[0]))
+		previous = current
+	}
+	return fmt.Errorf("files below %s are still changing after %s", root, maxStabilityChecks*r.StabilityInterval)
+}
+
+// FileStates returns the state of every file below root that passes the
+// filter, keyed by path.
+func (r *Reader) FileStates(root string) (map[string]FileState, error) {
+	states := make(map[string]FileState)
+	err := fs.WalkDir(r.FileSystem(), root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return r.skipDir(root, path)
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if r.Filter.Excludes(filepath.ToSlash(norm.NFC.String(relPath))) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		states[path] = FileState{Size: info.Size(), ModTime: info.ModTime()}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check file stability: %w", redact.Error(err))
+	}
+	return states, nil
+}
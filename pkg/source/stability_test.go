@@ -0,0 +1,69 @@
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWaitForStable(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "large.bin")
+	os.WriteFile(path, []byte("partial"), 0644)
+
+	r := &Reader{StabilityInterval: 20 * time.Millisecond}
+
+	// Keep appending while the first intervals pass
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		defer f.Close()
+		for i := 0; i < 3; i++ {
+			f.Write([]byte("-more"))
+			time.Sleep(15 * time.Millisecond)
+		}
+	}()
+
+	files, err := r.ReadDir(tempDir)
+	<-done
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if string(files["large.bin"].Content) != "partial-more-more-more" {
+		t.Errorf("Expected the complete file, got %q", files["large.bin"].Content)
+	}
+}
+
+func TestWaitForStableGivesUp(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "growing.log")
+	os.WriteFile(path, nil, 0644)
+
+	r := &Reader{StabilityInterval: 5 * time.Millisecond}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		defer f.Close()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				f.Write([]byte("x"))
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}()
+
+	err := r.WaitForStable(tempDir)
+	close(stop)
+	<-done
+	if err == nil {
+		t.Error("Expected files that keep changing to fail the check")
+	}
+}
@@ -0,0 +1,81 @@
+package source
+
+import (
+	"bytes"
+	"fmt"
+
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Transform changes the content of matching files before they are synced.
+type Transform struct {
+	// Files the transform applies to, as patterns matched by MatchesAny
+	Patterns []string
+	// Apply returns the transformed content
+	Apply func(content []byte) []byte
+}
+
+// TrimNewline strips a single trailing newline, "\n" or "\r\n", from the
+// files matching patterns, for values such as passwords written by shell
+// scripts and consumed through environment variables.
+func TrimNewline(patterns []string) Transform {
+	return Transform{
+		Patterns: patterns,
+		Apply: func(content []byte) []byte {
+			if trimmed, ok := bytes.CutSuffix(content, []byte("\n")); ok {
+				content, _ = bytes.CutSuffix(trimmed, []byte("\r"))
+			}
+			return content
+		},
+	}
+}
+
+// NormalizeLineEndings replaces Windows line endings, "\r\n", with "\n"
+// in the text files matching patterns, for files generated on Windows
+// hosts that confuse parsers downstream. Binary files are left alone.
+func NormalizeLineEndings(patterns []string) Transform {
+	return Transform{
+		Patterns: patterns,
+		Apply: func(content []byte) []byte {
+			if IsBinary(content) {
+				return content
+			}
+			return bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+		},
+	}
+}
+
+// Transcode converts the files matching patterns from the named encoding,
+// such as "utf-16le", "latin1" or "shift_jis", to UTF-8, for files exported
+// from Windows or legacy systems. Names are the labels of the WHATWG
+// Encoding Standard. A byte order mark overrides the encoding.
+func Transcode(patterns []string, name string) (Transform, error) {
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return Transform{}, fmt.Errorf("unknown encoding %q", name)
+	}
+	return Transform{
+		Patterns: patterns,
+		Apply: func(content []byte) []byte {
+			decoded, _, err := transform.Bytes(unicode.BOMOverride(enc.NewDecoder()), content)
+			if err != nil {
+				// Decoders replace invalid input, so this is not expected
+				return content
+			}
+			return decoded
+		},
+	}, nil
+}
+
+// transform applies every transform matching the file at relPath, a
+// slash-separated path relative to the folder, in order.
+func (r *Reader) transform(relPath string, content []byte) []byte {
+	for _, t := range r.Transforms {
+		if MatchesAny(t.Patterns, relPath) {
+			content = t.Apply(content)
+		}
+	}
+	return content
+}
@@ -0,0 +1,110 @@
+package source
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestTrimNewline(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"newline", "hunter2\n", "hunter2"},
+		{"crlf", "hunter2\r\n", "hunter2"},
+		{"only one newline", "hunter2\n\n", "hunter2\n"},
+		{"no newline", "hunter2", "hunter2"},
+		{"carriage return only", "hunter2\r", "hunter2\r"},
+		{"empty", "", ""},
+	}
+
+	trim := TrimNewline(nil)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := string(trim.Apply([]byte(tc.content))); got != tc.expected {
+				t.Errorf("Apply(%q) = %q, expected %q", tc.content, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestNormalizeLineEndings(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"crlf", "a: 1\r\nb: 2\r\n", "a: 1\nb: 2\n"},
+		{"mixed", "a: 1\r\nb: 2\n", "a: 1\nb: 2\n"},
+		{"lone carriage return", "a\rb", "a\rb"},
+		{"binary", "\x00\r\n", "\x00\r\n"},
+	}
+
+	normalize := NormalizeLineEndings(nil)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := string(normalize.Apply([]byte(tc.content))); got != tc.expected {
+				t.Errorf("Apply(%q) = %q, expected %q", tc.content, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestTranscode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		encoding string
+		content  []byte
+		expected string
+	}{
+		{"latin1", "latin1", []byte("gr\xfc\xdf dich"), "grüß dich"},
+		{"utf-16le", "utf-16le", []byte("h\x00\xe9\x00"), "hé"},
+		{"utf-16le with byte order mark", "utf-16le", []byte("\xff\xfeh\x00i\x00"), "hi"},
+		{"byte order mark overrides", "latin1", []byte("\xfe\xff\x00h\x00i"), "hi"},
+		{"shift_jis", "shift_jis", []byte("\x93\xfa\x96\x7b"), "日本"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			transcode, err := Transcode(nil, tc.encoding)
+			if err != nil {
+				t.Fatalf("Transcode failed: %v", err)
+			}
+			if got := string(transcode.Apply(tc.content)); got != tc.expected {
+				t.Errorf("Apply() = %q, expected %q", got, tc.expected)
+			}
+		})
+	}
+
+	if _, err := Transcode(nil, "klingon"); err == nil {
+		t.Error("Expected an unknown encoding to fail")
+	}
+}
+
+func TestReadDirTransforms(t *testing.T) {
+	fsys := fstest.MapFS{
+		"password":         {Data: []byte("hunter2\n")},
+		"tokens/api.token": {Data: []byte("abc\n")},
+		"config.yaml":      {Data: []byte("key: value\r\n")},
+	}
+	r := &Reader{FS: fsys, Transforms: []Transform{
+		NormalizeLineEndings([]string{"*.yaml"}),
+		TrimNewline([]string{"password", "*.token"}),
+	}}
+
+	files, err := r.ReadDir(".")
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	expected := map[string]string{
+		"password":         "hunter2",
+		"tokens.api.token": "abc",
+		"config.yaml":      "key: value\n",
+	}
+	for key, value := range expected {
+		if string(files[key].Content) != value {
+			t.Errorf("Expected %s=%q, got %q", key, value, files[key].Content)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"go-file-secret-sync/pkg/redact"
+)
+
+// URLSource periodically fetches URLs and stores their bodies as keys, for
+// documents such as CRLs or JWKS that are published over HTTP(S).
+type URLSource struct {
+	entries    []*urlEntry
+	headerName string
+	headerVal  string
+	interval   time.Duration
+	httpClient *http.Client
+}
+
+// urlEntry is a single fetched URL along with the validators used for
+// conditional requests.
+type urlEntry struct {
+	key          string
+	url          string
+	etag         string
+	lastModified string
+	body         []byte
+	fetched      bool
+}
+
+// NewURLSource creates a URL source fetching urls, each optionally
+// prefixed with "key=", every interval. authHeader is an optional header
+// such as "Authorization: Bearer <token>" sent with every request.
+func NewURLSource(urls []string, authHeader string, interval time.Duration, httpClient *http.Client) (*URLSource, error) {
+	u := &URLSource{
+		interval:   interval,
+		httpClient: httpClient,
+	}
+
+	if authHeader != "" {
+		name, value, ok := strings.Cut(authHeader, ":")
+		if !ok {
+			return nil, fmt.Errorf("header must be in the form \"Name: value\"")
+		}
+		u.headerName = strings.TrimSpace(name)
+		u.headerVal = strings.TrimSpace(value)
+	}
+
+	for _, entry := range urls {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		u.entries = append(u.entries, parseURLEntry(entry))
+	}
+
+	return u, nil
+}
+
+// Interval returns how often the URLs are fetched again.
+func (u *URLSource) Interval() time.Duration {
+	return u.interval
+}
+
+// parseURLEntry parses "key=url" or a bare URL, in which case the key is
+// the last path segment of the URL.
+func parseURLEntry(entry string) *urlEntry {
+	if eq, scheme := strings.Index(entry, "="), strings.Index(entry, "://"); eq > 0 && (scheme < 0 || eq < scheme) {
+		return &urlEntry{key: entry[:eq], url: entry[eq+1:]}
+	}
+
+	key := entry
+	if scheme := strings.Index(entry, "://"); scheme >= 0 {
+		key = entry[scheme+3:]
+	}
+	key, _, _ = strings.Cut(key, "?")
+	return &urlEntry{key: path.Base(key), url: entry}
+}
+
+// Fetch returns the current body of every URL. Unchanged documents are
+// served from cache using ETag/If-Modified-Since; when a refresh fails the
+// last good body is kept, so an unreachable server never empties a key.
+func (u *URLSource) Fetch(ctx context.Context) (map[string][]byte, error) {
+	data := make(map[string][]byte, len(u.entries))
+
+	for _, entry := range u.entries {
+		if err := u.fetchEntry(ctx, entry); err != nil {
+			if !entry.fetched {
+				return nil, err
+			}
+			log.Printf("Failed to refresh %s, keeping last fetched content: %v", loggedURL(entry.url), err)
+		}
+		// A copy, so callers may zero it without losing the content
+		// kept for conditional requests
+		data[entry.key] = bytes.Clone(entry.body)
+	}
+
+	return data, nil
+}
+
+func (u *URLSource) fetchEntry(ctx context.Context, entry *urlEntry) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, entry.url, nil)
+	if err != nil {
+		return fmt.Errorf("invalid URL for key %s: %w", redact.Name(entry.key), urlError(err))
+	}
+	if u.headerName != "" {
+		req.Header.Set(u.headerName, u.headerVal)
+	}
+	if entry.fetched {
+		if entry.etag != "" {
+			req.Header.Set("If-None-Match", entry.etag)
+		}
+		if entry.lastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.lastModified)
+		}
+	}
+
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", loggedURL(entry.url), urlError(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && entry.fetched {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch %s: unexpected status %s", loggedURL(entry.url), resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read body of %s: %w", loggedURL(entry.url), err)
+	}
+
+	entry.body = body
+	entry.etag = resp.Header.Get("ETag")
+	entry.lastModified = resp.Header.Get("Last-Modified")
+	entry.fetched = true
+	log.Printf("Fetched URL: %s -> %s (%d bytes)", loggedURL(entry.url), redact.Name(entry.key), len(body))
+	return nil
+}
+
+// loggedURL returns rawURL without its userinfo, query and fragment, which
+// may carry credentials such as passwords, tokens and signatures, for logs
+// and errors.
+func loggedURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "<invalid URL>"
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.ForceQuery = false
+	u.Fragment = ""
+	return u.String()
+}
+
+// urlError returns the cause of a *url.Error, whose message repeats the
+// URL with its query, or else err.
+func urlError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
@@ -0,0 +1,154 @@
+package source
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"go-file-secret-sync/pkg/redact"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Formats files can be required to be valid in.
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+	FormatPEM  = "pem"
+)
+
+// Policies for files that fail validation.
+const (
+	// Fail the read, so nothing is synced until the file is fixed
+	ValidationBlock = "block"
+	// Leave the file out, logging a warning
+	ValidationSkip = "skip"
+)
+
+// ValidationRule checks the content of the files matching Patterns.
+type ValidationRule struct {
+	// Files the rule applies to, as patterns matched by MatchesAny
+	Patterns []string
+	// What the content must be, e.g. "valid YAML"
+	Description string
+	// Check returns why content is invalid, or nil
+	Check func(content []byte) error
+}
+
+// Validation keeps invalid files, such as half-written configuration, from
+// reaching workloads.
+type Validation struct {
+	Rules []ValidationRule
+	// ValidationBlock or ValidationSkip; empty blocks
+	Policy string
+}
+
+// ValidationError is returned for a file failing a validation rule.
+type ValidationError struct {
+	// Slash-separated path of the file relative to the folder
+	Path string
+	// The Description of the failed rule
+	Description string
+	Err         error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("file %s is not %s: %v", redact.Name(e.Path), e.Description, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// FormatRule returns a rule requiring the files matching patterns to parse
+// as format: FormatYAML, FormatJSON or FormatPEM.
+func FormatRule(patterns []string, format string) (ValidationRule, error) {
+	var check func([]byte) error
+	switch format {
+	case FormatYAML:
+		check = checkYAML
+	case FormatJSON:
+		check = checkJSON
+	case FormatPEM:
+		check = checkPEM
+	default:
+		return ValidationRule{}, fmt.Errorf("unknown format %q", format)
+	}
+	return ValidationRule{Patterns: patterns, Description: "valid " + format, Check: check}, nil
+}
+
+// Validate checks the policy and the patterns of the rules.
+func (v Validation) Validate() error {
+	switch v.Policy {
+	case "", ValidationBlock, ValidationSkip:
+	default:
+		return fmt.Errorf("unknown validation policy %q", v.Policy)
+	}
+	for _, rule := range v.Rules {
+		if err := ValidatePatterns(rule.Patterns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// admits reports whether the file at relPath, a slash-separated path
+// relative to the folder, is synced given its content. It fails for
+// invalid files unless they are skipped.
+func (v Validation) admits(relPath string, content []byte) (bool, error) {
+	for _, rule := range v.Rules {
+		if !MatchesAny(rule.Patterns, relPath) {
+			continue
+		}
+		if err := rule.Check(content); err != nil {
+			err := &ValidationError{Path: relPath, Description: rule.Description, Err: err}
+			if v.Policy == ValidationSkip {
+				log.Printf("Warning: skipping invalid file: %v", err)
+				return false, nil
+			}
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// checkYAML parses every document of a YAML stream.
+func checkYAML(content []byte) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// checkJSON parses a single JSON value.
+func checkJSON(content []byte) error {
+	var value any
+	return json.Unmarshal(content, &value)
+}
+
+// checkPEM requires at least one PEM block and nothing but whitespace
+// around the blocks.
+func checkPEM(content []byte) error {
+	rest := bytes.TrimSpace(content)
+	if len(rest) == 0 {
+		return errors.New("no PEM block found")
+	}
+	for len(rest) > 0 {
+		block, remaining := pem.Decode(rest)
+		if block == nil {
+			return errors.New("no PEM block found, or data outside of PEM blocks")
+		}
+		rest = bytes.TrimSpace(remaining)
+	}
+	return nil
+}
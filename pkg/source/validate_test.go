@@ -0,0 +1,114 @@
+package source
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+const testPEM = `-----BEGIN CERTIFICATE-----
+MIIBszCCAVmgAwIBAgIUY2VydA==
+-----END CERTIFICATE-----
+`
+
+func TestFormatRule(t *testing.T) {
+	testCases := []struct {
+		name      string
+		format    string
+		content   string
+		expectErr bool
+	}{
+		{"yaml", FormatYAML, "key: value\nlist: [1, 2]\n", false},
+		{"yaml stream", FormatYAML, "a: 1\n---\nb: 2\n", false},
+		{"empty yaml", FormatYAML, "", false},
+		{"invalid yaml", FormatYAML, "key: [unclosed\n", true},
+		{"json", FormatJSON, `{"key": "value"}`, false},
+		{"invalid json", FormatJSON, `{"key": `, true},
+		{"json with trailing data", FormatJSON, `{} {}`, true},
+		{"pem", FormatPEM, testPEM, false},
+		{"pem chain", FormatPEM, testPEM + "\n" + testPEM, false},
+		{"empty pem", FormatPEM, "", true},
+		{"not pem", FormatPEM, "hunter2", true},
+		{"data after pem", FormatPEM, testPEM + "garbage", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, err := FormatRule(nil, tc.format)
+			if err != nil {
+				t.Fatalf("FormatRule failed: %v", err)
+			}
+			if err := rule.Check([]byte(tc.content)); (err != nil) != tc.expectErr {
+				t.Errorf("Check() error = %v, expectErr %v", err, tc.expectErr)
+			}
+		})
+	}
+
+	if _, err := FormatRule(nil, "xml"); err == nil {
+		t.Error("Expected an unknown format to fail")
+	}
+}
+
+func TestReadDirValidation(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.yaml":    {Data: []byte("key: value\n")},
+		"broken.yaml": {Data: []byte("key: [unclosed\n")},
+		"password":    {Data: []byte("hunter2")},
+	}
+	yamlRule, err := FormatRule([]string{"*.yaml"}, FormatYAML)
+	if err != nil {
+		t.Fatalf("FormatRule failed: %v", err)
+	}
+
+	testCases := []struct {
+		name      string
+		policy    string
+		expected  []string
+		expectErr bool
+	}{
+		{"block by default", "", nil, true},
+		{"block", ValidationBlock, nil, true},
+		{"skip", ValidationSkip, []string{"app.yaml", "password"}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &Reader{FS: fsys, Validation: Validation{Rules: []ValidationRule{yamlRule}, Policy: tc.policy}}
+			files, err := r.ReadDir(".")
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("ReadDir() error = %v, expectErr %v", err, tc.expectErr)
+			}
+			if tc.expectErr {
+				return
+			}
+			if len(files) != len(tc.expected) {
+				t.Errorf("Expected keys %v, got %v", tc.expected, files)
+			}
+			for _, key := range tc.expected {
+				if _, ok := files[key]; !ok {
+					t.Errorf("Missing key %s", key)
+				}
+			}
+		})
+	}
+}
+
+func TestValidationValidate(t *testing.T) {
+	testCases := []struct {
+		name       string
+		validation Validation
+		expectErr  bool
+	}{
+		{"empty", Validation{}, false},
+		{"skip", Validation{Policy: ValidationSkip}, false},
+		{"unknown policy", Validation{Policy: "sometimes"}, true},
+		{"invalid pattern", Validation{Rules: []ValidationRule{{Patterns: []string{"["}}}}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.validation.Validate(); (err != nil) != tc.expectErr {
+				t.Errorf("Validate() error = %v, expectErr %v", err, tc.expectErr)
+			}
+		})
+	}
+}
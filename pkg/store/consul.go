@@ -0,0 +1,198 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"go-file-secret-sync/pkg/redact"
+)
+
+// ConsulConfig configures a Consul target.
+type ConsulConfig struct {
+	// Address of the Consul agent; http://127.0.0.1:8500 when empty
+	Addr string
+	// Key prefix the Secrets are written below
+	Prefix string
+	// ACL token and datacenter, both optional
+	Token      string
+	Datacenter string
+	HTTPClient *http.Client
+}
+
+// Consul publishes synced files as keys in Consul KV. Every write is a
+// check-and-set against the ModifyIndex that was read, so concurrent
+// writers are detected instead of overwritten.
+type Consul struct {
+	addr       string
+	prefix     string
+	token      string
+	datacenter string
+	httpClient *http.Client
+
+	// Keys only hold the name of a Secret
+	claims nameClaims
+}
+
+// consulKVPair is an entry returned by the Consul KV API.
+type consulKVPair struct {
+	Key         string
+	Value       []byte
+	ModifyIndex uint64
+}
+
+// NewConsul creates a Consul target.
+func NewConsul(cfg ConsulConfig) *Consul {
+	addr := cfg.Addr
+	if addr == "" {
+		addr = "http://127.0.0.1:8500"
+	}
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Consul{
+		addr:       strings.TrimRight(addr, "/"),
+		prefix:     strings.Trim(cfg.Prefix, "/"),
+		token:      cfg.Token,
+		datacenter: cfg.Datacenter,
+		httpClient: httpClient,
+	}
+}
+
+// WriteSecret writes every key of data below <prefix>/<name>/ and deletes
+// the keys there that data no longer holds. Secrets of the same name from
+// different namespaces are refused.
+func (c *Consul) WriteSecret(ctx context.Context, namespace, name string, data map[string][]byte) error {
+	if err := c.claims.claim(namespace, name); err != nil {
+		return err
+	}
+	keyPrefix := path.Join(c.prefix, name) + "/"
+
+	current, err := c.list(ctx, keyPrefix)
+	if err != nil {
+		return err
+	}
+
+	changed := 0
+	for key, value := range data {
+		existing, exists := current[keyPrefix+key]
+		if exists && bytes.Equal(existing.Value, value) {
+			continue
+		}
+
+		// cas=0 only creates the key if it does not exist yet
+		var index uint64
+		if exists {
+			index = existing.ModifyIndex
+		}
+		if err := c.cas(ctx, http.MethodPut, keyPrefix+key, value, index); err != nil {
+			return err
+		}
+		changed++
+	}
+
+	// Remove keys for files that are gone
+	for fullKey, pair := range current {
+		if _, ok := data[strings.TrimPrefix(fullKey, keyPrefix)]; ok {
+			continue
+		}
+		if err := c.cas(ctx, http.MethodDelete, fullKey, nil, pair.ModifyIndex); err != nil {
+			return err
+		}
+		changed++
+	}
+
+	if changed == 0 {
+		log.Printf("Consul keys under %s are up to date", keyPrefix)
+		return nil
+	}
+
+	log.Printf("Updated %d consul keys under %s", changed, keyPrefix)
+	return nil
+}
+
+// list returns all keys directly managed under keyPrefix.
+func (c *Consul) list(ctx context.Context, keyPrefix string) (map[string]consulKVPair, error) {
+	query := url.Values{"recurse": {"true"}}
+	respBody, status, err := c.do(ctx, http.MethodGet, keyPrefix, query, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list consul keys under %s: %w", keyPrefix, err)
+	}
+
+	pairs := make(map[string]consulKVPair)
+	if status == http.StatusNotFound {
+		return pairs, nil
+	}
+
+	var entries []consulKVPair
+	if err := json.Unmarshal(respBody, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode consul keys under %s: %w", keyPrefix, err)
+	}
+	for _, entry := range entries {
+		pairs[entry.Key] = entry
+	}
+	return pairs, nil
+}
+
+// cas writes or deletes key only if its ModifyIndex still equals index.
+func (c *Consul) cas(ctx context.Context, method, key string, value []byte, index uint64) error {
+	query := url.Values{"cas": {strconv.FormatUint(index, 10)}}
+	respBody, _, err := c.do(ctx, method, key, query, value)
+	if err != nil {
+		return fmt.Errorf("failed to write consul key %s: %w", redact.Name(key), err)
+	}
+	if strings.TrimSpace(string(respBody)) != "true" {
+		return fmt.Errorf("consul key %s was modified concurrently (cas index %d)", redact.Name(key), index)
+	}
+	return nil
+}
+
+func (c *Consul) do(ctx context.Context, method, key string, query url.Values, body []byte) ([]byte, int, error) {
+	if c.datacenter != "" {
+		query.Set("dc", c.datacenter)
+	}
+	reqURL := c.addr + "/v1/kv/" + key + "?" + query.Encode()
+
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return nil, 0, err
+	}
+	if c.token != "" {
+		req.Header.Set("X-Consul-Token", c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, resp.StatusCode, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, resp.StatusCode, fmt.Errorf("consul returned %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return respBody, resp.StatusCode, nil
+}
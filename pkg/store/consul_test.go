@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeConsul is a minimal in-memory Consul KV store with CAS semantics.
+type fakeConsul struct {
+	mu    sync.Mutex
+	index uint64
+	kv    map[string]consulKVPair
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	switch r.Method {
+	case http.MethodGet:
+		var entries []consulKVPair
+		for k, pair := range f.kv {
+			if strings.HasPrefix(k, key) {
+				entries = append(entries, pair)
+			}
+		}
+		if len(entries) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(entries)
+	case http.MethodPut, http.MethodDelete:
+		cas, _ := strconv.ParseUint(r.URL.Query().Get("cas"), 10, 64)
+		if f.kv[key].ModifyIndex != cas {
+			w.Write([]byte("false"))
+			return
+		}
+		if r.Method == http.MethodDelete {
+			delete(f.kv, key)
+		} else {
+			value, _ := io.ReadAll(r.Body)
+			f.index++
+			f.kv[key] = consulKVPair{Key: key, Value: value, ModifyIndex: f.index}
+		}
+		w.Write([]byte("true"))
+	}
+}
+
+func TestConsulTargetSync(t *testing.T) {
+	consul := &fakeConsul{kv: map[string]consulKVPair{}}
+	server := httptest.NewServer(consul)
+	defer server.Close()
+
+	c := &Consul{addr: server.URL, prefix: "config", httpClient: server.Client()}
+	ctx := context.Background()
+
+	data := map[string][]byte{
+		"app.conf": []byte("debug=true"),
+		"old.conf": []byte("stale"),
+	}
+	if err := c.WriteSecret(ctx, "", "my-app", data); err != nil {
+		t.Fatalf("WriteSecret failed: %v", err)
+	}
+	if string(consul.kv["config/my-app/app.conf"].Value) != "debug=true" {
+		t.Errorf("Expected app.conf to be written, got %v", consul.kv)
+	}
+
+	// Changed key is updated, removed key is deleted, unchanged key untouched
+	unchangedIndex := consul.kv["config/my-app/app.conf"].ModifyIndex
+	data = map[string][]byte{
+		"app.conf": []byte("debug=true"),
+		"new.conf": []byte("fresh"),
+	}
+	if err := c.WriteSecret(ctx, "", "my-app", data); err != nil {
+		t.Fatalf("WriteSecret failed: %v", err)
+	}
+	if _, exists := consul.kv["config/my-app/old.conf"]; exists {
+		t.Error("Expected old.conf to be deleted")
+	}
+	if string(consul.kv["config/my-app/new.conf"].Value) != "fresh" {
+		t.Error("Expected new.conf to be written")
+	}
+	if consul.kv["config/my-app/app.conf"].ModifyIndex != unchangedIndex {
+		t.Error("Expected unchanged key not to be rewritten")
+	}
+
+	// Secrets of the same name from another namespace would overwrite it
+	if err := c.WriteSecret(ctx, "other", "my-app", data); err == nil {
+		t.Error("Expected a Secret of the same name from another namespace to be refused")
+	}
+}
+
+func TestConsulTargetCASConflict(t *testing.T) {
+	consul := &fakeConsul{kv: map[string]consulKVPair{}}
+	server := httptest.NewServer(consul)
+	defer server.Close()
+
+	c := &Consul{addr: server.URL, httpClient: server.Client()}
+
+	// Simulate another writer changing the key between list and write
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			w.Write([]byte("false"))
+			return
+		}
+		consul.ServeHTTP(w, r)
+	})
+
+	if err := c.WriteSecret(context.Background(), "", "my-app", map[string][]byte{"k": []byte("v")}); err == nil {
+		t.Error("Expected WriteSecret to fail on a CAS conflict")
+	}
+}
@@ -0,0 +1,244 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ManifestConfig configures a manifest target.
+type ManifestConfig struct {
+	// Directory the manifests are written to, one <name>.yaml per Secret;
+	// Stdout when empty
+	OutputDir string
+	// Where manifests go without OutputDir; os.Stdout when nil
+	Stdout io.Writer
+	// Write UTF-8 values as stringData instead of base64-encoded data
+	StringData bool
+	// Sort all fields instead of keeping the usual Kubernetes order
+	SortKeys bool
+	// Type of the Secrets; Opaque when empty
+	Type corev1.SecretType
+}
+
+// Manifest renders the Secret as a YAML manifest to a directory or stdout
+// instead of calling the Kubernetes API, so the output can be fed into a
+// Git repository or CI pipeline.
+type Manifest struct {
+	outputDir  string
+	stdout     io.Writer
+	stringData bool
+	sortKeys   bool
+	secretType corev1.SecretType
+	// Digest of the manifest last written for each Secret
+	last map[string][sha256.Size]byte
+}
+
+// NewManifest creates a manifest target.
+func NewManifest(cfg ManifestConfig) *Manifest {
+	stdout := cfg.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	return &Manifest{
+		outputDir:  cfg.OutputDir,
+		stdout:     stdout,
+		stringData: cfg.StringData,
+		sortKeys:   cfg.SortKeys,
+		secretType: cfg.Type,
+		last:       make(map[string][sha256.Size]byte),
+	}
+}
+
+// WriteSecret renders the manifest of the Secret and writes it, unless it
+// is the same as the one last written.
+func (m *Manifest) WriteSecret(ctx context.Context, namespace, name string, data map[string][]byte) error {
+	manifest, err := m.render(namespace, name, data)
+	if err != nil {
+		return fmt.Errorf("failed to render manifest: %w", err)
+	}
+	// The manifest holds the values in the clear or base64-encoded
+	defer clear(manifest)
+
+	digest := sha256.Sum256(manifest)
+	if last, ok := m.last[name]; ok && last == digest {
+		log.Printf("Manifest for secret %s is up to date", name)
+		return nil
+	}
+
+	if m.outputDir == "" {
+		if _, err := fmt.Fprintf(m.stdout, "---\n%s", manifest); err != nil {
+			return fmt.Errorf("failed to write manifest: %w", err)
+		}
+	} else {
+		// Only the owner may read the values
+		path := filepath.Join(m.outputDir, name+".yaml")
+		if err := WriteFileAtomic(path, manifest, 0600); err != nil {
+			return fmt.Errorf("failed to write manifest: %w", err)
+		}
+		log.Printf("Wrote manifest for secret %s to %s", name, path)
+	}
+
+	m.last[name] = digest
+	return nil
+}
+
+// render builds the Secret manifest. Fields keep the usual Kubernetes order
+// (apiVersion, kind, metadata, ...) unless sortKeys is set; data keys are
+// always sorted so the output is stable across syncs.
+func (m *Manifest) render(namespace, name string, data map[string][]byte) ([]byte, error) {
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "file-secret-sync",
+			},
+		},
+		Type: m.secretType,
+	}
+	if secret.Type == "" {
+		secret.Type = corev1.SecretTypeOpaque
+	}
+
+	for key, value := range data {
+		if m.stringData && utf8.Valid(value) {
+			if secret.StringData == nil {
+				secret.StringData = make(map[string]string)
+			}
+			secret.StringData[key] = string(value)
+			continue
+		}
+		if secret.Data == nil {
+			secret.Data = make(map[string][]byte)
+		}
+		secret.Data[key] = value
+	}
+
+	// Go through JSON so the API field names and omitempty rules apply
+	jsonBytes, err := json.Marshal(secret)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(jsonBytes, &doc); err != nil {
+		return nil, err
+	}
+	root := doc.Content[0]
+
+	// apiVersion before kind, as kubectl prints it
+	if len(root.Content) >= 4 && root.Content[0].Value == "kind" && root.Content[2].Value == "apiVersion" {
+		root.Content[0], root.Content[1], root.Content[2], root.Content[3] =
+			root.Content[2], root.Content[3], root.Content[0], root.Content[1]
+	}
+	removeMappingKey(mappingValue(root, "metadata"), "creationTimestamp")
+	clearStyle(root)
+
+	if m.sortKeys {
+		sortMappingKeys(root)
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// mappingValue returns the value node for key in a mapping node.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// removeMappingKey deletes key and its value from a mapping node.
+func removeMappingKey(node *yaml.Node, key string) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// clearStyle drops the JSON flow style so the tree is emitted as block YAML.
+func clearStyle(node *yaml.Node) {
+	node.Style &^= yaml.FlowStyle | yaml.DoubleQuotedStyle
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
+
+// sortMappingKeys sorts all mapping nodes in the tree by key.
+func sortMappingKeys(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		pairs := make([][2]*yaml.Node, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			pairs = append(pairs, [2]*yaml.Node{node.Content[i], node.Content[i+1]})
+		}
+		sort.Slice(pairs, func(i, j int) bool {
+			return pairs[i][0].Value < pairs[j][0].Value
+		})
+		node.Content = node.Content[:0]
+		for _, pair := range pairs {
+			node.Content = append(node.Content, pair[0], pair[1])
+		}
+	}
+	for _, child := range node.Content {
+		sortMappingKeys(child)
+	}
+}
+
+// WriteFileAtomic writes data to a temporary file next to path and renames
+// it into place, so readers never observe a partially written file.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,120 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestManifestRender(t *testing.T) {
+	m := &Manifest{}
+	data := map[string][]byte{
+		"password": []byte("s3cr3t"),
+		"binary":   {0xff, 0xfe},
+	}
+
+	manifest, err := m.render("test-namespace", "test-secret", data)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	expected := `apiVersion: v1
+kind: Secret
+metadata:
+  name: test-secret
+  namespace: test-namespace
+  labels:
+    app.kubernetes.io/managed-by: file-secret-sync
+data:
+  binary: //4=
+  password: czNjcjN0
+type: Opaque
+`
+	if string(manifest) != expected {
+		t.Errorf("Unexpected manifest:\n%s\nexpected:\n%s", manifest, expected)
+	}
+
+	// stringData for text values, sorted keys throughout
+	m = &Manifest{stringData: true, sortKeys: true}
+	manifest, err = m.render("test-namespace", "test-secret", data)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	expected = `apiVersion: v1
+data:
+  binary: //4=
+kind: Secret
+metadata:
+  labels:
+    app.kubernetes.io/managed-by: file-secret-sync
+  name: test-secret
+  namespace: test-namespace
+stringData:
+  password: s3cr3t
+type: Opaque
+`
+	if string(manifest) != expected {
+		t.Errorf("Unexpected manifest:\n%s\nexpected:\n%s", manifest, expected)
+	}
+}
+
+func TestManifestWriteSecret(t *testing.T) {
+	var stdout bytes.Buffer
+	m := NewManifest(ManifestConfig{Stdout: &stdout, StringData: true})
+	data := map[string][]byte{"config.yaml": []byte("a: 1\nb: 2\n")}
+
+	ctx := context.Background()
+	if err := m.WriteSecret(ctx, "test-namespace", "test-secret", data); err != nil {
+		t.Fatalf("WriteSecret failed: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "config.yaml: |\n    a: 1\n    b: 2\n") {
+		t.Errorf("Expected multi-line stringData in stdout manifest, got:\n%s", stdout.String())
+	}
+
+	// Unchanged data is not printed again
+	stdout.Reset()
+	if err := m.WriteSecret(ctx, "test-namespace", "test-secret", data); err != nil {
+		t.Fatalf("WriteSecret failed: %v", err)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("Expected no output for unchanged data, got:\n%s", stdout.String())
+	}
+
+	// Directory output
+	outputDir := t.TempDir()
+	m = NewManifest(ManifestConfig{OutputDir: outputDir})
+	if err := m.WriteSecret(ctx, "test-namespace", "test-secret", map[string][]byte{"k": []byte("v")}); err != nil {
+		t.Fatalf("WriteSecret failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(outputDir, "test-secret.yaml"))
+	if err != nil {
+		t.Fatalf("Expected manifest file to be written: %v", err)
+	}
+	if !strings.Contains(string(content), "k: dg==") {
+		t.Errorf("Unexpected manifest file content:\n%s", content)
+	}
+	info, err := os.Stat(filepath.Join(outputDir, "test-secret.yaml"))
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("Expected manifest file mode 0600, got %o", mode)
+	}
+}
+
+func TestManifestRenderType(t *testing.T) {
+	m := NewManifest(ManifestConfig{Type: corev1.SecretTypeDockerConfigJson})
+	manifest, err := m.render("test-namespace", "pull-secret", map[string][]byte{".dockerconfigjson": []byte(`{"auths":{}}`)})
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if !strings.Contains(string(manifest), "type: kubernetes.io/dockerconfigjson\n") {
+		t.Errorf("Expected the dockerconfigjson type, got:\n%s", manifest)
+	}
+}
@@ -0,0 +1,41 @@
+// Package store writes synced data to destinations other than Kubernetes
+// Secrets: Vault KV, Consul KV and Secret manifests for GitOps.
+package store
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Target is a destination for synced data. Every sync calls WriteSecret
+// with the complete data of each Secret, so targets can skip writes of
+// unchanged data. The sync zeroes data once WriteSecret returns, so
+// targets must not keep it.
+type Target interface {
+	WriteSecret(ctx context.Context, namespace, name string, data map[string][]byte) error
+}
+
+// nameClaims remembers the namespace each Secret name is written from, for
+// targets whose paths only hold the name, so that mappings of different
+// namespaces writing Secrets of the same name fail instead of overwriting
+// each other. The zero value is ready to use.
+type nameClaims struct {
+	mu         sync.Mutex
+	namespaces map[string]string
+}
+
+// claim records that namespace writes name, unless another namespace
+// already does.
+func (c *nameClaims) claim(namespace, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.namespaces == nil {
+		c.namespaces = make(map[string]string)
+	}
+	if claimed, ok := c.namespaces[name]; ok && claimed != namespace {
+		return fmt.Errorf("secret %s is written from namespaces %s and %s, which would overwrite each other", name, claimed, namespace)
+	}
+	c.namespaces[name] = namespace
+	return nil
+}
@@ -0,0 +1,326 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// base64FieldsMetadata is the key of the custom metadata of a KV secret
+// listing, comma-separated, the fields stored base64-encoded because
+// their files are not valid UTF-8 and KV values are strings.
+const base64FieldsMetadata = "file-secret-sync/base64-fields"
+
+// maxCustomMetadataValue is the longest value Vault accepts in custom
+// metadata.
+const maxCustomMetadataValue = 512
+
+// ServiceAccountTokenPath is the token of the pod's service account, used
+// to log in with the Kubernetes auth method.
+const ServiceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// VaultConfig configures a Vault target.
+type VaultConfig struct {
+	// Address of the Vault server
+	Addr string
+	// Mount of the KV v2 engine and the path secrets are written below
+	Mount      string
+	PathPrefix string
+	// Vault Enterprise namespace
+	Namespace string
+	// Mount of the Kubernetes auth method and the role to log in as;
+	// unused when Token is set
+	AuthPath string
+	Role     string
+	// Service account token to log in with; ServiceAccountTokenPath when
+	// empty
+	TokenPath string
+	// Static token used instead of logging in
+	Token      string
+	HTTPClient *http.Client
+}
+
+// Vault writes synced files as fields of a Vault KV v2 secret,
+// authenticating with the Kubernetes auth method.
+type Vault struct {
+	addr       string
+	mount      string
+	pathPrefix string
+	namespace  string
+	authPath   string
+	role       string
+	tokenPath  string
+	httpClient *http.Client
+
+	token       string
+	tokenExpiry time.Time
+
+	// Paths only hold the name of a Secret
+	claims nameClaims
+}
+
+// NewVault creates a Vault target.
+func NewVault(cfg VaultConfig) (*Vault, error) {
+	if cfg.Addr == "" {
+		return nil, fmt.Errorf("a Vault address is required")
+	}
+	if cfg.Token == "" && cfg.Role == "" {
+		return nil, fmt.Errorf("a Vault role or token is required")
+	}
+
+	v := &Vault{
+		addr:       strings.TrimRight(cfg.Addr, "/"),
+		mount:      cfg.Mount,
+		pathPrefix: cfg.PathPrefix,
+		namespace:  cfg.Namespace,
+		authPath:   cfg.AuthPath,
+		role:       cfg.Role,
+		tokenPath:  cfg.TokenPath,
+		token:      cfg.Token,
+		httpClient: cfg.HTTPClient,
+	}
+	if v.mount == "" {
+		v.mount = "secret"
+	}
+	if v.authPath == "" {
+		v.authPath = "kubernetes"
+	}
+	if v.tokenPath == "" {
+		v.tokenPath = ServiceAccountTokenPath
+	}
+	if v.httpClient == nil {
+		v.httpClient = http.DefaultClient
+	}
+	return v, nil
+}
+
+// WriteSecret writes data as the fields of the KV secret at name below the
+// path prefix, unless it already holds them. Secrets of the same name from
+// different namespaces are refused.
+func (v *Vault) WriteSecret(ctx context.Context, namespace, name string, data map[string][]byte) error {
+	if err := v.claims.claim(namespace, name); err != nil {
+		return err
+	}
+	secretPath := path.Join(v.pathPrefix, name)
+
+	fields := make(map[string]string, len(data))
+	var encoded []string
+	for key, value := range data {
+		// KV values are JSON strings; binary content is stored base64-encoded
+		if utf8.Valid(value) {
+			fields[key] = string(value)
+		} else {
+			fields[key] = base64.StdEncoding.EncodeToString(value)
+			encoded = append(encoded, key)
+		}
+	}
+	sort.Strings(encoded)
+	base64Fields := strings.Join(encoded, ",")
+	if len(base64Fields) > maxCustomMetadataValue {
+		return fmt.Errorf("vault secret %s has too many binary fields to record which are base64-encoded", secretPath)
+	}
+
+	current, version, currentBase64Fields, err := v.read(ctx, secretPath)
+	if err != nil {
+		return err
+	}
+	dataChanged := current == nil || !equalStringMaps(current, fields)
+	if !dataChanged && currentBase64Fields == base64Fields {
+		log.Printf("Vault secret %s/%s is up to date", v.mount, secretPath)
+		return nil
+	}
+	if !dataChanged {
+		return v.writeBase64Fields(ctx, secretPath, base64Fields)
+	}
+
+	// Check-and-set against the version we read to avoid clobbering
+	// concurrent writers
+	body := map[string]interface{}{
+		"data":    fields,
+		"options": map[string]interface{}{"cas": version},
+	}
+	if _, err := v.request(ctx, http.MethodPost, "/v1/"+v.mount+"/data/"+secretPath, body); err != nil {
+		return fmt.Errorf("failed to write vault secret %s: %w", secretPath, err)
+	}
+	if currentBase64Fields != base64Fields {
+		if err := v.writeBase64Fields(ctx, secretPath, base64Fields); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Updated vault secret %s/%s with %d files", v.mount, secretPath, len(fields))
+	return nil
+}
+
+// writeBase64Fields records in the custom metadata of a KV v2 secret which
+// of its fields are base64-encoded, removing the entry when none are. The
+// other custom metadata is kept.
+func (v *Vault) writeBase64Fields(ctx context.Context, secretPath, fields string) error {
+	var value interface{}
+	if fields != "" {
+		value = fields
+	}
+	body := map[string]interface{}{
+		"custom_metadata": map[string]interface{}{base64FieldsMetadata: value},
+	}
+	if _, err := v.request(ctx, http.MethodPatch, "/v1/"+v.mount+"/metadata/"+secretPath, body); err != nil {
+		return fmt.Errorf("failed to record base64-encoded fields of vault secret %s: %w", secretPath, err)
+	}
+	return nil
+}
+
+// read returns the current fields, version and base64-encoded fields of a
+// KV v2 secret. A missing secret returns nil fields and version 0.
+func (v *Vault) read(ctx context.Context, secretPath string) (map[string]string, int, string, error) {
+	respBody, err := v.request(ctx, http.MethodGet, "/v1/"+v.mount+"/data/"+secretPath, nil)
+	if err != nil {
+		if isVaultNotFound(err) {
+			return nil, 0, "", nil
+		}
+		return nil, 0, "", fmt.Errorf("failed to read vault secret %s: %w", secretPath, err)
+	}
+
+	var resp struct {
+		Data struct {
+			Data     map[string]string `json:"data"`
+			Metadata struct {
+				Version        int               `json:"version"`
+				CustomMetadata map[string]string `json:"custom_metadata"`
+			} `json:"metadata"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, 0, "", fmt.Errorf("failed to decode vault secret %s: %w", secretPath, err)
+	}
+	return resp.Data.Data, resp.Data.Metadata.Version, resp.Data.Metadata.CustomMetadata[base64FieldsMetadata], nil
+}
+
+// login authenticates with the Kubernetes auth method using the pod's
+// service account token.
+func (v *Vault) login(ctx context.Context) error {
+	jwt, err := os.ReadFile(v.tokenPath)
+	if err != nil {
+		return fmt.Errorf("failed to read service account token: %w", err)
+	}
+
+	body := map[string]string{
+		"role": v.role,
+		"jwt":  strings.TrimSpace(string(jwt)),
+	}
+	respBody, err := v.do(ctx, http.MethodPost, "/v1/auth/"+v.authPath+"/login", body, "")
+	if err != nil {
+		return fmt.Errorf("vault login failed: %w", err)
+	}
+
+	var resp struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return fmt.Errorf("failed to decode vault login response: %w", err)
+	}
+	if resp.Auth.ClientToken == "" {
+		return fmt.Errorf("vault login returned no token")
+	}
+
+	v.token = resp.Auth.ClientToken
+	// Log in again a little before the lease runs out
+	v.tokenExpiry = time.Now().Add(time.Duration(resp.Auth.LeaseDuration) * time.Second * 9 / 10)
+	log.Printf("Logged in to vault with role %s", v.role)
+	return nil
+}
+
+// request performs an authenticated Vault API call, logging in first when
+// the token is missing or about to expire.
+func (v *Vault) request(ctx context.Context, method, apiPath string, body interface{}) ([]byte, error) {
+	if v.role != "" && (v.token == "" || time.Now().After(v.tokenExpiry)) {
+		if err := v.login(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return v.do(ctx, method, apiPath, body, v.token)
+}
+
+// vaultError is a non-2xx response from the Vault API.
+type vaultError struct {
+	StatusCode int
+	Errors     []string `json:"errors"`
+}
+
+func (e *vaultError) Error() string {
+	return fmt.Sprintf("vault returned %d: %s", e.StatusCode, strings.Join(e.Errors, "; "))
+}
+
+func isVaultNotFound(err error) bool {
+	vaultErr, ok := err.(*vaultError)
+	return ok && vaultErr.StatusCode == http.StatusNotFound
+}
+
+func (v *Vault) do(ctx context.Context, method, apiPath string, body interface{}, token string) ([]byte, error) {
+	var reqBody io.Reader
+	if body != nil {
+		jsonBody, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(jsonBody)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, v.addr+apiPath, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if method == http.MethodPatch {
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if v.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.namespace)
+	}
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		vaultErr := &vaultError{StatusCode: resp.StatusCode}
+		json.Unmarshal(respBody, vaultErr)
+		return nil, vaultErr
+	}
+	return respBody, nil
+}
+
+// equalStringMaps reports whether a and b hold the same keys and values.
+func equalStringMaps(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range b {
+		if other, ok := a[key]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,219 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeVault is a minimal in-memory Vault serving Kubernetes auth and KV v2.
+type fakeVault struct {
+	mu       sync.Mutex
+	logins   int
+	writes   int
+	data     map[string]map[string]string
+	version  map[string]int
+	metadata map[string]map[string]string
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/v1/auth/kubernetes/login" {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["role"] != "sync" || body["jwt"] != "sa-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		f.logins++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth": map[string]interface{}{"client_token": "vault-token", "lease_duration": 3600},
+		})
+		return
+	}
+
+	if r.Header.Get("X-Vault-Token") != "vault-token" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	if secretPath, ok := strings.CutPrefix(r.URL.Path, "/v1/secret/metadata/"); ok && r.Method == http.MethodPatch {
+		var body struct {
+			CustomMetadata map[string]*string `json:"custom_metadata"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if f.metadata[secretPath] == nil {
+			f.metadata[secretPath] = make(map[string]string)
+		}
+		for key, value := range body.CustomMetadata {
+			if value == nil {
+				delete(f.metadata[secretPath], key)
+			} else {
+				f.metadata[secretPath][key] = *value
+			}
+		}
+		w.Write([]byte(`{}`))
+		return
+	}
+
+	secretPath := r.URL.Path[len("/v1/secret/data/"):]
+	switch r.Method {
+	case http.MethodGet:
+		data, ok := f.data[secretPath]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"data":     data,
+				"metadata": map[string]interface{}{"version": f.version[secretPath], "custom_metadata": f.metadata[secretPath]},
+			},
+		})
+	case http.MethodPost:
+		var body struct {
+			Data    map[string]string `json:"data"`
+			Options struct {
+				CAS int `json:"cas"`
+			} `json:"options"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Options.CAS != f.version[secretPath] {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":["check-and-set parameter did not match the current version"]}`))
+			return
+		}
+		f.writes++
+		f.data[secretPath] = body.Data
+		f.version[secretPath]++
+		w.Write([]byte(`{}`))
+	}
+}
+
+func TestVaultTargetSync(t *testing.T) {
+	vault := &fakeVault{data: map[string]map[string]string{}, version: map[string]int{}, metadata: map[string]map[string]string{}}
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenPath, []byte("sa-token\n"), 0600); err != nil {
+		t.Fatalf("Failed to write token: %v", err)
+	}
+
+	v := &Vault{
+		addr:       server.URL,
+		mount:      "secret",
+		pathPrefix: "apps",
+		authPath:   "kubernetes",
+		role:       "sync",
+		tokenPath:  tokenPath,
+		httpClient: server.Client(),
+	}
+
+	ctx := context.Background()
+	data := map[string][]byte{
+		"username": []byte("admin"),
+		"binary":   {0xff, 0x00},
+	}
+	if err := v.WriteSecret(ctx, "", "test-secret", data); err != nil {
+		t.Fatalf("WriteSecret failed: %v", err)
+	}
+
+	stored := vault.data["apps/test-secret"]
+	if stored["username"] != "admin" || stored["binary"] != "/wA=" {
+		t.Errorf("Unexpected vault data: %v", stored)
+	}
+	if fields := vault.metadata["apps/test-secret"][base64FieldsMetadata]; fields != "binary" {
+		t.Errorf("Expected the base64-encoded field to be recorded, got %q", fields)
+	}
+
+	// Unchanged data is not written again, changed data uses CAS
+	if err := v.WriteSecret(ctx, "", "test-secret", data); err != nil {
+		t.Fatalf("WriteSecret failed: %v", err)
+	}
+	data["username"] = []byte("root")
+	if err := v.WriteSecret(ctx, "", "test-secret", data); err != nil {
+		t.Fatalf("WriteSecret failed: %v", err)
+	}
+
+	if vault.writes != 2 {
+		t.Errorf("Expected 2 writes, got %d", vault.writes)
+	}
+	if vault.logins != 1 {
+		t.Errorf("Expected a single login, got %d", vault.logins)
+	}
+	if vault.data["apps/test-secret"]["username"] != "root" {
+		t.Errorf("Vault secret was not updated")
+	}
+
+	// The record is removed with the last binary field
+	delete(data, "binary")
+	if err := v.WriteSecret(ctx, "", "test-secret", data); err != nil {
+		t.Fatalf("WriteSecret failed: %v", err)
+	}
+	if fields, ok := vault.metadata["apps/test-secret"][base64FieldsMetadata]; ok {
+		t.Errorf("Expected no base64-encoded fields to be recorded, got %q", fields)
+	}
+
+	// Secrets of the same name from another namespace would overwrite it
+	if err := v.WriteSecret(ctx, "other", "test-secret", data); err == nil {
+		t.Error("Expected a Secret of the same name from another namespace to be refused")
+	}
+}
+
+func TestVaultTargetLoginFailure(t *testing.T) {
+	server := httptest.NewServer(&fakeVault{})
+	defer server.Close()
+
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	os.WriteFile(tokenPath, []byte("wrong-token"), 0600)
+
+	v := &Vault{
+		addr:       server.URL,
+		mount:      "secret",
+		authPath:   "kubernetes",
+		role:       "sync",
+		tokenPath:  tokenPath,
+		httpClient: server.Client(),
+	}
+
+	if err := v.WriteSecret(context.Background(), "", "test-secret", map[string][]byte{"k": []byte("v")}); err == nil {
+		t.Error("Expected WriteSecret to fail when login is rejected")
+	}
+}
+
+func TestNewVault(t *testing.T) {
+	testCases := []struct {
+		name  string
+		cfg   VaultConfig
+		valid bool
+	}{
+		{"role", VaultConfig{Addr: "https://vault:8200/", Role: "sync"}, true},
+		{"token", VaultConfig{Addr: "https://vault:8200", Token: "s.token"}, true},
+		{"no address", VaultConfig{Role: "sync"}, false},
+		{"no credentials", VaultConfig{Addr: "https://vault:8200"}, false},
+	}
+
+	for _, tc := range testCases {
+		v, err := NewVault(tc.cfg)
+		if (err == nil) != tc.valid {
+			t.Errorf("%s: expected valid=%v, got %v", tc.name, tc.valid, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if v.addr != "https://vault:8200" || v.mount != "secret" || v.authPath != "kubernetes" || v.tokenPath != ServiceAccountTokenPath {
+			t.Errorf("%s: unexpected defaults %+v", tc.name, v)
+		}
+	}
+}
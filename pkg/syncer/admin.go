@@ -0,0 +1,119 @@
+package syncer
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// mappingInfo describes a configured folder mapping for the admin API.
+type mappingInfo struct {
+	Folder    string   `json:"folder"`
+	Namespace string   `json:"namespace"`
+	Secret    string   `json:"secret,omitempty"`
+	Mode      string   `json:"mode"`
+	Include   []string `json:"include,omitempty"`
+	Exclude   []string `json:"exclude,omitempty"`
+}
+
+// registerAdminAPI adds the admin API to mux. Every request must carry
+// token as a bearer token.
+func (s *syncerState) registerAdminAPI(mux *http.ServeMux, token string) {
+	mux.Handle("POST /api/sync", requireToken(token, http.HandlerFunc(s.health.serveTriggerSync)))
+	mux.Handle("GET /api/status", requireToken(token, http.HandlerFunc(s.health.serveStatus)))
+	mux.Handle("GET /api/mappings", requireToken(token, http.HandlerFunc(s.health.serveMappings)))
+	mux.Handle("GET /api/events", requireToken(token, http.HandlerFunc(s.events.serveEvents)))
+	mux.Handle("POST /api/promote", requireToken(token, http.HandlerFunc(s.health.serveApprovePromotion)))
+}
+
+// requireToken rejects requests without the bearer token.
+func requireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !bearerTokenValid(r.Header.Get("Authorization"), token) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bearerTokenValid reports whether the authorization header value carries
+// token as a bearer token.
+func bearerTokenValid(authorization, token string) bool {
+	presented, ok := strings.CutPrefix(authorization, "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(presented), []byte(token)) == 1
+}
+
+// triggerSync asks every mapping to sync now and returns how many were
+// asked.
+func (h *healthState) triggerSync() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for fss := range h.mappings {
+		if fss.syncNow == nil {
+			continue
+		}
+		select {
+		case fss.syncNow <- struct{}{}:
+		default:
+			// A sync is already requested
+		}
+	}
+	return len(h.mappings)
+}
+
+// mappingInfos returns the configuration of every mapping, sorted like
+// statuses.
+func (h *healthState) mappingInfos() []mappingInfo {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	infos := make([]mappingInfo, 0, len(h.mappings))
+	for fss := range h.mappings {
+		infos = append(infos, mappingInfo{
+			Folder:    fss.folderPath,
+			Namespace: fss.namespace,
+			Secret:    fss.secretName,
+			Mode:      fss.secretMode,
+			Include:   fss.reader.Filter.Include,
+			Exclude:   fss.reader.Filter.Exclude,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Folder != infos[j].Folder {
+			return infos[i].Folder < infos[j].Folder
+		}
+		return infos[i].Namespace+"/"+infos[i].Secret < infos[j].Namespace+"/"+infos[j].Secret
+	})
+	return infos
+}
+
+func (h *healthState) serveTriggerSync(w http.ResponseWriter, r *http.Request) {
+	n := h.triggerSync()
+	log.Printf("Sync of %d mappings requested through the admin API", n)
+	writeJSON(w, http.StatusAccepted, map[string]int{"mappings": n})
+}
+
+func (h *healthState) serveStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, struct {
+		Ready    bool            `json:"ready"`
+		Mappings []mappingStatus `json:"mappings"`
+	}{h.ready(), h.statuses()})
+}
+
+func (h *healthState) serveMappings(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.mappingInfos())
+}
+
+// writeJSON writes value as the JSON response body.
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
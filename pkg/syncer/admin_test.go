@@ -0,0 +1,112 @@
+package syncer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-file-secret-sync/pkg/source"
+)
+
+func TestAdminAPI(t *testing.T) {
+	state := newSyncerState()
+	mux := state.newHTTPMux()
+	state.registerAdminAPI(mux, "secret-token")
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fss := &FileSecretSync{
+		folderPath: "/secrets/admin",
+		namespace:  "test-namespace",
+		secretName: "admin-secret",
+		secretMode: "single",
+		reader:     source.Reader{Filter: source.Filter{Include: []string{"*.pem"}}},
+		syncNow:    make(chan struct{}, 1),
+		state:      state,
+	}
+	state.health.track(fss)
+
+	request := func(method, path, token string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(method, server.URL+path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, path, err)
+		}
+		return resp
+	}
+
+	testCases := []struct {
+		method   string
+		path     string
+		token    string
+		expected int
+	}{
+		{http.MethodGet, "/api/status", "", http.StatusUnauthorized},
+		{http.MethodGet, "/api/status", "wrong-token", http.StatusUnauthorized},
+		{http.MethodPost, "/api/sync", "", http.StatusUnauthorized},
+		{http.MethodGet, "/api/sync", "secret-token", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/api/status", "secret-token", http.StatusOK},
+		{http.MethodGet, "/api/mappings", "secret-token", http.StatusOK},
+	}
+	for _, tc := range testCases {
+		resp := request(tc.method, tc.path, tc.token)
+		resp.Body.Close()
+		if resp.StatusCode != tc.expected {
+			t.Errorf("%s %s with token %q: expected status %d, got %d", tc.method, tc.path, tc.token, tc.expected, resp.StatusCode)
+		}
+	}
+	select {
+	case <-fss.syncNow:
+		t.Error("Expected no sync to be requested without a valid token")
+	default:
+	}
+
+	// Triggering a sync signals every mapping
+	resp := request(http.MethodPost, "/api/sync", "secret-token")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("Expected status %d, got %d", http.StatusAccepted, resp.StatusCode)
+	}
+	select {
+	case <-fss.syncNow:
+	default:
+		t.Error("Expected a sync to be requested")
+	}
+
+	resp = request(http.MethodGet, "/api/mappings", "secret-token")
+	var mappings []mappingInfo
+	if err := json.NewDecoder(resp.Body).Decode(&mappings); err != nil {
+		t.Fatalf("Failed to decode mappings: %v", err)
+	}
+	resp.Body.Close()
+	found := false
+	for _, m := range mappings {
+		if m.Folder == fss.folderPath {
+			found = true
+			if m.Secret != "admin-secret" || m.Mode != "single" || len(m.Include) != 1 {
+				t.Errorf("Unexpected mapping: %+v", m)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Expected mapping for %s, got %+v", fss.folderPath, mappings)
+	}
+
+	resp = request(http.MethodGet, "/api/status", "secret-token")
+	var status struct {
+		Ready    bool            `json:"ready"`
+		Mappings []mappingStatus `json:"mappings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		t.Fatalf("Failed to decode status: %v", err)
+	}
+	resp.Body.Close()
+	if status.Ready {
+		t.Error("Expected not ready before the initial sync")
+	}
+}
@@ -0,0 +1,142 @@
+package syncer
+
+import (
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"go-file-secret-sync/pkg/redact"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// reloaderMatchAnnotation opts a Secret into stakater/Reloader's search
+// mode, so workloads annotated with reloader.stakater.com/search restart
+// when it changes.
+const reloaderMatchAnnotation = "reloader.stakater.com/match"
+
+// Argo CD annotations that keep an application from reporting a Secret it
+// did not create as OutOfSync, and from pruning it.
+const (
+	argoCDCompareOptionsAnnotation = "argocd.argoproj.io/compare-options"
+	argoCDSyncOptionsAnnotation    = "argocd.argoproj.io/sync-options"
+)
+
+// Status annotations stamped on every write, so consumers can see on the
+// Secret itself when, from which files and by whom it was last written.
+const (
+	lastSyncTimeAnnotation   = "file-secret-sync/last-sync-time"
+	sourceChecksumAnnotation = "file-secret-sync/source-checksum"
+	syncedByAnnotation       = "file-secret-sync/synced-by"
+)
+
+// keyAnnotationPrefixes are followed by a key to form annotations
+// describing that key, which are removed along with the key.
+var keyAnnotationPrefixes = []string{contentTypeAnnotationPrefix, keyChecksumAnnotationPrefix}
+
+// keyAnnotations returns an annotation named prefix followed by the key
+// for every key in data, set to what value returns for it. Keys too long
+// or otherwise unfit for an annotation name are left out.
+func keyAnnotations(prefix string, data map[string][]byte, value func(key string, content []byte) string) map[string]string {
+	annotations := make(map[string]string, len(data))
+	for key, content := range data {
+		name := prefix + key
+		if errs := validation.IsQualifiedName(name); len(errs) > 0 {
+			log.Printf("Not annotating key %s with %s: %s", redact.Name(key), strings.TrimSuffix(prefix, "."), strings.Join(errs, ", "))
+			continue
+		}
+		annotations[name] = value(key, content)
+	}
+	return annotations
+}
+
+// desiredAnnotations returns the annotations that must be present on a
+// Secret holding data. The touch annotation is not included because it
+// changes on every write.
+func (fss *FileSecretSync) desiredAnnotations(data map[string][]byte) map[string]string {
+	annotations := map[string]string{
+		checksumAnnotation: dataChecksum(data),
+	}
+	if fss.reloaderMatch {
+		annotations[reloaderMatchAnnotation] = "true"
+	}
+	if fss.argoCD {
+		annotations[argoCDCompareOptionsAnnotation] = "IgnoreExtraneous"
+		annotations[argoCDSyncOptionsAnnotation] = "Prune=false"
+	}
+	if fss.instanceID != "" {
+		annotations[instanceAnnotation] = fss.instanceID
+	}
+	if fss.contentTypes {
+		maps.Copy(annotations, contentTypeAnnotations(data))
+	}
+	if fss.keyChecksums {
+		maps.Copy(annotations, keyChecksumAnnotations(data))
+	}
+	return annotations
+}
+
+// hasKeyAnnotationPrefix reports whether the annotation name describes a
+// single key.
+func hasKeyAnnotationPrefix(name string) bool {
+	return slices.ContainsFunc(keyAnnotationPrefixes, func(prefix string) bool {
+		return strings.HasPrefix(name, prefix)
+	})
+}
+
+// annotationsOutdated reports whether meta lacks any desired annotation.
+func (fss *FileSecretSync) annotationsOutdated(meta metav1.ObjectMeta, data map[string][]byte) bool {
+	for key, value := range fss.desiredAnnotations(data) {
+		if meta.Annotations[key] != value {
+			return true
+		}
+	}
+	return false
+}
+
+// stampAnnotations sets the desired annotations and the status
+// annotations on meta, and the touch annotation to the current time when
+// one is configured.
+func (fss *FileSecretSync) stampAnnotations(meta *metav1.ObjectMeta, data map[string][]byte) {
+	if meta.Annotations == nil {
+		meta.Annotations = make(map[string]string)
+	}
+	desired := fss.desiredAnnotations(data)
+	for key := range meta.Annotations {
+		// Annotations of keys that were removed, or no longer wanted
+		if _, ok := desired[key]; !ok && hasKeyAnnotationPrefix(key) {
+			delete(meta.Annotations, key)
+		}
+	}
+	for key, value := range desired {
+		meta.Annotations[key] = value
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	meta.Annotations[lastSyncTimeAnnotation] = now
+	if fss.sourceChecksum != "" {
+		meta.Annotations[sourceChecksumAnnotation] = fss.sourceChecksum
+	} else {
+		delete(meta.Annotations, sourceChecksumAnnotation)
+	}
+	if fss.syncedBy != "" {
+		meta.Annotations[syncedByAnnotation] = fss.syncedBy
+	}
+	if fss.touchAnnotation != "" {
+		meta.Annotations[fss.touchAnnotation] = now
+	}
+}
+
+// syncIdentity names this instance for the synced-by annotation. In
+// Kubernetes the hostname is the pod name.
+func syncIdentity() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	return name
+}
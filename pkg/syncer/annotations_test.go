@@ -0,0 +1,110 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSyncFilesReloaderAnnotations(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("test: value"), 0644)
+	data := map[string][]byte{"config.yaml": []byte("test: value")}
+
+	// Up-to-date data, but written without the Reloader annotation
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-secret",
+			Namespace:   "test-namespace",
+			Annotations: map[string]string{checksumAnnotation: dataChecksum(data)},
+		},
+		Data: data,
+	})
+	fss := &FileSecretSync{
+		client:          client,
+		namespace:       "test-namespace",
+		secretName:      "test-secret",
+		folderPath:      tempDir,
+		reloaderMatch:   true,
+		touchAnnotation: "example.com/updated-at",
+	}
+
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+
+	secret, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if secret.Annotations[reloaderMatchAnnotation] != "true" {
+		t.Errorf("Expected Reloader match annotation, got %v", secret.Annotations)
+	}
+	if secret.Annotations["example.com/updated-at"] == "" {
+		t.Errorf("Expected touch annotation, got %v", secret.Annotations)
+	}
+
+	// Once stamped, an unchanged folder does not touch the Secret again
+	if fss.annotationsOutdated(secret.ObjectMeta, data) {
+		t.Error("Expected annotations to be up to date after sync")
+	}
+}
+
+func TestDesiredAnnotationsArgoCD(t *testing.T) {
+	data := map[string][]byte{"config.yaml": []byte("test: value")}
+
+	fss := &FileSecretSync{}
+	if annotations := fss.desiredAnnotations(data); annotations[argoCDSyncOptionsAnnotation] != "" || annotations[argoCDCompareOptionsAnnotation] != "" {
+		t.Errorf("Expected no Argo CD annotations by default, got %v", annotations)
+	}
+
+	fss.argoCD = true
+	annotations := fss.desiredAnnotations(data)
+	if annotations[argoCDCompareOptionsAnnotation] != "IgnoreExtraneous" || annotations[argoCDSyncOptionsAnnotation] != "Prune=false" {
+		t.Errorf("Expected Argo CD annotations, got %v", annotations)
+	}
+
+	// Secrets written before the option was enabled are stamped too
+	meta := metav1.ObjectMeta{Annotations: map[string]string{checksumAnnotation: dataChecksum(data)}}
+	if !fss.annotationsOutdated(meta, data) {
+		t.Error("Expected a Secret without Argo CD annotations to be outdated")
+	}
+}
+
+func TestSyncFilesStatusAnnotations(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("test: value"), 0644)
+
+	client := fake.NewSimpleClientset()
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		folderPath: tempDir,
+		syncedBy:   "file-secret-sync-7d9f8-abcde",
+	}
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+
+	secret, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if _, err := time.Parse(time.RFC3339, secret.Annotations[lastSyncTimeAnnotation]); err != nil {
+		t.Errorf("Expected an RFC 3339 last sync time, got %v", secret.Annotations)
+	}
+	fingerprint, _ := fss.sourceFingerprint()
+	if secret.Annotations[sourceChecksumAnnotation] != "sha256:"+fingerprint {
+		t.Errorf("Expected source checksum sha256:%s, got %v", fingerprint, secret.Annotations)
+	}
+	if secret.Annotations[syncedByAnnotation] != "file-secret-sync-7d9f8-abcde" {
+		t.Errorf("Expected synced-by annotation, got %v", secret.Annotations)
+	}
+}
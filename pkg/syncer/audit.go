@@ -0,0 +1,225 @@
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-file-secret-sync/pkg/redact"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// auditKey is the ConfigMap key holding audit records, one JSON object
+// per line.
+const auditKey = "audit.jsonl"
+
+// auditRecord describes the changes a write made to a Secret. It holds
+// the sizes of the values, never the values or digests of them, which
+// would give away short values to anyone trying every candidate.
+type auditRecord struct {
+	Time      time.Time     `json:"time"`
+	Namespace string        `json:"namespace"`
+	Secret    string        `json:"secret"`
+	Folder    string        `json:"folder,omitempty"`
+	SyncedBy  string        `json:"syncedBy,omitempty"`
+	Changes   []auditChange `json:"changes"`
+}
+
+// auditChange describes one added, changed or removed key.
+type auditChange struct {
+	Key    string `json:"key"`
+	Change string `json:"change"`
+	// Size of the new value; absent for removed keys
+	Size *int `json:"size,omitempty"`
+	// Size of the previous value; absent for added keys
+	PreviousSize *int `json:"previousSize,omitempty"`
+}
+
+// auditLog writes audit records to the log, a file and a ConfigMap, as
+// configured. It is shared by all mappings.
+type auditLog struct {
+	// Log every record
+	log bool
+	// File records are appended to; empty appends to none
+	file string
+	// ConfigMap in namespace keeping the last maxEntries records
+	configMap  string
+	namespace  string
+	maxEntries int
+
+	mu sync.Mutex
+}
+
+// loadAuditLog configures the audit log from AUDIT_LOG, AUDIT_FILE,
+// AUDIT_CONFIGMAP and AUDIT_CONFIGMAP_ENTRIES. It returns nil when no
+// records are kept.
+func loadAuditLog(namespace string) (*auditLog, error) {
+	audit := &auditLog{
+		log:       os.Getenv("AUDIT_LOG") == "true",
+		file:      os.Getenv("AUDIT_FILE"),
+		configMap: os.Getenv("AUDIT_CONFIGMAP"),
+		namespace: namespace,
+	}
+	maxEntries, err := strconv.Atoi(envOrDefault("AUDIT_CONFIGMAP_ENTRIES", "100"))
+	if err != nil || maxEntries < 1 {
+		return nil, fmt.Errorf("invalid AUDIT_CONFIGMAP_ENTRIES %q", os.Getenv("AUDIT_CONFIGMAP_ENTRIES"))
+	}
+	audit.maxEntries = maxEntries
+	if !audit.log && audit.file == "" && audit.configMap == "" {
+		return nil, nil
+	}
+	return audit, nil
+}
+
+// newAuditRecord describes the changes from previous to data, the data of
+// the named Secret before and after a write.
+func (fss *FileSecretSync) newAuditRecord(name string, previous, data map[string][]byte) auditRecord {
+	record := auditRecord{
+		Time:      time.Now().UTC(),
+		Namespace: fss.namespace,
+		Secret:    name,
+		Folder:    fss.folderPath,
+		SyncedBy:  fss.syncedBy,
+	}
+	for _, change := range digestData(previous).changes(digestData(data)) {
+		entry := auditChange{Key: change.key, Change: change.change}
+		if value, ok := data[change.key]; ok {
+			entry.Size = valueSize(value)
+		}
+		if value, ok := previous[change.key]; ok {
+			entry.PreviousSize = valueSize(value)
+		}
+		record.Changes = append(record.Changes, entry)
+	}
+	return record
+}
+
+// redacted returns the record with the key names redacted for logging.
+func (r auditRecord) redacted() auditRecord {
+	if !redact.Enabled() {
+		return r
+	}
+	changes := make([]auditChange, len(r.Changes))
+	for i, change := range r.Changes {
+		change.Key = redact.Name(change.Key)
+		changes[i] = change
+	}
+	r.Changes = changes
+	return r
+}
+
+// valueSize returns the size of value.
+func valueSize(value []byte) *int {
+	size := len(value)
+	return &size
+}
+
+// recordAudit keeps an audit record of a write that changed the data of
+// the named Secret from previous to data. Failing to keep it is logged.
+func (fss *FileSecretSync) recordAudit(ctx context.Context, name string, previous, data map[string][]byte) {
+	if fss.audit == nil {
+		return
+	}
+	record := fss.newAuditRecord(name, previous, data)
+	if len(record.Changes) == 0 {
+		return
+	}
+	if err := fss.audit.write(ctx, fss.client, record); err != nil {
+		log.Printf("Failed to keep audit record for secret %s: %v", name, err)
+	}
+}
+
+// write keeps record in every configured place.
+func (a *auditLog) write(ctx context.Context, client kubernetes.Interface, record auditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit record: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.log {
+		logged, err := json.Marshal(record.redacted())
+		if err != nil {
+			return fmt.Errorf("failed to encode audit record: %w", err)
+		}
+		log.Printf("Audit: %s", logged)
+	}
+	if a.file != "" {
+		if err := appendLine(a.file, line); err != nil {
+			return err
+		}
+	}
+	if a.configMap != "" && client != nil {
+		if err := a.appendToConfigMap(ctx, client, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendLine appends line and a newline to the file at path, creating it
+// when needed.
+func appendLine(path string, line []byte) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit file: %w", redact.Error(err))
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write audit file: %w", err)
+	}
+	return f.Close()
+}
+
+// appendToConfigMap appends line to the audit ConfigMap, creating it when
+// needed, and drops the oldest records beyond maxEntries.
+func (a *auditLog) appendToConfigMap(ctx context.Context, client kubernetes.Interface, line []byte) error {
+	configMaps := client.CoreV1().ConfigMaps(a.namespace)
+	configMap, err := configMaps.Get(ctx, a.configMap, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      a.configMap,
+				Namespace: a.namespace,
+				Labels: map[string]string{
+					"app.kubernetes.io/managed-by": "file-secret-sync",
+				},
+			},
+			Data: map[string]string{auditKey: string(line) + "\n"},
+		}
+		if _, err := configMaps.Create(ctx, configMap, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create audit ConfigMap %s: %w", a.configMap, err)
+		}
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get audit ConfigMap %s: %w", a.configMap, err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(configMap.Data[auditKey], "\n"), "\n")
+	if lines[0] == "" {
+		lines = nil
+	}
+	lines = append(lines, string(line))
+	if len(lines) > a.maxEntries {
+		lines = lines[len(lines)-a.maxEntries:]
+	}
+	if configMap.Data == nil {
+		configMap.Data = make(map[string]string)
+	}
+	configMap.Data[auditKey] = strings.Join(lines, "\n") + "\n"
+	if _, err := configMaps.Update(ctx, configMap, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update audit ConfigMap %s: %w", a.configMap, err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package syncer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestLoadAuditLog(t *testing.T) {
+	testCases := []struct {
+		name      string
+		env       map[string]string
+		expectNil bool
+		expectErr bool
+	}{
+		{"disabled", nil, true, false},
+		{"log", map[string]string{"AUDIT_LOG": "true"}, false, false},
+		{"file", map[string]string{"AUDIT_FILE": "/tmp/audit.jsonl"}, false, false},
+		{"configmap", map[string]string{"AUDIT_CONFIGMAP": "audit", "AUDIT_CONFIGMAP_ENTRIES": "10"}, false, false},
+		{"invalid entries", map[string]string{"AUDIT_CONFIGMAP": "audit", "AUDIT_CONFIGMAP_ENTRIES": "0"}, false, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, key := range []string{"AUDIT_LOG", "AUDIT_FILE", "AUDIT_CONFIGMAP", "AUDIT_CONFIGMAP_ENTRIES"} {
+				t.Setenv(key, tc.env[key])
+			}
+			audit, err := loadAuditLog("test-namespace")
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("loadAuditLog() error = %v, expectErr %v", err, tc.expectErr)
+			}
+			if !tc.expectErr && (audit == nil) != tc.expectNil {
+				t.Errorf("Expected nil audit log %v, got %+v", tc.expectNil, audit)
+			}
+		})
+	}
+}
+
+func TestNewAuditRecord(t *testing.T) {
+	fss := &FileSecretSync{namespace: "test-namespace", folderPath: "/secrets"}
+	previous := map[string][]byte{"kept": []byte("same"), "changed": []byte("old"), "removed": []byte("gone")}
+	data := map[string][]byte{"kept": []byte("same"), "changed": []byte("newer"), "added": []byte("fresh")}
+
+	record := fss.newAuditRecord("test-secret", previous, data)
+	if record.Secret != "test-secret" || record.Namespace != "test-namespace" || record.Folder != "/secrets" {
+		t.Errorf("Unexpected record metadata: %+v", record)
+	}
+	changes := make(map[string]auditChange)
+	for _, change := range record.Changes {
+		changes[change.Key] = change
+	}
+	if len(changes) != 3 {
+		t.Fatalf("Expected 3 changes, got %+v", record.Changes)
+	}
+	if c := changes["added"]; c.Change != "added" || c.Size == nil || *c.Size != 5 || c.PreviousSize != nil {
+		t.Errorf("Unexpected added change: %+v", c)
+	}
+	if c := changes["removed"]; c.Change != "removed" || c.Size != nil || c.PreviousSize == nil || *c.PreviousSize != 4 {
+		t.Errorf("Unexpected removed change: %+v", c)
+	}
+	if c := changes["changed"]; c.Change != "changed" || *c.Size != 5 || *c.PreviousSize != 3 {
+		t.Errorf("Unexpected changed change: %+v", c)
+	}
+
+	// Values never appear in the record
+	encoded, _ := json.Marshal(record)
+	for _, value := range []string{"old", "newer", "gone", "fresh"} {
+		if bytes.Contains(encoded, []byte(`"`+value+`"`)) {
+			t.Errorf("Record contains value %q: %s", value, encoded)
+		}
+	}
+}
+
+func TestSyncFilesAudit(t *testing.T) {
+	tempDir := t.TempDir()
+	auditFile := filepath.Join(t.TempDir(), "audit.jsonl")
+	os.WriteFile(filepath.Join(tempDir, "password"), []byte("v1"), 0644)
+
+	client := fake.NewSimpleClientset()
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		folderPath: tempDir,
+		audit:      &auditLog{file: auditFile, configMap: "audit", namespace: "test-namespace", maxEntries: 2},
+	}
+	for _, content := range []string{"v2", "v2", "v3"} {
+		if err := fss.syncFiles(); err != nil {
+			t.Fatalf("syncFiles failed: %v", err)
+		}
+		os.WriteFile(filepath.Join(tempDir, "password"), []byte(content), 0644)
+	}
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+
+	// One record for the create and one for each of the two changes
+	content, err := os.ReadFile(auditFile)
+	if err != nil {
+		t.Fatalf("Failed to read audit file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 audit records, got %d: %s", len(lines), content)
+	}
+	var record auditRecord
+	if err := json.Unmarshal([]byte(lines[2]), &record); err != nil {
+		t.Fatalf("Invalid audit record: %v", err)
+	}
+	if len(record.Changes) != 1 || record.Changes[0].Change != "changed" {
+		t.Errorf("Unexpected last record: %+v", record)
+	}
+
+	configMap, err := client.CoreV1().ConfigMaps("test-namespace").Get(context.Background(), "audit", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get audit ConfigMap: %v", err)
+	}
+	entries := strings.Split(strings.TrimSpace(configMap.Data[auditKey]), "\n")
+	if len(entries) != 2 || entries[1] != lines[2] {
+		t.Errorf("Expected the last 2 records in the ConfigMap, got %q", configMap.Data[auditKey])
+	}
+}
@@ -0,0 +1,76 @@
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// backupOfAnnotation records which Secret a backup was taken from.
+	backupOfAnnotation = "file-secret-sync/backup-of"
+	// backupTimeAnnotation records when the backup was taken.
+	backupTimeAnnotation = "file-secret-sync/backup-time"
+	// backupSuffix is appended to a Secret's name to name its backup.
+	backupSuffix = "-backup"
+)
+
+// backupSecret copies the current data of secret into <name>-backup before
+// it is overwritten, so a bad sync can be recovered without restoring etcd.
+// Only the most recent previous content is kept.
+func (fss *FileSecretSync) backupSecret(ctx context.Context, secret *corev1.Secret) error {
+	name := secret.Name + backupSuffix
+	annotations := map[string]string{
+		backupOfAnnotation:   secret.Name,
+		backupTimeAnnotation: time.Now().UTC().Format(time.RFC3339),
+		checksumAnnotation:   dataChecksum(secret.Data),
+	}
+
+	secrets := fss.client.CoreV1().Secrets(fss.namespace)
+	backup, err := secrets.Get(ctx, name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		backup = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: fss.namespace,
+				Labels: map[string]string{
+					"app.kubernetes.io/managed-by": "file-secret-sync",
+				},
+				Annotations: annotations,
+			},
+			Type: secret.Type,
+			Data: secret.Data,
+		}
+		if err := fss.waitForWrite(ctx); err != nil {
+			return err
+		}
+		if _, err := secrets.Create(ctx, backup, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create backup secret %s: %w", name, err)
+		}
+		log.Printf("Backed up secret %s to %s", secret.Name, name)
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get backup secret %s: %w", name, err)
+	}
+
+	if backup.Annotations[backupOfAnnotation] != secret.Name {
+		return fmt.Errorf("secret %s exists but is not a backup of %s", name, secret.Name)
+	}
+	for key, value := range annotations {
+		backup.Annotations[key] = value
+	}
+	backup.Data = secret.Data
+	if err := fss.waitForWrite(ctx); err != nil {
+		return err
+	}
+	if _, err := secrets.Update(ctx, backup, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update backup secret %s: %w", name, err)
+	}
+	log.Printf("Backed up secret %s to %s", secret.Name, name)
+	return nil
+}
@@ -0,0 +1,59 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSyncFilesBackup(t *testing.T) {
+	tempDir := t.TempDir()
+	client := fake.NewSimpleClientset()
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		folderPath: tempDir,
+		backup:     true,
+	}
+
+	ctx := context.Background()
+	for _, content := range []string{"v1", "v2", "v3"} {
+		os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte(content), 0644)
+		if err := fss.syncFiles(); err != nil {
+			t.Fatalf("syncFiles failed: %v", err)
+		}
+	}
+
+	backup, err := client.CoreV1().Secrets("test-namespace").Get(ctx, "test-secret-backup", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get backup secret: %v", err)
+	}
+	if string(backup.Data["config.yaml"]) != "v2" {
+		t.Errorf("Expected backup of the previous content, got %q", backup.Data["config.yaml"])
+	}
+	if backup.Annotations[backupOfAnnotation] != "test-secret" {
+		t.Errorf("Expected backup-of annotation, got %v", backup.Annotations)
+	}
+}
+
+func TestBackupSecretRefusesForeignSecret(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-secret-backup", Namespace: "test-namespace"},
+		Data:       map[string][]byte{"important": []byte("keep")},
+	})
+	fss := &FileSecretSync{client: client, namespace: "test-namespace"}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+		Data:       map[string][]byte{"config.yaml": []byte("v1")},
+	}
+	if err := fss.backupSecret(context.Background(), secret); err == nil {
+		t.Error("Expected backup to refuse overwriting an unrelated secret")
+	}
+}
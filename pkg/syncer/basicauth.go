@@ -0,0 +1,60 @@
+package syncer
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"go-file-secret-sync/pkg/redact"
+	"go-file-secret-sync/pkg/source"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// basicAuthFiles names the files holding the credentials of a
+// kubernetes.io/basic-auth Secret.
+type basicAuthFiles struct {
+	username string
+	password string
+}
+
+// packBasicAuth stores the username and password files under the keys of
+// a kubernetes.io/basic-auth Secret. Like the API server, it requires at
+// least one of them, and it refuses other files, which usually means a
+// credential file is misnamed.
+func packBasicAuth(files map[string]source.File, names basicAuthFiles) (map[string][]byte, error) {
+	data := make(map[string][]byte)
+	var unexpected []string
+	for key, file := range files {
+		switch key {
+		case names.username:
+			data[corev1.BasicAuthUsernameKey] = file.Content
+		case names.password:
+			data[corev1.BasicAuthPasswordKey] = file.Content
+		default:
+			unexpected = append(unexpected, redact.Name(key))
+		}
+	}
+	if len(unexpected) > 0 {
+		sort.Strings(unexpected)
+		return nil, fmt.Errorf("unexpected files %s; only %s and %s are synced",
+			strings.Join(unexpected, ", "), redact.Name(names.username), redact.Name(names.password))
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("neither %s nor %s was found", redact.Name(names.username), redact.Name(names.password))
+	}
+
+	for key, value := range data {
+		if bytes.HasSuffix(value, []byte("\n")) {
+			return nil, fmt.Errorf("%s ends with a newline; strip it with TRIM_NEWLINE", key)
+		}
+	}
+	if username, ok := data[corev1.BasicAuthUsernameKey]; ok {
+		if !utf8.Valid(username) || bytes.ContainsAny(username, ":\r\n") {
+			return nil, fmt.Errorf("username must be UTF-8 text without colons or line breaks")
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,88 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"go-file-secret-sync/pkg/source"
+)
+
+func TestPackBasicAuth(t *testing.T) {
+	defaults := basicAuthFiles{username: "username", password: "password"}
+
+	testCases := []struct {
+		name      string
+		names     basicAuthFiles
+		files     map[string]string
+		expected  map[string]string
+		expectErr bool
+	}{
+		{"both", defaults, map[string]string{"username": "admin", "password": "hunter2"}, map[string]string{"username": "admin", "password": "hunter2"}, false},
+		{"password only", defaults, map[string]string{"password": "hunter2"}, map[string]string{"password": "hunter2"}, false},
+		{"explicit names", basicAuthFiles{username: "db.user", password: "db.pass"}, map[string]string{"db.user": "admin", "db.pass": "hunter2"}, map[string]string{"username": "admin", "password": "hunter2"}, false},
+		{"neither", defaults, map[string]string{}, nil, true},
+		{"unexpected file", defaults, map[string]string{"username": "admin", "passwd": "hunter2"}, nil, true},
+		{"trailing newline", defaults, map[string]string{"password": "hunter2\n"}, nil, true},
+		{"colon in username", defaults, map[string]string{"username": "ad:min"}, nil, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			files := make(map[string]source.File)
+			for key, content := range tc.files {
+				files[key] = source.File{Path: key, Content: []byte(content)}
+			}
+			data, err := packBasicAuth(files, tc.names)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("packBasicAuth() error = %v, expectErr %v", err, tc.expectErr)
+			}
+			if tc.expectErr {
+				return
+			}
+			if len(data) != len(tc.expected) {
+				t.Errorf("Expected %v, got %q", tc.expected, data)
+			}
+			for key, value := range tc.expected {
+				if string(data[key]) != value {
+					t.Errorf("Expected %s=%q, got %q", key, value, data[key])
+				}
+			}
+		})
+	}
+}
+
+func TestSyncFilesBasicAuth(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "username"), []byte("admin"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "password"), []byte("hunter2"), 0644)
+
+	client := fake.NewSimpleClientset()
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		secretName: "db-credentials",
+		folderPath: tempDir,
+		dataFormat: "basic-auth",
+		basicAuth:  basicAuthFiles{username: "username", password: "password"},
+	}
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+
+	secret, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "db-credentials", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if secret.Type != corev1.SecretTypeBasicAuth {
+		t.Errorf("Expected type %s, got %s", corev1.SecretTypeBasicAuth, secret.Type)
+	}
+	if string(secret.Data["username"]) != "admin" || string(secret.Data["password"]) != "hunter2" {
+		t.Errorf("Unexpected data: %q", secret.Data)
+	}
+}
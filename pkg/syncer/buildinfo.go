@@ -0,0 +1,21 @@
+package syncer
+
+import (
+	"runtime"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// buildInfo describes the binary, so it is shared by the metrics of every
+// Syncer.
+var buildInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "file_secret_sync_build_info",
+	Help: "Always 1, labelled with the version, commit, build date and Go version of the binary.",
+}, []string{"version", "commit", "build_date", "goversion"})
+
+// SetBuildInfo exports the version of the running binary in the
+// file_secret_sync_build_info metric.
+func SetBuildInfo(version, commit, buildDate string) {
+	buildInfo.Reset()
+	buildInfo.WithLabelValues(version, commit, buildDate, runtime.Version()).Set(1)
+}
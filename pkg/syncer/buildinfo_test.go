@@ -0,0 +1,23 @@
+package syncer
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestBuildInfoMetric(t *testing.T) {
+	SetBuildInfo("v0.1.0", "abc", "2025-01-01T00:00:00Z")
+	SetBuildInfo("v1.2.3", "abc123", "2025-02-01T00:00:00Z")
+
+	expected := `
+# HELP file_secret_sync_build_info Always 1, labelled with the version, commit, build date and Go version of the binary.
+# TYPE file_secret_sync_build_info gauge
+file_secret_sync_build_info{build_date="2025-02-01T00:00:00Z",commit="abc123",goversion="` + runtime.Version() + `",version="v1.2.3"} 1
+`
+	if err := testutil.CollectAndCompare(buildInfo, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,117 @@
+package syncer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	"go-file-secret-sync/pkg/source"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// packData turns files into Secret data according to DATA_FORMAT: one key
+// per file, or the whole set packed into a single key.
+func (fss *FileSecretSync) packData(files map[string]source.File) (map[string][]byte, error) {
+	switch fss.dataFormat {
+	case "", "files":
+		return source.Contents(files), nil
+	case "tar.gz":
+		bundle, err := packTarGz(files)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack bundle: %w", err)
+		}
+		return map[string][]byte{fss.dataKey: bundle}, nil
+	case "json":
+		blob, err := packJSON(files, fss.dataBase64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack JSON: %w", err)
+		}
+		return map[string][]byte{fss.dataKey: blob}, nil
+	case "ca-bundle":
+		bundle, err := packCABundle(files, fss.timers().Now())
+		if err != nil {
+			return nil, fmt.Errorf("failed to build CA bundle: %w", err)
+		}
+		return map[string][]byte{fss.dataKey: bundle}, nil
+	case "dockerconfigjson":
+		config, err := packDockerConfig(files)
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge Docker configs: %w", err)
+		}
+		return map[string][]byte{corev1.DockerConfigJsonKey: config}, nil
+	case "basic-auth":
+		data, err := packBasicAuth(files, fss.basicAuth)
+		if err != nil {
+			return nil, fmt.Errorf("invalid basic-auth credentials: %w", err)
+		}
+		return data, nil
+	case "ssh-auth":
+		data, err := packSSHAuth(files, fss.sshAuth)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ssh-auth credentials: %w", err)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("unknown DATA_FORMAT %q", fss.dataFormat)
+	}
+}
+
+// packTarGz packs files into a gzipped tarball, preserving their paths and
+// permissions. Entries are sorted and carry no timestamps or owners, so the
+// same files always produce the same bytes and unchanged folders do not
+// cause updates.
+func packTarGz(files map[string]source.File) ([]byte, error) {
+	sorted := make([]source.File, 0, len(files))
+	for _, file := range files {
+		sorted = append(sorted, file)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, file := range sorted {
+		header := &tar.Header{
+			Name:     file.Path,
+			Typeflag: tar.TypeReg,
+			Mode:     int64(file.Mode.Perm()),
+			Size:     int64(len(file.Content)),
+			Format:   tar.FormatPAX,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(file.Content); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// packJSON serializes files into one JSON object mapping each key to its
+// content. Content that is not valid UTF-8 is base64-encoded, as is all
+// content when allBase64 is set so consumers can decode values uniformly.
+func packJSON(files map[string]source.File, allBase64 bool) ([]byte, error) {
+	object := make(map[string]string, len(files))
+	for key, file := range files {
+		if !allBase64 && utf8.Valid(file.Content) {
+			object[key] = string(file.Content)
+		} else {
+			object[key] = base64.StdEncoding.EncodeToString(file.Content)
+		}
+	}
+	// Map keys are marshalled in sorted order, so the output is stable
+	return json.Marshal(object)
+}
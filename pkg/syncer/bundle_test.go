@@ -0,0 +1,110 @@
+package syncer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"go-file-secret-sync/pkg/source"
+)
+
+func TestSyncFilesTarGzBundle(t *testing.T) {
+	tempDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tempDir, "bin"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "app.conf"), []byte("debug=true"), 0640)
+	os.WriteFile(filepath.Join(tempDir, "bin", "start.sh"), []byte("#!/bin/sh"), 0755)
+	os.Chmod(filepath.Join(tempDir, "app.conf"), 0640)
+	os.Chmod(filepath.Join(tempDir, "bin", "start.sh"), 0755)
+
+	client := fake.NewSimpleClientset()
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		folderPath: tempDir,
+		secretName: "test-secret",
+		dataFormat: "tar.gz",
+		dataKey:    "bundle.tar.gz",
+	}
+
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+
+	secret, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get created secret: %v", err)
+	}
+	if len(secret.Data) != 1 {
+		t.Fatalf("Expected a single key, got %d", len(secret.Data))
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(secret.Data["bundle.tar.gz"]))
+	if err != nil {
+		t.Fatalf("Bundle is not gzipped: %v", err)
+	}
+	tr := tar.NewReader(gz)
+
+	expected := []struct {
+		name    string
+		mode    int64
+		content string
+	}{
+		{"app.conf", 0640, "debug=true"},
+		{"bin/start.sh", 0755, "#!/bin/sh"},
+	}
+	for _, exp := range expected {
+		header, err := tr.Next()
+		if err != nil {
+			t.Fatalf("Failed to read tar entry: %v", err)
+		}
+		content, _ := io.ReadAll(tr)
+		if header.Name != exp.name || header.Mode != exp.mode || string(content) != exp.content {
+			t.Errorf("Expected %s (%o) %q, got %s (%o) %q", exp.name, exp.mode, exp.content, header.Name, header.Mode, content)
+		}
+	}
+	if _, err := tr.Next(); err != io.EOF {
+		t.Errorf("Expected end of bundle, got %v", err)
+	}
+
+	// Packing the same folder again yields identical bytes
+	again, err := fss.collectSecrets(context.Background())
+	if err != nil {
+		t.Fatalf("collectSecrets failed: %v", err)
+	}
+	if !bytes.Equal(again["test-secret"]["bundle.tar.gz"], secret.Data["bundle.tar.gz"]) {
+		t.Error("Expected bundle to be reproducible")
+	}
+}
+
+func TestPackJSON(t *testing.T) {
+	files := map[string]source.File{
+		"app.conf": {Path: "app.conf", Content: []byte("debug=true")},
+		"key.der":  {Path: "key.der", Content: []byte{0xff, 0x00}},
+	}
+
+	testCases := []struct {
+		allBase64 bool
+		expected  string
+	}{
+		{false, `{"app.conf":"debug=true","key.der":"/wA="}`},
+		{true, `{"app.conf":"ZGVidWc9dHJ1ZQ==","key.der":"/wA="}`},
+	}
+
+	for _, tc := range testCases {
+		blob, err := packJSON(files, tc.allBase64)
+		if err != nil {
+			t.Fatalf("packJSON failed: %v", err)
+		}
+		if string(blob) != tc.expected {
+			t.Errorf("packJSON(allBase64=%v) = %s, expected %s", tc.allBase64, blob, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package syncer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"go-file-secret-sync/pkg/redact"
+	"go-file-secret-sync/pkg/source"
+)
+
+// bundledCertificate is a certificate of a CA bundle.
+type bundledCertificate struct {
+	cert        *x509.Certificate
+	fingerprint [sha256.Size]byte
+}
+
+// packCABundle concatenates the PEM certificates of all files into one
+// bundle, for distributing internal CA certificates to workloads.
+// Duplicates are left out, and certificates are sorted by subject, so
+// the same certificates always produce the same bundle. Certificates
+// that expired before now are left out with a warning. Files that hold
+// anything else than certificates, such as a private key, are refused.
+func packCABundle(files map[string]source.File, now time.Time) ([]byte, error) {
+	keys := make([]string, 0, len(files))
+	for key := range files {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	seen := make(map[[sha256.Size]byte]bool)
+	var certs []bundledCertificate
+	for _, key := range keys {
+		rest := files[key].Content
+		found := false
+		for {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" {
+				return nil, fmt.Errorf("file %s holds a %s, not only certificates", redact.Name(key), block.Type)
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("file %s holds an invalid certificate: %w", redact.Name(key), err)
+			}
+			found = true
+
+			fingerprint := sha256.Sum256(cert.Raw)
+			if seen[fingerprint] {
+				continue
+			}
+			seen[fingerprint] = true
+			if now.After(cert.NotAfter) {
+				log.Printf("Warning: leaving out certificate %s from %s, it expired on %s",
+					cert.Subject, redact.Name(key), cert.NotAfter.UTC().Format(time.RFC3339))
+				continue
+			}
+			certs = append(certs, bundledCertificate{cert: cert, fingerprint: fingerprint})
+		}
+		if !found {
+			return nil, fmt.Errorf("file %s holds no PEM certificates", redact.Name(key))
+		}
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no valid certificates found")
+	}
+
+	sort.Slice(certs, func(i, j int) bool {
+		if si, sj := certs[i].cert.Subject.String(), certs[j].cert.Subject.String(); si != sj {
+			return si < sj
+		}
+		return bytes.Compare(certs[i].fingerprint[:], certs[j].fingerprint[:]) < 0
+	})
+
+	var bundle bytes.Buffer
+	for _, c := range certs {
+		pem.Encode(&bundle, &pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw})
+	}
+	return bundle.Bytes(), nil
+}
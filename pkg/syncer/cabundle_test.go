@@ -0,0 +1,115 @@
+package syncer
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"go-file-secret-sync/pkg/source"
+)
+
+// testCACert returns a self-signed PEM CA certificate named cn that
+// expires at notAfter.
+func testCACert(t *testing.T, cn string, notAfter time.Time) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestPackCABundle(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	rootA := testCACert(t, "Root A", now.AddDate(5, 0, 0))
+	rootB := testCACert(t, "Root B", now.AddDate(5, 0, 0))
+	expired := testCACert(t, "Old Root", now.AddDate(0, -1, 0))
+
+	file := func(content ...[]byte) source.File {
+		return source.File{Content: bytes.Join(content, []byte("\n"))}
+	}
+
+	testCases := []struct {
+		name      string
+		files     map[string]source.File
+		expected  []string
+		expectErr string
+	}{
+		{"sorted and deduplicated", map[string]source.File{
+			"b.crt":     file(rootB),
+			"chain.pem": file(rootB, rootA),
+			"a.crt":     file([]byte("# Root A\n"), rootA),
+		}, []string{"Root A", "Root B"}, ""},
+		{"expired left out", map[string]source.File{"a.crt": file(rootA, expired)}, []string{"Root A"}, ""},
+		{"only expired", map[string]source.File{"old.crt": file(expired)}, nil, "no valid certificates"},
+		{"private key", map[string]source.File{
+			"a.crt": file(rootA),
+			"a.key": file(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: []byte("key")})),
+		}, nil, "EC PRIVATE KEY"},
+		{"no certificates", map[string]source.File{"README.md": file([]byte("notes"))}, nil, "no PEM certificates"},
+		{"invalid certificate", map[string]source.File{
+			"bad.crt": file(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("junk")})),
+		}, nil, "invalid certificate"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bundle, err := packCABundle(tc.files, now)
+			if tc.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectErr) {
+					t.Fatalf("Expected error containing %q, got %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("packCABundle failed: %v", err)
+			}
+
+			var subjects []string
+			for rest := bundle; ; {
+				var block *pem.Block
+				block, rest = pem.Decode(rest)
+				if block == nil {
+					if len(bytes.TrimSpace(rest)) > 0 {
+						t.Errorf("Unexpected content in bundle: %q", rest)
+					}
+					break
+				}
+				cert, err := x509.ParseCertificate(block.Bytes)
+				if err != nil {
+					t.Fatalf("Bundle holds an invalid certificate: %v", err)
+				}
+				subjects = append(subjects, cert.Subject.CommonName)
+			}
+			if strings.Join(subjects, ",") != strings.Join(tc.expected, ",") {
+				t.Errorf("Expected certificates %v, got %v", tc.expected, subjects)
+			}
+
+			// The same files always produce the same bundle
+			again, _ := packCABundle(tc.files, now)
+			if !bytes.Equal(again, bundle) {
+				t.Error("Expected the bundle to be reproducible")
+			}
+		})
+	}
+}
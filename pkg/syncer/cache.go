@@ -0,0 +1,41 @@
+package syncer
+
+import (
+	"time"
+)
+
+// appliedSecret is the checksum of the data last written to, or found up
+// to date in, a Secret.
+type appliedSecret struct {
+	checksum string
+	at       time.Time
+}
+
+// isUnchanged reports whether data with checksum was applied to the named
+// Secret less than appliedTTL ago, in which case the Secret need not even
+// be read. Entries expire so that Secrets changed or deleted by others are
+// still repaired.
+func (fss *FileSecretSync) isUnchanged(name, checksum string, now time.Time) bool {
+	if fss.appliedTTL <= 0 {
+		return false
+	}
+	applied, ok := fss.applied[name]
+	return ok && applied.checksum == checksum && now.Sub(applied.at) < fss.appliedTTL
+}
+
+// recordApplied remembers that data with checksum was applied to the named
+// Secret.
+func (fss *FileSecretSync) recordApplied(name, checksum string, now time.Time) {
+	if fss.appliedTTL <= 0 {
+		return
+	}
+	if fss.applied == nil {
+		fss.applied = make(map[string]appliedSecret)
+	}
+	fss.applied[name] = appliedSecret{checksum: checksum, at: now}
+}
+
+// forgetApplied makes the next sync of the named Secret read it again.
+func (fss *FileSecretSync) forgetApplied(name string) {
+	delete(fss.applied, name)
+}
@@ -0,0 +1,79 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSyncSkipsUnchangedSecrets(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("v1"), 0644)
+
+	client := fake.NewSimpleClientset()
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		folderPath: tempDir,
+		appliedTTL: time.Hour,
+	}
+
+	syncAndCountCalls := func() int {
+		t.Helper()
+		client.ClearActions()
+		if err := fss.syncFiles(); err != nil {
+			t.Fatalf("syncFiles failed: %v", err)
+		}
+		return len(client.Actions())
+	}
+
+	if calls := syncAndCountCalls(); calls != 2 {
+		t.Errorf("Expected a get and a create on the first sync, got %d calls", calls)
+	}
+	if calls := syncAndCountCalls(); calls != 0 {
+		t.Errorf("Expected no API calls for unchanged files, got %d", calls)
+	}
+
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("v2"), 0644)
+	if calls := syncAndCountCalls(); calls != 2 {
+		t.Errorf("Expected a get and an update after a change, got %d calls", calls)
+	}
+
+	// Expired entries make the next sync read the Secret again, which
+	// repairs Secrets deleted by others
+	client.CoreV1().Secrets("test-namespace").Delete(context.Background(), "test-secret", metav1.DeleteOptions{})
+	fss.applied["test-secret"] = appliedSecret{checksum: fss.applied["test-secret"].checksum, at: time.Now().Add(-2 * time.Hour)}
+	if calls := syncAndCountCalls(); calls != 2 {
+		t.Errorf("Expected a get and a create after the entry expired, got %d calls", calls)
+	}
+	if _, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected the deleted secret to be recreated: %v", err)
+	}
+}
+
+func TestSyncWithoutAppliedCache(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("v1"), 0644)
+
+	client := fake.NewSimpleClientset()
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		folderPath: tempDir,
+	}
+	for i := 0; i < 2; i++ {
+		if err := fss.syncFiles(); err != nil {
+			t.Fatalf("syncFiles failed: %v", err)
+		}
+	}
+	if len(fss.applied) != 0 || len(client.Actions()) != 3 {
+		t.Errorf("Expected every sync to read the secret, got %d calls", len(client.Actions()))
+	}
+}
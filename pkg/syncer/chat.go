@@ -0,0 +1,119 @@
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// chatNotifier announces syncs in a Slack or Microsoft Teams channel
+// through an incoming webhook. Failures are always announced; successful
+// syncs only when they changed keys and changes are enabled.
+type chatNotifier struct {
+	service    string
+	url        string
+	changes    bool
+	httpClient *http.Client
+	// payload formats the message for the service
+	payload func(title, text string, failed bool) any
+}
+
+// newChatNotifiers creates notifiers for SLACK_WEBHOOK_URL and
+// TEAMS_WEBHOOK_URL when set.
+func newChatNotifiers() ([]notifier, error) {
+	slackURL, teamsURL := os.Getenv("SLACK_WEBHOOK_URL"), os.Getenv("TEAMS_WEBHOOK_URL")
+	if slackURL == "" && teamsURL == "" {
+		return nil, nil
+	}
+
+	httpClient, err := newHTTPClient("")
+	if err != nil {
+		return nil, err
+	}
+	changes := os.Getenv("CHAT_NOTIFY_CHANGES") == "true"
+
+	var notifiers []notifier
+	if slackURL != "" {
+		notifiers = append(notifiers, &chatNotifier{service: "Slack", url: slackURL, changes: changes, httpClient: httpClient, payload: slackPayload})
+	}
+	if teamsURL != "" {
+		notifiers = append(notifiers, &chatNotifier{service: "Teams", url: teamsURL, changes: changes, httpClient: httpClient, payload: teamsPayload})
+	}
+	return notifiers, nil
+}
+
+func (c *chatNotifier) String() string {
+	// The URL holds the webhook's credentials
+	return c.service + " webhook"
+}
+
+func (c *chatNotifier) notify(ctx context.Context, n syncNotification) error {
+	title, text, ok := c.message(n)
+	if !ok {
+		return nil
+	}
+	body, err := json.Marshal(c.payload(title, text, n.Error != ""))
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	return postJSON(ctx, c.httpClient, c.url, body, nil)
+}
+
+// message returns the title and text announcing n, and false when n is not
+// announced.
+func (c *chatNotifier) message(n syncNotification) (string, string, bool) {
+	target := n.Namespace + "/" + n.Secret
+	if n.Secret == "" {
+		target = n.Namespace
+	}
+
+	if n.Error != "" {
+		return fmt.Sprintf("Sync of %s to %s failed", n.Folder, target), n.Error, true
+	}
+	if !c.changes || len(n.ChangedKeys) == 0 {
+		return "", "", false
+	}
+
+	lines := make([]string, len(n.ChangedKeys))
+	for i, change := range n.ChangedKeys {
+		lines[i] = fmt.Sprintf("%s/%s: %s (%s)", n.Namespace, change.Secret, change.Key, change.Change)
+	}
+	return fmt.Sprintf("Updated %d keys from %s", len(n.ChangedKeys), n.Folder), strings.Join(lines, "\n"), true
+}
+
+// slackPayload formats a message for a Slack incoming webhook.
+func slackPayload(title, text string, failed bool) any {
+	icon := ":arrows_counterclockwise:"
+	if failed {
+		icon = ":x:"
+	}
+	return map[string]string{"text": fmt.Sprintf("%s *%s*\n```%s```", icon, title, text)}
+}
+
+// teamsPayload formats a message as an Adaptive Card, as accepted by Teams
+// workflow webhooks.
+func teamsPayload(title, text string, failed bool) any {
+	color := "Good"
+	if failed {
+		color = "Attention"
+	}
+	return map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content": map[string]any{
+				"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+				"type":    "AdaptiveCard",
+				"version": "1.4",
+				"body": []map[string]any{
+					{"type": "TextBlock", "text": title, "weight": "Bolder", "color": color, "wrap": true},
+					// Text blocks need blank lines for line breaks
+					{"type": "TextBlock", "text": strings.ReplaceAll(text, "\n", "\n\n"), "fontType": "Monospace", "wrap": true},
+				},
+			},
+		}},
+	}
+}
@@ -0,0 +1,94 @@
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestChatNotifierMessages(t *testing.T) {
+	changed := syncNotification{
+		Result:      "success",
+		Folder:      "/secrets/app",
+		Namespace:   "default",
+		Secret:      "app",
+		ChangedKeys: []notifiedChange{{"app", "tls.crt", "changed"}, {"app", "tls.key", "changed"}},
+	}
+	unchanged := syncNotification{Result: "success", Folder: "/secrets/app", Namespace: "default", Secret: "app"}
+	failed := syncNotification{Result: "failure", Folder: "/secrets/app", Namespace: "default", Secret: "app", Error: "secrets \"app\" is forbidden"}
+
+	testCases := []struct {
+		name      string
+		changes   bool
+		n         syncNotification
+		announced bool
+		contains  string
+	}{
+		{"failure", false, failed, true, "is forbidden"},
+		{"changes disabled", false, changed, false, ""},
+		{"changes enabled", true, changed, true, "default/app: tls.key (changed)"},
+		{"nothing changed", true, unchanged, false, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &chatNotifier{changes: tc.changes}
+			title, text, announced := c.message(tc.n)
+			if announced != tc.announced {
+				t.Fatalf("Expected announced=%v, got %v", tc.announced, announced)
+			}
+			if announced && !strings.Contains(title+"\n"+text, tc.contains) {
+				t.Errorf("Expected message to contain %q, got %q: %q", tc.contains, title, text)
+			}
+		})
+	}
+}
+
+func TestChatNotifiers(t *testing.T) {
+	bodies := make(map[string]map[string]any)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies[r.URL.Path] = body
+	}))
+	defer server.Close()
+
+	t.Setenv("SLACK_WEBHOOK_URL", server.URL+"/slack")
+	t.Setenv("TEAMS_WEBHOOK_URL", server.URL+"/teams")
+	notifiers, err := newChatNotifiers()
+	if err != nil {
+		t.Fatalf("newChatNotifiers failed: %v", err)
+	}
+	if len(notifiers) != 2 {
+		t.Fatalf("Expected two notifiers, got %d", len(notifiers))
+	}
+
+	failed := syncNotification{Result: "failure", Folder: "/secrets/app", Namespace: "default", Secret: "app", Error: "API unavailable"}
+	for _, n := range notifiers {
+		if strings.Contains(n.String(), server.URL) {
+			t.Errorf("Expected %q not to reveal the webhook URL", n.String())
+		}
+		if err := n.notify(context.Background(), failed); err != nil {
+			t.Fatalf("notify to %s failed: %v", n, err)
+		}
+	}
+
+	if text, _ := bodies["/slack"]["text"].(string); !strings.Contains(text, ":x:") || !strings.Contains(text, "API unavailable") {
+		t.Errorf("Unexpected Slack message: %v", bodies["/slack"])
+	}
+	if teams := bodies["/teams"]; teams["type"] != "message" || !strings.Contains(toJSON(t, teams), "API unavailable") {
+		t.Errorf("Unexpected Teams message: %v", teams)
+	}
+}
+
+func toJSON(t *testing.T, value any) string {
+	t.Helper()
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	return string(data)
+}
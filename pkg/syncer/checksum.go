@@ -0,0 +1,50 @@
+package syncer
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"sort"
+)
+
+// checksumAnnotation holds a digest of the whole data map, so workloads
+// templating it into their pod spec, or tools watching it, can restart
+// when the content changes.
+const checksumAnnotation = "file-secret-sync/checksum"
+
+// keyChecksumAnnotationPrefix is followed by a key to form the annotation
+// holding the SHA-256 digest of that key's value, so a single value can be
+// checked without reading the Secret's data.
+const keyChecksumAnnotationPrefix = "file-secret-sync/sha256."
+
+// dataChecksum returns "sha256:<hex>" over data. Keys are hashed in sorted
+// order and every field is length-prefixed, so the digest does not depend
+// on map ordering and distinct maps cannot collide by concatenation.
+func dataChecksum(data map[string][]byte) string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	var length [8]byte
+	for _, key := range keys {
+		binary.BigEndian.PutUint64(length[:], uint64(len(key)))
+		h.Write(length[:])
+		h.Write([]byte(key))
+		binary.BigEndian.PutUint64(length[:], uint64(len(data[key])))
+		h.Write(length[:])
+		h.Write(data[key])
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// keyChecksumAnnotations returns the checksum annotation of every key in
+// data, holding the hex digest as printed by sha256sum.
+func keyChecksumAnnotations(data map[string][]byte) map[string]string {
+	return keyAnnotations(keyChecksumAnnotationPrefix, data, func(_ string, value []byte) string {
+		sum := sha256.Sum256(value)
+		return hex.EncodeToString(sum[:])
+	})
+}
@@ -0,0 +1,128 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDataChecksum(t *testing.T) {
+	base := dataChecksum(map[string][]byte{"a": []byte("1"), "b": []byte("2")})
+
+	testCases := []struct {
+		name  string
+		data  map[string][]byte
+		equal bool
+	}{
+		{"same data", map[string][]byte{"b": []byte("2"), "a": []byte("1")}, true},
+		{"changed value", map[string][]byte{"a": []byte("1"), "b": []byte("3")}, false},
+		{"shifted boundary", map[string][]byte{"a": []byte("1b"), "": []byte("2")}, false},
+		{"missing key", map[string][]byte{"a": []byte("1")}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := dataChecksum(tc.data); (got == base) != tc.equal {
+				t.Errorf("dataChecksum(%v) = %s, base %s, expected equal=%v", tc.data, got, base, tc.equal)
+			}
+		})
+	}
+}
+
+func TestSyncFilesChecksumAnnotation(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("test: value"), 0644)
+
+	// A Secret written before checksums existed, with identical data
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+		Data:       map[string][]byte{"config.yaml": []byte("test: value")},
+	})
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		folderPath: tempDir,
+	}
+
+	getChecksum := func() string {
+		secret, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get secret: %v", err)
+		}
+		return secret.Annotations[checksumAnnotation]
+	}
+
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+	first := getChecksum()
+	if first != dataChecksum(map[string][]byte{"config.yaml": []byte("test: value")}) {
+		t.Errorf("Expected checksum annotation to be stamped, got %q", first)
+	}
+
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("test: updated"), 0644)
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+	if getChecksum() == first {
+		t.Error("Expected checksum annotation to change with the content")
+	}
+}
+
+func TestSyncFilesKeyChecksumAnnotations(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "password"), []byte("hunter2"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "username"), []byte("admin"), 0644)
+
+	client := fake.NewSimpleClientset()
+	fss := &FileSecretSync{
+		client:       client,
+		namespace:    "test-namespace",
+		secretName:   "test-secret",
+		folderPath:   tempDir,
+		keyChecksums: true,
+	}
+	getAnnotations := func() map[string]string {
+		t.Helper()
+		secret, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get secret: %v", err)
+		}
+		return secret.Annotations
+	}
+
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+	// echo -n hunter2 | sha256sum
+	const passwordSum = "f52fbd32b2b3b86ff88ef6c490628285f482af15ddcb29541f94bcf526a3f6c7"
+	annotations := getAnnotations()
+	if annotations[keyChecksumAnnotationPrefix+"password"] != passwordSum {
+		t.Errorf("Expected the checksum of password, got %v", annotations)
+	}
+	usernameSum := annotations[keyChecksumAnnotationPrefix+"username"]
+	if usernameSum == "" {
+		t.Errorf("Expected username to be annotated, got %v", annotations)
+	}
+
+	// Only the annotation of the changed key changes, and removed keys
+	// lose theirs
+	os.WriteFile(filepath.Join(tempDir, "password"), []byte("correct horse"), 0644)
+	os.Remove(filepath.Join(tempDir, "username"))
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+	annotations = getAnnotations()
+	if sum := annotations[keyChecksumAnnotationPrefix+"password"]; sum == "" || sum == passwordSum {
+		t.Errorf("Expected the checksum of password to change, got %v", annotations)
+	}
+	if _, ok := annotations[keyChecksumAnnotationPrefix+"username"]; ok {
+		t.Errorf("Expected the annotation of the removed key to be removed, got %v", annotations)
+	}
+}
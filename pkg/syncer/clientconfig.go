@@ -0,0 +1,53 @@
+package syncer
+
+import (
+	"fmt"
+	"os"
+
+	"k8s.io/client-go/rest"
+)
+
+// newClientConfig returns the configuration for talking to the Kubernetes
+// API: the in-cluster configuration, or the cluster at KUBE_API_SERVER
+// when running outside of it or behind a gateway. KUBE_CA_FILE,
+// KUBE_CLIENT_CERT_FILE with KUBE_CLIENT_KEY_FILE, and KUBE_TOKEN_FILE or
+// KUBE_TOKEN replace the service account's CA and credentials.
+func newClientConfig() (*rest.Config, error) {
+	var config *rest.Config
+	if host := os.Getenv("KUBE_API_SERVER"); host != "" {
+		config = &rest.Config{Host: host}
+	} else {
+		var err error
+		config, err = rest.InClusterConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create in-cluster config: %w", err)
+		}
+	}
+
+	if caFile := os.Getenv("KUBE_CA_FILE"); caFile != "" {
+		config.TLSClientConfig.CAFile = caFile
+		config.TLSClientConfig.CAData = nil
+	}
+
+	certFile, keyFile := os.Getenv("KUBE_CLIENT_CERT_FILE"), os.Getenv("KUBE_CLIENT_KEY_FILE")
+	if (certFile == "") != (keyFile == "") {
+		return nil, fmt.Errorf("KUBE_CLIENT_CERT_FILE and KUBE_CLIENT_KEY_FILE must be set together")
+	}
+	if certFile != "" {
+		config.TLSClientConfig.CertFile = certFile
+		config.TLSClientConfig.KeyFile = keyFile
+		// A client certificate replaces the service account token
+		config.BearerToken = ""
+		config.BearerTokenFile = ""
+	}
+
+	tokenFile, token := os.Getenv("KUBE_TOKEN_FILE"), os.Getenv("KUBE_TOKEN")
+	if tokenFile != "" && token != "" {
+		return nil, fmt.Errorf("KUBE_TOKEN_FILE and KUBE_TOKEN are mutually exclusive")
+	}
+	if tokenFile != "" || token != "" {
+		config.BearerTokenFile = tokenFile
+		config.BearerToken = token
+	}
+	return config, nil
+}
@@ -0,0 +1,87 @@
+package syncer
+
+import (
+	"testing"
+
+	"k8s.io/client-go/rest"
+)
+
+func TestNewClientConfig(t *testing.T) {
+	testCases := []struct {
+		name      string
+		env       map[string]string
+		check     func(t *testing.T, config *rest.Config)
+		expectErr bool
+	}{
+		{
+			name:      "outside the cluster without a server",
+			env:       map[string]string{"KUBERNETES_SERVICE_HOST": "", "KUBERNETES_SERVICE_PORT": ""},
+			expectErr: true,
+		},
+		{
+			name: "client certificate",
+			env: map[string]string{
+				"KUBE_API_SERVER":       "https://gateway.example.com:6443",
+				"KUBE_CA_FILE":          "/etc/kube/ca.crt",
+				"KUBE_CLIENT_CERT_FILE": "/etc/kube/tls.crt",
+				"KUBE_CLIENT_KEY_FILE":  "/etc/kube/tls.key",
+			},
+			check: func(t *testing.T, config *rest.Config) {
+				tls := config.TLSClientConfig
+				if config.Host != "https://gateway.example.com:6443" || tls.CAFile != "/etc/kube/ca.crt" || tls.CertFile != "/etc/kube/tls.crt" || tls.KeyFile != "/etc/kube/tls.key" {
+					t.Errorf("Unexpected config: host %q, CA %q, cert %q, key %q", config.Host, tls.CAFile, tls.CertFile, tls.KeyFile)
+				}
+				if config.BearerToken != "" || config.BearerTokenFile != "" {
+					t.Errorf("Expected no token, got %q and %q", config.BearerToken, config.BearerTokenFile)
+				}
+			},
+		},
+		{
+			name: "token file",
+			env:  map[string]string{"KUBE_API_SERVER": "https://10.0.0.1", "KUBE_TOKEN_FILE": "/var/run/token"},
+			check: func(t *testing.T, config *rest.Config) {
+				if config.BearerTokenFile != "/var/run/token" || config.BearerToken != "" {
+					t.Errorf("Expected token file /var/run/token, got %q and %q", config.BearerTokenFile, config.BearerToken)
+				}
+			},
+		},
+		{
+			name: "inline token",
+			env:  map[string]string{"KUBE_API_SERVER": "https://10.0.0.1", "KUBE_TOKEN": "abc"},
+			check: func(t *testing.T, config *rest.Config) {
+				if config.BearerToken != "abc" || config.BearerTokenFile != "" {
+					t.Errorf("Expected token abc, got %q and %q", config.BearerToken, config.BearerTokenFile)
+				}
+			},
+		},
+		{
+			name:      "certificate without key",
+			env:       map[string]string{"KUBE_API_SERVER": "https://10.0.0.1", "KUBE_CLIENT_CERT_FILE": "/etc/kube/tls.crt"},
+			expectErr: true,
+		},
+		{
+			name:      "token and token file",
+			env:       map[string]string{"KUBE_API_SERVER": "https://10.0.0.1", "KUBE_TOKEN": "abc", "KUBE_TOKEN_FILE": "/var/run/token"},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, name := range []string{"KUBE_API_SERVER", "KUBE_CA_FILE", "KUBE_CLIENT_CERT_FILE", "KUBE_CLIENT_KEY_FILE", "KUBE_TOKEN_FILE", "KUBE_TOKEN"} {
+				t.Setenv(name, "")
+			}
+			for name, value := range tc.env {
+				t.Setenv(name, value)
+			}
+
+			config, err := newClientConfig()
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("newClientConfig() error = %v, expectErr %v", err, tc.expectErr)
+			}
+			if tc.check != nil {
+				tc.check(t, config)
+			}
+		})
+	}
+}
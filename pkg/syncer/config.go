@@ -0,0 +1,297 @@
+package syncer
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+
+	"go-file-secret-sync/pkg/source"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+)
+
+// syncConfig is the configuration file given by CONFIG_FILE. It maps
+// several folders to Secrets; everything else is configured through the
+// environment as usual.
+type syncConfig struct {
+	Mappings []mappingConfig `yaml:"mappings"`
+}
+
+// mappingConfig maps one folder to a Secret.
+type mappingConfig struct {
+	Folder            string   `yaml:"folder"`
+	Secret            string   `yaml:"secret"`
+	Namespace         string   `yaml:"namespace"`
+	Include           []string `yaml:"include"`
+	Exclude           []string `yaml:"exclude"`
+	MaxDepth          int      `yaml:"max_depth"`
+	Recursive         *bool    `yaml:"recursive"`
+	MaxSyncsPerMinute int      `yaml:"max_syncs_per_minute"`
+}
+
+// loadConfigFile reads and validates the configuration file at path.
+// Unknown fields are rejected so that typos do not go unnoticed.
+func loadConfigFile(path, secretMode string) (*syncConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var cfg syncConfig
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if len(cfg.Mappings) == 0 {
+		return nil, fmt.Errorf("%s defines no mappings", path)
+	}
+	seen := make(map[string]bool)
+	for i := range cfg.Mappings {
+		m := &cfg.Mappings[i]
+		for _, field := range []*string{&m.Folder, &m.Secret, &m.Namespace} {
+			if *field, err = expandEnv(*field); err != nil {
+				return nil, fmt.Errorf("mapping %d: %w", i, err)
+			}
+		}
+
+		if m.Folder == "" {
+			return nil, fmt.Errorf("mapping %d: folder is required", i)
+		}
+		if m.Secret == "" && secretMode == "single" {
+			return nil, fmt.Errorf("mapping %d: secret is required", i)
+		}
+		if err := source.ValidatePatterns(m.Include); err != nil {
+			return nil, fmt.Errorf("mapping %d: include: %w", i, err)
+		}
+		if err := source.ValidatePatterns(m.Exclude); err != nil {
+			return nil, fmt.Errorf("mapping %d: exclude: %w", i, err)
+		}
+		if m.MaxDepth < 0 {
+			return nil, fmt.Errorf("mapping %d: max_depth must not be negative", i)
+		}
+		if _, err := m.maxDepth(); err != nil {
+			return nil, fmt.Errorf("mapping %d: %w", i, err)
+		}
+		if m.MaxSyncsPerMinute < 0 {
+			return nil, fmt.Errorf("mapping %d: max_syncs_per_minute must not be negative", i)
+		}
+		if seen[m.id()] {
+			return nil, fmt.Errorf("mapping %d: %s is mapped more than once", i, m.id())
+		}
+		seen[m.id()] = true
+	}
+	return &cfg, nil
+}
+
+// envReference matches ${VAR} references in configuration values.
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv replaces ${VAR} references in value with the value of the
+// environment variable, so one configuration works across environments
+// driven by Downward API variables. Referencing an unset variable is an
+// error rather than silently producing an empty string.
+func expandEnv(value string) (string, error) {
+	var missing []string
+	expanded := envReference.ReplaceAllStringFunc(value, func(ref string) string {
+		name := envReference.FindStringSubmatch(ref)[1]
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return v
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("undefined environment variables in %q: %v", value, missing)
+	}
+	return expanded, nil
+}
+
+// id identifies a mapping across configuration reloads.
+func (m mappingConfig) id() string {
+	return m.Folder + " -> " + m.Namespace + "/" + m.Secret
+}
+
+// maxDepth returns the depth limit of the mapping, combining max_depth
+// and recursive.
+func (m mappingConfig) maxDepth() (int, error) {
+	return resolveMaxDepth(m.MaxDepth, m.Recursive == nil || *m.Recursive)
+}
+
+// configRunner runs one FileSecretSync per mapping of the configuration
+// file and applies changes to the file while running.
+type configRunner struct {
+	base    *FileSecretSync
+	path    string
+	running map[string]*runningMapping
+}
+
+// runningMapping is a mapping that is currently being synced.
+type runningMapping struct {
+	cfg mappingConfig
+	fss *FileSecretSync
+}
+
+// runConfigFile syncs every mapping of the configuration file at path,
+// using base for all settings a mapping does not override, and reloads
+// the file when it changes. It returns on failure, or after stopping all
+// mappings on shutdown.
+func runConfigFile(base *FileSecretSync, path string) error {
+	r := &configRunner{
+		base:    base,
+		path:    path,
+		running: make(map[string]*runningMapping),
+	}
+
+	cfg, err := loadConfigFile(path, base.secretMode)
+	if err != nil {
+		return err
+	}
+	r.apply(cfg)
+
+	// Watch the directory rather than the file: editors and ConfigMap
+	// volumes replace the file instead of writing to it
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create config file watcher: %w", err)
+	}
+	defer watcher.Close()
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("failed to watch config file: %w", err)
+	}
+
+	debounceTimer := base.timers().NewTimer(0)
+	<-debounceTimer.C()
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return fmt.Errorf("config file watcher closed")
+			}
+			if name := filepath.Base(event.Name); name == filepath.Base(path) || name == "..data" {
+				debounceTimer.Reset(base.debounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return fmt.Errorf("config file watcher closed")
+			}
+			log.Printf("Config file watcher error: %v", err)
+		case <-debounceTimer.C():
+			r.reload()
+		case <-base.rootContext().Done():
+			for _, running := range r.running {
+				running.fss.stopMonitoring()
+			}
+			return nil
+		}
+	}
+}
+
+// reload applies the configuration file again, keeping the running
+// mappings when the file is invalid.
+func (r *configRunner) reload() {
+	cfg, err := loadConfigFile(r.path, r.base.secretMode)
+	if err != nil {
+		log.Printf("Invalid configuration, keeping the last good one: %v", err)
+		return
+	}
+	log.Printf("Reloading configuration from %s", r.path)
+	r.apply(cfg)
+}
+
+// apply stops mappings that were removed or changed and starts the ones
+// that are new or changed.
+func (r *configRunner) apply(cfg *syncConfig) {
+	wanted := make(map[string]mappingConfig, len(cfg.Mappings))
+	for _, m := range cfg.Mappings {
+		wanted[m.id()] = m
+	}
+
+	for id, running := range r.running {
+		if m, ok := wanted[id]; ok && reflect.DeepEqual(m, running.cfg) {
+			continue
+		}
+		log.Printf("Stopping mapping %s", id)
+		running.fss.stopMonitoring()
+		running.fss.shared().health.forget(running.fss)
+		running.fss.forgetMetrics()
+		delete(r.running, id)
+	}
+
+	for id, m := range wanted {
+		if _, ok := r.running[id]; ok {
+			continue
+		}
+		fss, err := r.start(m)
+		if err != nil {
+			log.Printf("Failed to start mapping %s: %v", id, err)
+			continue
+		}
+		r.running[id] = &runningMapping{cfg: m, fss: fss}
+	}
+}
+
+// forMapping returns a copy of base that syncs the mapping m.
+func forMapping(base *FileSecretSync, m mappingConfig) FileSecretSync {
+	// Mappings share the state of the Syncer running base
+	base.shared()
+	fss := *base
+	fss.folderPath = m.Folder
+	fss.secretName = m.Secret
+	fss.reader.Filter.Include = m.Include
+	fss.reader.Filter.Exclude = m.Exclude
+	if m.MaxDepth != 0 || m.Recursive != nil {
+		// Validated when the file was loaded
+		fss.reader.Filter.MaxDepth, _ = m.maxDepth()
+	}
+	if m.MaxSyncsPerMinute != 0 {
+		fss.maxSyncsPerMinute = m.MaxSyncsPerMinute
+	}
+	fss.urls = nil
+	if m.Namespace != "" {
+		fss.namespace = m.Namespace
+	}
+	// Owners must be in the namespace of the objects they own
+	if fss.namespace != base.namespace {
+		fss.owner = nil
+	}
+	return fss
+}
+
+// start syncs a mapping once and monitors its folder in the background,
+// so that slow mappings do not hold up the others or reloads.
+func (r *configRunner) start(m mappingConfig) (*FileSecretSync, error) {
+	fss := forMapping(r.base, m)
+	if err := fss.newTree(); err != nil {
+		return nil, err
+	}
+	fss.stop = make(chan struct{})
+	fss.syncNow = make(chan struct{}, 1)
+	fss.shared().health.track(&fss)
+	fss.initMetrics()
+
+	fss.retry = newSyncRetry(fss.timers(), fss.retryPolicy)
+	fss.resume = newResumeTimer(fss.timers())
+
+	log.Printf("Starting mapping %s", m.id())
+	if r.base.owner != nil && fss.owner == nil {
+		log.Printf("Warning: mapping %s writes to namespace %s, so its Secrets get no owner reference", m.id(), fss.namespace)
+	}
+	go func() {
+		// Stopped mappings have been replaced or removed
+		if fss.stopped() {
+			return
+		}
+		fss.runSync()
+		if err := fss.startMonitoring(); err != nil {
+			log.Printf("Monitoring of %s failed: %v", m.id(), err)
+		}
+	}()
+	return &fss, nil
+}
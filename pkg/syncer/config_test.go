@@ -0,0 +1,197 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	testCases := []struct {
+		name       string
+		content    string
+		shouldFail bool
+	}{
+		{"valid", "mappings:\n- folder: /a\n  secret: a\n  include: ['*.pem']\n- folder: /b\n  secret: b\n", false},
+		{"unknown field", "mappings:\n- folder: /a\n  secret: a\n  excludes: ['*.tmp']\n", true},
+		{"missing folder", "mappings:\n- secret: a\n", true},
+		{"missing secret", "mappings:\n- folder: /a\n", true},
+		{"invalid pattern", "mappings:\n- folder: /a\n  secret: a\n  exclude: ['[']\n", true},
+		{"duplicate mapping", "mappings:\n- folder: /a\n  secret: a\n- folder: /a\n  secret: a\n", true},
+		{"depth limit", "mappings:\n- folder: /a\n  secret: a\n  max_depth: 2\n- folder: /b\n  secret: b\n  recursive: false\n", false},
+		{"negative depth", "mappings:\n- folder: /a\n  secret: a\n  max_depth: -1\n", true},
+		{"deep non-recursive", "mappings:\n- folder: /a\n  secret: a\n  max_depth: 2\n  recursive: false\n", true},
+		{"sync throttle", "mappings:\n- folder: /a\n  secret: a\n  max_syncs_per_minute: 6\n", false},
+		{"negative sync throttle", "mappings:\n- folder: /a\n  secret: a\n  max_syncs_per_minute: -1\n", true},
+		{"no mappings", "mappings: []\n", true},
+		{"invalid yaml", "mappings: [\n", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			os.WriteFile(path, []byte(tc.content), 0644)
+
+			_, err := loadConfigFile(path, "single")
+			if tc.shouldFail && err == nil {
+				t.Error("Expected error but got none")
+			} else if !tc.shouldFail && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("POD_NAMESPACE", "team-a")
+	t.Setenv("APP", "billing")
+
+	testCases := []struct {
+		value      string
+		expected   string
+		shouldFail bool
+	}{
+		{"/secrets/${APP}", "/secrets/billing", false},
+		{"${APP}-${POD_NAMESPACE}", "billing-team-a", false},
+		{"plain-$APP", "plain-$APP", false},
+		{"${UNDEFINED_VARIABLE_FOR_TEST}", "", true},
+	}
+
+	for _, tc := range testCases {
+		got, err := expandEnv(tc.value)
+		if tc.shouldFail {
+			if err == nil {
+				t.Errorf("expandEnv(%q) expected error", tc.value)
+			}
+			continue
+		}
+		if err != nil || got != tc.expected {
+			t.Errorf("expandEnv(%q) = %q, %v; expected %q", tc.value, got, err, tc.expected)
+		}
+	}
+}
+
+func TestLoadConfigFileExpandsEnv(t *testing.T) {
+	t.Setenv("APP", "billing")
+	t.Setenv("POD_NAMESPACE", "team-a")
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(path, []byte("mappings:\n- folder: /secrets/${APP}\n  secret: ${APP}-secret\n  namespace: ${POD_NAMESPACE}\n"), 0644)
+
+	cfg, err := loadConfigFile(path, "single")
+	if err != nil {
+		t.Fatalf("loadConfigFile failed: %v", err)
+	}
+	expected := mappingConfig{Folder: "/secrets/billing", Secret: "billing-secret", Namespace: "team-a"}
+	if cfg.Mappings[0].id() != expected.id() {
+		t.Errorf("Expected %s, got %s", expected.id(), cfg.Mappings[0].id())
+	}
+}
+
+func TestConfigRunnerReload(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, dir := range []string{"app", "db"} {
+		os.MkdirAll(filepath.Join(tempDir, dir), 0755)
+		os.WriteFile(filepath.Join(tempDir, dir, "config.yaml"), []byte(dir), 0644)
+		os.WriteFile(filepath.Join(tempDir, dir, "notes.txt"), []byte("notes"), 0644)
+	}
+	configPath := filepath.Join(tempDir, "config.yaml")
+	writeConfig := func(content string) {
+		os.WriteFile(configPath, []byte(content), 0644)
+	}
+
+	client := fake.NewSimpleClientset()
+	r := &configRunner{
+		base: &FileSecretSync{
+			client:     client,
+			namespace:  "test-namespace",
+			secretMode: "single",
+		},
+		path:    configPath,
+		running: make(map[string]*runningMapping),
+	}
+	defer func() {
+		for _, running := range r.running {
+			running.fss.stopMonitoring()
+		}
+	}()
+
+	keys := func(n int) func(map[string][]byte) bool {
+		return func(data map[string][]byte) bool { return len(data) == n }
+	}
+
+	writeConfig("mappings:\n" +
+		"- folder: " + filepath.Join(tempDir, "app") + "\n  secret: app\n" +
+		"- folder: " + filepath.Join(tempDir, "db") + "\n  secret: db\n")
+	r.reload()
+	if len(r.running) != 2 {
+		t.Fatalf("Expected two running mappings, got %d", len(r.running))
+	}
+	waitForData(t, client, "test-namespace", "app", keys(2))
+	waitForData(t, client, "test-namespace", "db", keys(2))
+
+	// Changing a filter restarts the mapping; removing one stops it
+	writeConfig("mappings:\n" +
+		"- folder: " + filepath.Join(tempDir, "app") + "\n  secret: app\n  exclude: ['*.txt']\n")
+	r.reload()
+	if len(r.running) != 1 {
+		t.Errorf("Expected one running mapping, got %d", len(r.running))
+	}
+	if data := waitForData(t, client, "test-namespace", "app", keys(1)); string(data["config.yaml"]) != "app" {
+		t.Errorf("Expected filtered data after reload, got %v", data)
+	}
+
+	// A broken configuration keeps the last good state
+	writeConfig("mappings:\n- secret: broken\n")
+	r.reload()
+	if len(r.running) != 1 {
+		t.Errorf("Expected invalid configuration to keep the running mapping, got %d", len(r.running))
+	}
+}
+
+// waitForData returns the data of the Secret name in namespace once done
+// accepts it, as mappings sync in the background.
+func waitForData(t *testing.T, client kubernetes.Interface, namespace, name string, done func(map[string][]byte) bool) map[string][]byte {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		secret, err := client.CoreV1().Secrets(namespace).Get(context.Background(), name, metav1.GetOptions{})
+		if err == nil && done(secret.Data) {
+			return secret.Data
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for secret %s/%s: %v", namespace, name, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestForMappingOwner(t *testing.T) {
+	owner := &metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "sync", UID: "1234"}
+	base := &FileSecretSync{namespace: "test-namespace", owner: owner}
+
+	tests := []struct {
+		name      string
+		namespace string
+		expected  *metav1.OwnerReference
+	}{
+		{"default namespace", "", owner},
+		{"same namespace", "test-namespace", owner},
+		{"other namespace", "other", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fss := forMapping(base, mappingConfig{Folder: "/data", Secret: "app", Namespace: tt.namespace})
+			if fss.owner != tt.expected {
+				t.Errorf("Expected owner %v, got %v", tt.expected, fss.owner)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package syncer
+
+import (
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// contentTypeAnnotationPrefix is followed by a key to form the annotation
+// holding the MIME type of that key's value.
+const contentTypeAnnotationPrefix = "file-secret-sync/content-type."
+
+// contentTypesByExtension covers the file types common in Secrets that
+// mime.TypeByExtension does not know without a system mime.types file.
+var contentTypesByExtension = map[string]string{
+	".yaml": "application/yaml",
+	".yml":  "application/yaml",
+	".json": "application/json",
+	".toml": "application/toml",
+	".ini":  "text/plain; charset=utf-8",
+	".conf": "text/plain; charset=utf-8",
+	".env":  "text/plain; charset=utf-8",
+	".txt":  "text/plain; charset=utf-8",
+	".pem":  "application/x-pem-file",
+	".crt":  "application/x-pem-file",
+	".key":  "application/x-pem-file",
+	".p12":  "application/x-pkcs12",
+	".pfx":  "application/x-pkcs12",
+	".jks":  "application/x-java-keystore",
+}
+
+// detectContentType returns the MIME type of value, from the extension of
+// key when it has a known one, or else from the content itself.
+func detectContentType(key string, value []byte) string {
+	ext := strings.ToLower(path.Ext(key))
+	if contentType, ok := contentTypesByExtension[ext]; ok {
+		return contentType
+	}
+	if contentType := mime.TypeByExtension(ext); ext != "" && contentType != "" {
+		return contentType
+	}
+	return http.DetectContentType(value)
+}
+
+// contentTypeAnnotations returns the content type annotation of every key
+// in data.
+func contentTypeAnnotations(data map[string][]byte) map[string]string {
+	return keyAnnotations(contentTypeAnnotationPrefix, data, detectContentType)
+}
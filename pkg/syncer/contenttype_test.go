@@ -0,0 +1,88 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDetectContentType(t *testing.T) {
+	testCases := []struct {
+		key      string
+		value    string
+		expected string
+	}{
+		{"config.yaml", "key: value", "application/yaml"},
+		{"certs.tls.crt", "-----BEGIN CERTIFICATE-----", "application/x-pem-file"},
+		{"settings.JSON", "{}", "application/json"},
+		{"logo.png", "", "image/png"},
+		{"password", "hunter2", "text/plain; charset=utf-8"},
+		{"value", "\x89PNG\r\n\x1a\n", "image/png"},
+		{"blob", "\x00\x01\x02", "application/octet-stream"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.key, func(t *testing.T) {
+			if got := detectContentType(tc.key, []byte(tc.value)); got != tc.expected {
+				t.Errorf("detectContentType(%q) = %q, expected %q", tc.key, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSyncFilesContentTypeAnnotations(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("test: value"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "password"), []byte("hunter2"), 0644)
+	longName := strings.Repeat("a", 60) + ".txt"
+	os.WriteFile(filepath.Join(tempDir, longName), []byte("too long"), 0644)
+
+	client := fake.NewSimpleClientset()
+	fss := &FileSecretSync{
+		client:       client,
+		namespace:    "test-namespace",
+		secretName:   "test-secret",
+		folderPath:   tempDir,
+		contentTypes: true,
+	}
+	getAnnotations := func() map[string]string {
+		t.Helper()
+		secret, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get secret: %v", err)
+		}
+		return secret.Annotations
+	}
+
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+	annotations := getAnnotations()
+	if annotations[contentTypeAnnotationPrefix+"config.yaml"] != "application/yaml" {
+		t.Errorf("Expected config.yaml to be annotated as YAML, got %v", annotations)
+	}
+	if annotations[contentTypeAnnotationPrefix+"password"] != "text/plain; charset=utf-8" {
+		t.Errorf("Expected password to be annotated as text, got %v", annotations)
+	}
+	if _, ok := annotations[contentTypeAnnotationPrefix+longName]; ok {
+		t.Errorf("Expected no annotation for a key too long for an annotation name, got %v", annotations)
+	}
+
+	// The annotation of a removed key is removed with it
+	os.Remove(filepath.Join(tempDir, "password"))
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+	annotations = getAnnotations()
+	if _, ok := annotations[contentTypeAnnotationPrefix+"password"]; ok {
+		t.Errorf("Expected the annotation of the removed key to be removed, got %v", annotations)
+	}
+	if annotations[contentTypeAnnotationPrefix+"config.yaml"] == "" {
+		t.Errorf("Expected config.yaml to stay annotated, got %v", annotations)
+	}
+}
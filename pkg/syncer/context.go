@@ -0,0 +1,34 @@
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+// defaultAPITimeout bounds every request to the Kubernetes API, so a hung
+// call cannot stall syncing indefinitely.
+const defaultAPITimeout = 30 * time.Second
+
+// applyAPITimeout sets the timeout of each request to the API server from
+// API_TIMEOUT.
+func applyAPITimeout(config *rest.Config) error {
+	timeout, err := time.ParseDuration(envOrDefault("API_TIMEOUT", defaultAPITimeout.String()))
+	if err != nil || timeout < 0 {
+		return fmt.Errorf("invalid API_TIMEOUT %q", os.Getenv("API_TIMEOUT"))
+	}
+	config.Timeout = timeout
+	return nil
+}
+
+// rootContext returns the context syncs run in, which is cancelled on
+// shutdown.
+func (fss *FileSecretSync) rootContext() context.Context {
+	if fss.ctx == nil {
+		return context.Background()
+	}
+	return fss.ctx
+}
@@ -0,0 +1,60 @@
+package syncer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+func TestApplyAPITimeout(t *testing.T) {
+	testCases := []struct {
+		value     string
+		expected  time.Duration
+		expectErr bool
+	}{
+		{"", defaultAPITimeout, false},
+		{"5s", 5 * time.Second, false},
+		{"0s", 0, false},
+		{"soon", 0, true},
+		{"-1s", 0, true},
+	}
+
+	for _, tc := range testCases {
+		t.Setenv("API_TIMEOUT", tc.value)
+		config := &rest.Config{}
+		err := applyAPITimeout(config)
+		if (err != nil) != tc.expectErr {
+			t.Errorf("applyAPITimeout(%q) error = %v, expected error: %v", tc.value, err, tc.expectErr)
+			continue
+		}
+		if !tc.expectErr && config.Timeout != tc.expected {
+			t.Errorf("applyAPITimeout(%q) set %s, expected %s", tc.value, config.Timeout, tc.expected)
+		}
+	}
+}
+
+func TestStartMonitoringStopsOnShutdown(t *testing.T) {
+	tempDir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	fss := &FileSecretSync{
+		folderPath: tempDir,
+		tree:       newTestTree(t, tempDir),
+		stop:       make(chan struct{}),
+		ctx:        ctx,
+	}
+
+	done := make(chan error)
+	go func() { done <- fss.startMonitoring() }()
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("startMonitoring returned error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("startMonitoring did not return after the root context was cancelled")
+	}
+}
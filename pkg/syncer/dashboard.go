@@ -0,0 +1,105 @@
+package syncer
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"go-file-secret-sync/pkg/redact"
+)
+
+// dashboardRefresh is how often the dashboard page reloads itself.
+const dashboardRefresh = 10 * time.Second
+
+// dashboardMapping is a mapping as shown on the dashboard.
+type dashboardMapping struct {
+	mappingStatus
+	KeyNames     []string
+	RecentErrors []syncError
+}
+
+// dashboard returns the state of every mapping for the dashboard, sorted
+// like statuses. It only exposes key names, never values, and redacts
+// them like the logs.
+func (h *healthState) dashboard() []dashboardMapping {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	mappings := make([]dashboardMapping, 0, len(h.mappings))
+	for _, m := range h.mappings {
+		mapping := dashboardMapping{
+			mappingStatus: m.status(),
+			KeyNames:      redact.Names(m.keyNames),
+		}
+		// Most recent first
+		for i := len(m.recentErrors) - 1; i >= 0; i-- {
+			mapping.RecentErrors = append(mapping.RecentErrors, m.recentErrors[i])
+		}
+		mappings = append(mappings, mapping)
+	}
+	sort.Slice(mappings, func(i, j int) bool { return mappings[i].less(mappings[j].mappingStatus) })
+	return mappings
+}
+
+var dashboardTemplate = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"since": func(t time.Time) string { return time.Since(t).Round(time.Second).String() },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="{{.Refresh}}">
+<title>go-file-secret-sync</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ccc; padding: 0.4em; text-align: left; vertical-align: top; }
+.success { color: #060; } .failure { color: #a00; } .pending { color: #960; }
+code { font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>go-file-secret-sync</h1>
+<p>{{if .Ready}}<span class="success">Ready</span>{{else}}<span class="pending">Not ready</span>{{end}}
+&middot; {{len .Mappings}} mappings &middot; updated {{.Now.Format "2006-01-02 15:04:05 MST"}}</p>
+<table>
+<tr><th>Folder</th><th>Secret</th><th>Result</th><th>Last sync</th><th>Last success</th><th>Keys</th><th>Recent errors</th></tr>
+{{range .Mappings}}<tr>
+<td><code>{{.Folder}}</code></td>
+<td>{{.Namespace}}/{{.Secret}}</td>
+<td class="{{.Result}}">{{.Result}}</td>
+<td>{{with .LastAttempt}}{{since .}} ago{{else}}never{{end}}</td>
+<td>{{with .LastSuccess}}{{since .}} ago{{else}}never{{end}}</td>
+<td>{{.Keys}}{{range .KeyNames}}<br><code>{{.}}</code> = ********{{end}}</td>
+<td>{{range .RecentErrors}}<div class="failure">{{.At.Format "15:04:05"}}: {{.Message}}</div>{{end}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// registerDashboard serves the dashboard on / of mux, requiring the bearer
+// token of the admin API when token is set.
+func (s *syncerState) registerDashboard(mux *http.ServeMux, token string) {
+	if token == "" {
+		mux.HandleFunc("GET /{$}", s.health.serveDashboard)
+		return
+	}
+	mux.Handle("GET /{$}", requireToken(token, http.HandlerFunc(s.health.serveDashboard)))
+}
+
+// serveDashboard renders a read-only HTML overview of every mapping.
+func (h *healthState) serveDashboard(w http.ResponseWriter, r *http.Request) {
+	view := struct {
+		Ready    bool
+		Now      time.Time
+		Refresh  int
+		Mappings []dashboardMapping
+	}{h.ready(), time.Now(), int(dashboardRefresh.Seconds()), h.dashboard()}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := dashboardTemplate.Execute(w, view); err != nil {
+		log.Printf("Failed to render dashboard: %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package syncer
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-file-secret-sync/pkg/redact"
+)
+
+func TestDashboard(t *testing.T) {
+	h := &healthState{mappings: make(map[*FileSecretSync]*mappingHealth)}
+	app := &FileSecretSync{folderPath: "/secrets/app", namespace: "default", secretName: "app"}
+	db := &FileSecretSync{folderPath: "/secrets/db", namespace: "database", secretName: "db"}
+	h.track(app)
+	h.track(db)
+
+	h.recordKeys(app, []string{"ca.pem", "tls.key"})
+	h.recordSync(app, nil)
+	for i := 0; i < maxRecentErrors+2; i++ {
+		h.recordSync(db, fmt.Errorf("attempt %d: secrets <db> is forbidden", i))
+	}
+
+	mappings := h.dashboard()
+	if len(mappings) != 2 || mappings[0].Folder != "/secrets/app" {
+		t.Fatalf("Expected mappings sorted by folder, got %+v", mappings)
+	}
+	if errs := mappings[1].RecentErrors; len(errs) != maxRecentErrors || !strings.HasPrefix(errs[0].Message, "attempt 6:") {
+		t.Errorf("Expected the %d most recent errors, newest first, got %+v", maxRecentErrors, errs)
+	}
+
+	recorder := httptest.NewRecorder()
+	h.serveDashboard(recorder, httptest.NewRequest("GET", "/", nil))
+	body, _ := io.ReadAll(recorder.Result().Body)
+	page := string(body)
+
+	for _, expected := range []string{"/secrets/app", "default/app", "<code>ca.pem</code> = ********", "<code>tls.key</code>", "failure", "secrets &lt;db&gt; is forbidden"} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("Expected dashboard to contain %q", expected)
+		}
+	}
+	if strings.Contains(page, "attempt 0:") {
+		t.Error("Expected old errors to be dropped from the dashboard")
+	}
+
+	// A later success keeps the error history
+	h.recordSync(db, nil)
+	if len(h.dashboard()[1].RecentErrors) != maxRecentErrors {
+		t.Error("Expected recent errors to be kept after a successful sync")
+	}
+}
+
+func TestDashboardToken(t *testing.T) {
+	redact.Enable(true)
+	defer redact.Enable(false)
+
+	state := newSyncerState()
+	mux := state.newHTTPMux()
+	state.registerDashboard(mux, "secret-token")
+	fss := &FileSecretSync{folderPath: "/secrets/app", namespace: "default", secretName: "app", state: state}
+	state.health.track(fss)
+	state.health.recordKeys(fss, []string{"tls.key"})
+	state.health.recordSync(fss, &fs.PathError{Op: "open", Path: "/secrets/app/tls.key", Err: fs.ErrPermission})
+
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("Expected the dashboard to require the token, got status %d", recorder.Code)
+	}
+
+	request := httptest.NewRequest("GET", "/", nil)
+	request.Header.Set("Authorization", "Bearer secret-token")
+	recorder = httptest.NewRecorder()
+	mux.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected the dashboard with the token, got status %d", recorder.Code)
+	}
+	page := recorder.Body.String()
+	if strings.Contains(page, "tls.key") {
+		t.Errorf("Expected key names and errors to be redacted, got:\n%s", page)
+	}
+	if !strings.Contains(page, "<code>"+redact.Name("tls.key")+"</code>") {
+		t.Errorf("Expected the redacted key name on the dashboard, got:\n%s", page)
+	}
+}
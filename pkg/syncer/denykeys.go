@@ -0,0 +1,22 @@
+package syncer
+
+import (
+	"log"
+
+	"go-file-secret-sync/pkg/redact"
+	"go-file-secret-sync/pkg/source"
+)
+
+// dropDeniedKeys removes the files whose key or path matches DENY_KEYS
+// from files, so they are never written whatever ends up in the folder.
+func (fss *FileSecretSync) dropDeniedKeys(files map[string]source.File) {
+	if len(fss.denyKeys) == 0 {
+		return
+	}
+	for key, file := range files {
+		if source.MatchesAny(fss.denyKeys, key) || source.MatchesAny(fss.denyKeys, file.Path) {
+			log.Printf("Warning: not syncing denied key %s", redact.Name(key))
+			delete(files, key)
+		}
+	}
+}
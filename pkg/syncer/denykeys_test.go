@@ -0,0 +1,82 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"go-file-secret-sync/pkg/source"
+)
+
+func TestDropDeniedKeys(t *testing.T) {
+	files := map[string]source.File{
+		"id_rsa":           {Path: "id_rsa"},
+		"ssh.id_ed25519":   {Path: "ssh/id_ed25519"},
+		"config.yaml":      {Path: "config.yaml"},
+		"certs.server.key": {Path: "certs/server.key"},
+	}
+
+	testCases := []struct {
+		name     string
+		denyKeys []string
+		expected []string
+	}{
+		{"none", nil, []string{"certs.server.key", "config.yaml", "id_rsa", "ssh.id_ed25519"}},
+		{"key", []string{"id_rsa"}, []string{"certs.server.key", "config.yaml", "ssh.id_ed25519"}},
+		{"file name in a subdirectory", []string{"id_*"}, []string{"certs.server.key", "config.yaml"}},
+		{"path", []string{"certs/*"}, []string{"config.yaml", "id_rsa", "ssh.id_ed25519"}},
+		{"dotted key", []string{"certs.*"}, []string{"config.yaml", "id_rsa", "ssh.id_ed25519"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			remaining := make(map[string]source.File, len(files))
+			for key, file := range files {
+				remaining[key] = file
+			}
+			fss := &FileSecretSync{denyKeys: tc.denyKeys}
+			fss.dropDeniedKeys(remaining)
+
+			var keys []string
+			for key := range remaining {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			if !reflect.DeepEqual(keys, tc.expected) {
+				t.Errorf("Expected keys %v, got %v", tc.expected, keys)
+			}
+		})
+	}
+}
+
+func TestSyncFilesDenyKeys(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "id_rsa"), []byte("PRIVATE"), 0600)
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("test: value"), 0644)
+
+	client := fake.NewSimpleClientset()
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		folderPath: tempDir,
+		denyKeys:   []string{"id_*"},
+	}
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+
+	secret, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if _, ok := secret.Data["id_rsa"]; ok || len(secret.Data) != 1 {
+		t.Errorf("Expected only config.yaml, got %v", secret.Data)
+	}
+}
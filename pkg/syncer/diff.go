@@ -0,0 +1,70 @@
+package syncer
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+// RunDiff implements the diff command, which prints the changes a sync
+// would make to the live Secrets:
+//
+//	go-file-secret-sync diff [-show-values]
+func RunDiff(args []string) int {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	showValues := flags.Bool("show-values", false, "print values instead of their lengths")
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	fss, err := newFromEnvironment()
+	if err != nil {
+		log.Printf("Invalid configuration: %v", err)
+		return 1
+	}
+	if fss.target != nil {
+		log.Printf("diff only supports the kubernetes target")
+		return 1
+	}
+
+	drifts, err := fss.detectMappingDrift(context.Background(), os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		log.Printf("Diff failed: %v", err)
+		return 1
+	}
+	printDiff(os.Stdout, drifts, *showValues)
+	return 0
+}
+
+// printDiff writes the pending changes of every Secret in drifts to w.
+// Values are shown as their length unless showValues is set: even a hash
+// of a short value, such as a PIN, can be reversed by trying every value.
+func printDiff(w io.Writer, drifts []secretDrift, showValues bool) {
+	format := func(value []byte) string {
+		if showValues {
+			return fmt.Sprintf("%q", value)
+		}
+		return fmt.Sprintf("%d bytes", len(value))
+	}
+
+	for _, drift := range drifts {
+		if drift.missing {
+			fmt.Fprintf(w, "secret %s (new)\n", drift.name)
+		} else {
+			fmt.Fprintf(w, "secret %s\n", drift.name)
+		}
+		for _, change := range drift.changes {
+			switch change.change {
+			case "added":
+				fmt.Fprintf(w, "+ %s %s\n", change.key, format(drift.desired[change.key]))
+			case "removed":
+				fmt.Fprintf(w, "- %s %s\n", change.key, format(drift.live[change.key]))
+			case "changed":
+				fmt.Fprintf(w, "~ %s %s -> %s\n", change.key, format(drift.live[change.key]), format(drift.desired[change.key]))
+			}
+		}
+	}
+}
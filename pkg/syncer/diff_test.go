@@ -0,0 +1,52 @@
+package syncer
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrintDiff(t *testing.T) {
+	drifts := []secretDrift{
+		{
+			name:    "app",
+			live:    map[string][]byte{"config.yaml": []byte("old"), "old.txt": []byte("gone")},
+			desired: map[string][]byte{"config.yaml": []byte("new"), "new.txt": []byte("added")},
+		},
+		{
+			name:    "db",
+			missing: true,
+			desired: map[string][]byte{"password": []byte("s3cr3t")},
+		},
+	}
+	for i := range drifts {
+		drifts[i].changes = compareData(drifts[i].live, drifts[i].desired)
+	}
+
+	testCases := []struct {
+		showValues bool
+		expected   string
+	}{
+		{false, `secret app
+~ config.yaml 3 bytes -> 3 bytes
++ new.txt 5 bytes
+- old.txt 4 bytes
+secret db (new)
++ password 6 bytes
+`},
+		{true, `secret app
+~ config.yaml "old" -> "new"
++ new.txt "added"
+- old.txt "gone"
+secret db (new)
++ password "s3cr3t"
+`},
+	}
+
+	for _, tc := range testCases {
+		var out bytes.Buffer
+		printDiff(&out, drifts, tc.showValues)
+		if out.String() != tc.expected {
+			t.Errorf("printDiff(showValues=%v):\n%s\nexpected:\n%s", tc.showValues, out.String(), tc.expected)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package syncer
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go-file-secret-sync/pkg/redact"
+)
+
+// dataDigests holds the SHA-256 digest of every value of a Secret's data,
+// keyed like the data. Comparing digests tells which keys changed without
+// keeping a second copy of the values around.
+type dataDigests map[string][sha256.Size]byte
+
+// digestData returns the per-key digests of data.
+func digestData(data map[string][]byte) dataDigests {
+	digests := make(dataDigests, len(data))
+	for key, value := range data {
+		digests[key] = sha256.Sum256(value)
+	}
+	return digests
+}
+
+// changes returns the keys that would change when the data d was taken
+// from is replaced by the data desired was taken from, sorted by key.
+func (d dataDigests) changes(desired dataDigests) []keyChange {
+	var changes []keyChange
+	for key, digest := range desired {
+		current, exists := d[key]
+		if !exists {
+			changes = append(changes, keyChange{key, "added"})
+		} else if current != digest {
+			changes = append(changes, keyChange{key, "changed"})
+		}
+	}
+	for key := range d {
+		if _, exists := desired[key]; !exists {
+			changes = append(changes, keyChange{key, "removed"})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].key < changes[j].key })
+	return changes
+}
+
+// formatChanges describes changed keys for logging, e.g.
+// "2 changed keys: ca.pem (changed), tls.key (added)".
+func formatChanges(changes []keyChange) string {
+	parts := make([]string, len(changes))
+	for i, change := range changes {
+		parts[i] = fmt.Sprintf("%s (%s)", redact.Name(change.key), change.change)
+	}
+	return fmt.Sprintf("%d changed keys: %s", len(changes), strings.Join(parts, ", "))
+}
@@ -0,0 +1,35 @@
+package syncer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDigestChanges(t *testing.T) {
+	live := map[string][]byte{
+		"same":    []byte("value"),
+		"changed": []byte("old"),
+		"removed": []byte("gone"),
+		"empty":   {},
+	}
+	desired := map[string][]byte{
+		"same":    []byte("value"),
+		"changed": []byte("new"),
+		"added":   []byte("fresh"),
+		"empty":   nil,
+	}
+
+	changes := digestData(live).changes(digestData(desired))
+	expected := []keyChange{{"added", "added"}, {"changed", "changed"}, {"removed", "removed"}}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected %v, got %v", expected, changes)
+	}
+
+	if changes := digestData(live).changes(digestData(live)); len(changes) != 0 {
+		t.Errorf("Expected no changes for identical data, got %v", changes)
+	}
+
+	if got := formatChanges(expected); got != "3 changed keys: added (added), changed (changed), removed (removed)" {
+		t.Errorf("Unexpected formatted changes: %q", got)
+	}
+}
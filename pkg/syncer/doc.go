@@ -0,0 +1,9 @@
+// Package syncer syncs the files of a folder to Kubernetes Secrets and
+// keeps them in sync as the files change. The go-file-secret-sync binary
+// is a thin wrapper around it; other programs can embed it with
+// New(cfg).Run(ctx).
+//
+// Reading the files is done by package source, writing to destinations
+// other than the Kubernetes API by package store, and watching the folder
+// by package watch.
+package syncer
@@ -0,0 +1,78 @@
+package syncer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"go-file-secret-sync/pkg/redact"
+	"go-file-secret-sync/pkg/source"
+)
+
+// dockerConfig is the format of .dockerconfigjson, and of the Docker
+// config.json files it is merged from.
+type dockerConfig struct {
+	Auths map[string]json.RawMessage `json:"auths"`
+}
+
+// packDockerConfig merges the registry credentials of files into a single
+// .dockerconfigjson. Each file is a Docker config.json, holding the
+// credentials under "auths", or a legacy .dockercfg mapping registries to
+// credentials directly. Other settings, such as credential helpers, are
+// left out. A registry with different credentials in two files is an
+// error.
+func packDockerConfig(files map[string]source.File) ([]byte, error) {
+	keys := make([]string, 0, len(files))
+	for key := range files {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	merged := dockerConfig{Auths: make(map[string]json.RawMessage)}
+	definedBy := make(map[string]string)
+	for _, key := range keys {
+		auths, err := registryAuths(files[key].Content)
+		if err != nil {
+			return nil, fmt.Errorf("file %s is not a Docker config: %w", redact.Name(key), err)
+		}
+		for registry, auth := range auths {
+			if previous, ok := merged.Auths[registry]; ok && !bytes.Equal(previous, auth) {
+				return nil, fmt.Errorf("registry %s has different credentials in %s and %s",
+					registry, redact.Name(definedBy[registry]), redact.Name(key))
+			}
+			merged.Auths[registry] = auth
+			definedBy[registry] = key
+		}
+	}
+	// Map keys are marshalled in sorted order, so the output is stable
+	return json.Marshal(merged)
+}
+
+// registryAuths returns the credentials of every registry in a Docker
+// config.json or .dockercfg, each re-encoded with sorted fields so equal
+// credentials compare equal.
+func registryAuths(content []byte) (map[string]json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(content, &fields); err != nil {
+		return nil, err
+	}
+	raw, ok := fields["auths"]
+	if !ok {
+		raw = content
+	}
+
+	var entries map[string]map[string]any
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, fmt.Errorf("credentials must map registries to objects")
+	}
+	auths := make(map[string]json.RawMessage, len(entries))
+	for registry, entry := range entries {
+		auth, err := json.Marshal(entry)
+		if err != nil {
+			return nil, err
+		}
+		auths[registry] = auth
+	}
+	return auths, nil
+}
@@ -0,0 +1,108 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"go-file-secret-sync/pkg/source"
+)
+
+func TestPackDockerConfig(t *testing.T) {
+	testCases := []struct {
+		name      string
+		files     map[string]string
+		expected  string
+		expectErr bool
+	}{
+		{
+			name: "merged",
+			files: map[string]string{
+				"ghcr.json":   `{"auths": {"ghcr.io": {"auth": "Z2g6dG9rZW4="}}, "credsStore": "desktop"}`,
+				"docker.json": `{"auths": {"https://index.docker.io/v1/": {"username": "u", "password": "p"}}}`,
+			},
+			expected: `{"auths":{"ghcr.io":{"auth":"Z2g6dG9rZW4="},"https://index.docker.io/v1/":{"password":"p","username":"u"}}}`,
+		},
+		{
+			name:     "legacy dockercfg",
+			files:    map[string]string{".dockercfg": `{"quay.io": {"auth": "cTp0"}}`},
+			expected: `{"auths":{"quay.io":{"auth":"cTp0"}}}`,
+		},
+		{
+			name: "same credentials twice",
+			files: map[string]string{
+				"a.json": `{"auths": {"ghcr.io": {"auth": "YQ==", "email": "e"}}}`,
+				"b.json": `{"auths": {"ghcr.io": {"email": "e", "auth": "YQ=="}}}`,
+			},
+			expected: `{"auths":{"ghcr.io":{"auth":"YQ==","email":"e"}}}`,
+		},
+		{
+			name: "conflicting credentials",
+			files: map[string]string{
+				"a.json": `{"auths": {"ghcr.io": {"auth": "YQ=="}}}`,
+				"b.json": `{"auths": {"ghcr.io": {"auth": "Yg=="}}}`,
+			},
+			expectErr: true,
+		},
+		{"not json", map[string]string{"README": "registry credentials"}, "", true},
+		{"not a registry map", map[string]string{"a.json": `{"auths": ["ghcr.io"]}`}, "", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			files := make(map[string]source.File)
+			for key, content := range tc.files {
+				files[key] = source.File{Path: key, Content: []byte(content)}
+			}
+			config, err := packDockerConfig(files)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("packDockerConfig() error = %v, expectErr %v", err, tc.expectErr)
+			}
+			if !tc.expectErr && string(config) != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, config)
+			}
+		})
+	}
+}
+
+func TestSyncFilesDockerConfig(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "ghcr.json"), []byte(`{"auths": {"ghcr.io": {"auth": "YQ=="}}}`), 0644)
+
+	// An Opaque Secret of the same name is replaced
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pull-secret",
+			Namespace: "test-namespace",
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": "file-secret-sync"},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{"ghcr.json": []byte("old")},
+	})
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		secretName: "pull-secret",
+		folderPath: tempDir,
+		dataFormat: "dockerconfigjson",
+	}
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+
+	secret, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "pull-secret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if secret.Type != corev1.SecretTypeDockerConfigJson {
+		t.Errorf("Expected type %s, got %s", corev1.SecretTypeDockerConfigJson, secret.Type)
+	}
+	if string(secret.Data[corev1.DockerConfigJsonKey]) != `{"auths":{"ghcr.io":{"auth":"YQ=="}}}` || len(secret.Data) != 1 {
+		t.Errorf("Unexpected data: %q", secret.Data)
+	}
+}
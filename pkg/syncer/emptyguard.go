@@ -0,0 +1,55 @@
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// guardEmptySource fails a sync that found no files when the Secrets were
+// synced from files before, which is typical of a volume that failed to
+// mount rather than of files that were deleted on purpose. The failure is
+// counted and recorded as a Warning event.
+func (fss *FileSecretSync) guardEmptySource(ctx context.Context) error {
+	var reason string
+	if len(fss.lastChecksums) > 0 {
+		reason = fmt.Sprintf("the last sync wrote %d secrets", len(fss.lastChecksums))
+	} else if keys := fss.liveKeyCount(ctx); keys > 0 {
+		reason = fmt.Sprintf("secret %s holds %d keys", fss.secretName, keys)
+	} else {
+		return nil
+	}
+
+	message := fmt.Sprintf("No files found in %s while %s; not emptying it, the volume may have failed to mount. Set ALLOW_SHRINK_TO_ZERO=true if the files were removed on purpose", fss.folderPath, reason)
+	log.Printf("Warning: %s", message)
+	fss.shared().metrics.emptySourceBlocks.With(fss.metricLabels()).Inc()
+	if fss.client != nil && fss.secretName != "" {
+		fss.recordEvent(ctx, fss.secretName, corev1.EventTypeWarning, "EmptySource", message)
+	}
+	return fmt.Errorf("no files found in %s while %s", fss.folderPath, reason)
+}
+
+// liveKeyCount returns how many keys the Secret of a single-Secret mapping
+// holds, or zero when it cannot be read.
+func (fss *FileSecretSync) liveKeyCount(ctx context.Context) int {
+	if fss.client == nil || fss.target != nil || fss.secretName == "" || fss.secretMode == "per-directory" || fss.secretMode == "per-file" {
+		return 0
+	}
+	secret, err := fss.client.CoreV1().Secrets(fss.namespace).Get(ctx, fss.secretName, metav1.GetOptions{})
+	if err != nil {
+		return 0
+	}
+	return len(secret.Data)
+}
+
+// shrinkToZero returns the empty data written when ALLOW_SHRINK_TO_ZERO
+// is set and no files were found, or nil when there is nothing to empty.
+func (fss *FileSecretSync) shrinkToZero() map[string]map[string][]byte {
+	if fss.secretName == "" || fss.versionedNames || fss.secretMode == "per-directory" || fss.secretMode == "per-file" {
+		return nil
+	}
+	return map[string]map[string][]byte{fss.secretName: {}}
+}
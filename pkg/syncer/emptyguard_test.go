@@ -0,0 +1,77 @@
+package syncer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSyncFilesEmptySource(t *testing.T) {
+	existing := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+		Data:       map[string][]byte{"password": []byte("hunter2"), "username": []byte("admin")},
+	}
+
+	testCases := []struct {
+		name          string
+		existing      bool
+		allowShrink   bool
+		expectErr     bool
+		expectedKeys  int
+		expectedEvent bool
+	}{
+		{"existing secret", true, false, true, 2, true},
+		{"shrink allowed", true, true, false, 0, false},
+		{"no secret yet", false, false, false, -1, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			if tc.existing {
+				client = fake.NewSimpleClientset(existing.DeepCopy())
+			}
+			fss := &FileSecretSync{
+				client:            client,
+				namespace:         "test-namespace",
+				secretName:        "test-secret",
+				folderPath:        t.TempDir(),
+				allowShrinkToZero: tc.allowShrink,
+			}
+			before := testutil.ToFloat64(fss.shared().metrics.emptySourceBlocks.With(fss.metricLabels()))
+
+			err := fss.syncFiles()
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("syncFiles() error = %v, expectErr %v", err, tc.expectErr)
+			}
+
+			secret, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{})
+			if tc.expectedKeys < 0 {
+				if err == nil {
+					t.Error("Expected no secret to be created")
+				}
+			} else if err != nil {
+				t.Fatalf("Failed to get secret: %v", err)
+			} else if len(secret.Data) != tc.expectedKeys {
+				t.Errorf("Expected %d keys, got %v", tc.expectedKeys, secret.Data)
+			}
+
+			blocks := testutil.ToFloat64(fss.shared().metrics.emptySourceBlocks.With(fss.metricLabels())) - before
+			events, _ := client.CoreV1().Events("test-namespace").List(context.Background(), metav1.ListOptions{})
+			if tc.expectedEvent {
+				if blocks != 1 {
+					t.Errorf("Expected the block to be counted, got %v", blocks)
+				}
+				if len(events.Items) != 1 || events.Items[0].Reason != "EmptySource" {
+					t.Errorf("Expected an EmptySource event, got %+v", events.Items)
+				}
+			} else if blocks != 0 || len(events.Items) != 0 {
+				t.Errorf("Expected no block, got %v blocks and events %+v", blocks, events.Items)
+			}
+		})
+	}
+}
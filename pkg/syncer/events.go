@@ -0,0 +1,154 @@
+package syncer
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Types of sync events.
+const (
+	eventSyncStarted   = "sync-started"
+	eventSyncSucceeded = "sync-succeeded"
+	eventSyncFailed    = "sync-failed"
+	eventKeysChanged   = "keys-changed"
+)
+
+// eventBufferSize is how many events a subscriber may fall behind before
+// further events are dropped for it.
+const eventBufferSize = 64
+
+// eventKeepAlive is how often an idle event stream sends a comment, so
+// proxies do not close it.
+const eventKeepAlive = 15 * time.Second
+
+// syncEvent is a structured event emitted while syncing.
+type syncEvent struct {
+	Type      string        `json:"type"`
+	Time      time.Time     `json:"time"`
+	Folder    string        `json:"folder"`
+	Namespace string        `json:"namespace"`
+	Secret    string        `json:"secret,omitempty"`
+	Changes   []eventChange `json:"changes,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// eventChange is a changed key of a keys-changed event.
+type eventChange struct {
+	Key    string `json:"key"`
+	Change string `json:"change"`
+}
+
+// eventBroker fans sync events out to every subscriber. Publishing never
+// blocks syncing: events are dropped for subscribers that fall behind.
+type eventBroker struct {
+	mu          sync.Mutex
+	subscribers map[chan syncEvent]struct{}
+}
+
+// subscribe returns a channel receiving every event published from now
+// on, and a function that ends the subscription.
+func (b *eventBroker) subscribe() (<-chan syncEvent, func()) {
+	ch := make(chan syncEvent, eventBufferSize)
+	b.mu.Lock()
+	if b.subscribers == nil {
+		b.subscribers = make(map[chan syncEvent]struct{})
+	}
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		delete(b.subscribers, ch)
+		b.mu.Unlock()
+	}
+}
+
+func (b *eventBroker) publish(event syncEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// The subscriber fell behind
+		}
+	}
+}
+
+// emitEvent publishes an event of the given type for this mapping.
+func (fss *FileSecretSync) emitEvent(eventType string, err error) {
+	event := syncEvent{
+		Type:      eventType,
+		Time:      time.Now(),
+		Folder:    fss.folderPath,
+		Namespace: fss.namespace,
+		Secret:    fss.secretName,
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	fss.shared().events.publish(event)
+}
+
+// emitKeysChanged publishes the keys that changed in the named Secret, and
+// remembers them for the notification of the current sync.
+func (fss *FileSecretSync) emitKeysChanged(name string, changes []keyChange) {
+	event := syncEvent{
+		Type:      eventKeysChanged,
+		Time:      time.Now(),
+		Folder:    fss.folderPath,
+		Namespace: fss.namespace,
+		Secret:    name,
+		Changes:   make([]eventChange, len(changes)),
+	}
+	for i, change := range changes {
+		event.Changes[i] = eventChange{Key: change.key, Change: change.change}
+		fss.syncChanges = append(fss.syncChanges, notifiedChange{name, change.key, change.change})
+	}
+	fss.shared().events.publish(event)
+}
+
+// serveEvents streams sync events as server-sent events until the client
+// disconnects.
+func (b *eventBroker) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ch, unsubscribe := b.subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case event := <-ch:
+			payload, err := json.Marshal(event)
+			if err != nil {
+				log.Printf("Failed to encode event: %v", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, payload); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,131 @@
+package syncer
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSyncEvents(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "ca.pem"), []byte("CA"), 0644)
+
+	fss := &FileSecretSync{
+		client:     fake.NewSimpleClientset(),
+		folderPath: tempDir,
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+	}
+
+	ch, unsubscribe := fss.shared().events.subscribe()
+	defer unsubscribe()
+
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+	os.WriteFile(filepath.Join(tempDir, "ca.pem"), []byte("new CA"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "tls.crt"), []byte("cert"), 0644)
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+
+	expected := []string{
+		eventSyncStarted, eventKeysChanged + " ca.pem:added", eventSyncSucceeded,
+		eventSyncStarted, eventKeysChanged + " ca.pem:changed tls.crt:added", eventSyncSucceeded,
+	}
+	for _, want := range expected {
+		var event syncEvent
+		select {
+		case event = <-ch:
+		default:
+			t.Fatalf("Expected event %q, got none", want)
+		}
+		got := event.Type
+		for _, change := range event.Changes {
+			got += " " + change.Key + ":" + change.Change
+		}
+		if got != want || event.Folder != tempDir || event.Namespace != "test-namespace" {
+			t.Errorf("Expected event %q for %s, got %q for %s", want, tempDir, got, event.Folder)
+		}
+	}
+
+	// A failing sync reports its error
+	fss.folderPath = filepath.Join(tempDir, "missing")
+	fss.syncFiles()
+	for event := range ch {
+		if event.Type == eventSyncFailed {
+			if event.Error == "" {
+				t.Error("Expected sync-failed event to carry the error")
+			}
+			break
+		}
+		if event.Type != eventSyncStarted {
+			t.Fatalf("Expected sync-failed event, got %s", event.Type)
+		}
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	state := newSyncerState()
+	mux := state.newHTTPMux()
+	state.registerAdminAPI(mux, "secret-token")
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/events", nil)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open event stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %q", ct)
+	}
+
+	// The headers are flushed after subscribing
+	fss := &FileSecretSync{folderPath: "/secrets/events", namespace: "test-namespace", secretName: "test-secret", state: state}
+	fss.emitEvent(eventSyncStarted, nil)
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	var eventType string
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatal("Event stream ended before an event was received")
+			}
+			if name, found := strings.CutPrefix(line, "event: "); found {
+				eventType = name
+			}
+			if data, found := strings.CutPrefix(line, "data: "); found {
+				var event syncEvent
+				if err := json.Unmarshal([]byte(data), &event); err != nil {
+					t.Fatalf("Failed to decode event: %v", err)
+				}
+				if eventType != eventSyncStarted || event.Folder != "/secrets/events" {
+					t.Errorf("Unexpected event %s: %+v", eventType, event)
+				}
+				return
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for an event")
+		}
+	}
+}
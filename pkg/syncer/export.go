@@ -0,0 +1,120 @@
+package syncer
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"go-file-secret-sync/pkg/redact"
+	"go-file-secret-sync/pkg/store"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// RunExport implements the export command, which writes every key of a
+// Secret to a file in a directory:
+//
+//	go-file-secret-sync export [-secret <name>] [-namespace <namespace>] [-file-mode 0600] [-dir-mode 0700] <dir>
+func RunExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	name := flags.String("secret", os.Getenv("SECRET_TO_WRITE"), "Secret to export (default SECRET_TO_WRITE)")
+	namespace := flags.String("namespace", "", "namespace of the Secret (default the current namespace)")
+	fileMode := flags.String("file-mode", "0600", "permissions of the written files")
+	dirMode := flags.String("dir-mode", "0700", "permissions of the directory if it is created")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	// Every namespace has its own Secret, named like the sync names it
+	if os.Getenv("SECRET_MODE") == "per-namespace" {
+		if *namespace == "" {
+			return fmt.Errorf("-namespace is required with SECRET_MODE=per-namespace")
+		}
+		secretSet := false
+		flags.Visit(func(f *flag.Flag) { secretSet = secretSet || f.Name == "secret" })
+		if !secretSet {
+			fss, err := newFromEnvironment()
+			if err != nil {
+				return fmt.Errorf("invalid configuration: %w", err)
+			}
+			if *name, err = fss.namespaceSecretName(*namespace); err != nil {
+				return err
+			}
+		}
+	}
+	if flags.NArg() != 1 || *name == "" {
+		return fmt.Errorf("usage: export [-secret <name>] [-namespace <namespace>] [-file-mode 0600] [-dir-mode 0700] <dir>")
+	}
+
+	filePerm, err := strconv.ParseUint(*fileMode, 8, 32)
+	if err != nil {
+		return fmt.Errorf("invalid -file-mode %q: %w", *fileMode, err)
+	}
+	dirPerm, err := strconv.ParseUint(*dirMode, 8, 32)
+	if err != nil {
+		return fmt.Errorf("invalid -dir-mode %q: %w", *dirMode, err)
+	}
+
+	if *namespace == "" {
+		*namespace, err = getCurrentNamespace()
+		if err != nil {
+			return err
+		}
+	}
+
+	client, err := newKubernetesClient()
+	if err != nil {
+		return err
+	}
+
+	return exportSecret(context.Background(), client, *namespace, *name, flags.Arg(0), fs.FileMode(filePerm), fs.FileMode(dirPerm))
+}
+
+// namespaceSecretName returns the name of the Secret that
+// SECRET_MODE=per-namespace writes to namespace.
+func (fss *FileSecretSync) namespaceSecretName(namespace string) (string, error) {
+	mappings, err := fss.offlineMappings("")
+	if err != nil {
+		return "", err
+	}
+	for _, mapping := range mappings {
+		if mapping.namespace == namespace {
+			return mapping.secretName, nil
+		}
+	}
+	return "", fmt.Errorf("no allowed folder of %s maps to namespace %s", fss.folderPath, namespace)
+}
+
+// exportSecret writes every key of the Secret name to a file of the same
+// name in dir, creating dir with dirMode if needed.
+func exportSecret(ctx context.Context, client kubernetes.Interface, namespace, name, dir string, fileMode, dirMode fs.FileMode) error {
+	secret, err := client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get secret %s: %w", name, err)
+	}
+
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	for key, value := range secret.Data {
+		// Secret keys cannot contain separators, but "." and ".." are
+		// not valid file names either
+		if key == "." || key == ".." || filepath.Base(key) != key {
+			return fmt.Errorf("secret %s has key %q that cannot be used as a file name", name, redact.Name(key))
+		}
+		path := filepath.Join(dir, key)
+		if err := store.WriteFileAtomic(path, value, fileMode); err != nil {
+			return fmt.Errorf("failed to write %s: %w", redact.Name(path), redact.Error(err))
+		}
+		log.Printf("Exported key: %s -> %s (%d bytes)", redact.Name(key), redact.Name(path), len(value))
+	}
+
+	log.Printf("Exported secret %s with %d keys to %s", name, len(secret.Data), dir)
+	return nil
+}
@@ -0,0 +1,90 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestExportSecret(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+		Data: map[string][]byte{
+			"config.yaml":   []byte("test: value"),
+			"certs.tls.crt": []byte("CERT"),
+		},
+	})
+
+	dir := filepath.Join(t.TempDir(), "export")
+	if err := exportSecret(context.Background(), client, "test-namespace", "test-secret", dir, 0640, 0750); err != nil {
+		t.Fatalf("exportSecret failed: %v", err)
+	}
+
+	for key, expected := range map[string]string{"config.yaml": "test: value", "certs.tls.crt": "CERT"} {
+		path := filepath.Join(dir, key)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read exported file: %v", err)
+		}
+		if string(content) != expected {
+			t.Errorf("Expected %s to contain %q, got %q", key, expected, content)
+		}
+		info, _ := os.Stat(path)
+		if info.Mode().Perm() != 0640 {
+			t.Errorf("Expected %s to have mode 0640, got %o", key, info.Mode().Perm())
+		}
+	}
+
+	info, _ := os.Stat(dir)
+	if info.Mode().Perm() != 0750 {
+		t.Errorf("Expected directory mode 0750, got %o", info.Mode().Perm())
+	}
+
+	// Exported folders read back into the same data
+	fss := &FileSecretSync{folderPath: dir}
+	data, err := fss.readFolderContents()
+	if err != nil {
+		t.Fatalf("readFolderContents failed: %v", err)
+	}
+	if string(data["certs.tls.crt"]) != "CERT" || len(data) != 2 {
+		t.Errorf("Expected exported folder to round-trip, got %v", data)
+	}
+
+	if err := exportSecret(context.Background(), client, "test-namespace", "missing", dir, 0600, 0700); err == nil {
+		t.Error("Expected export of a missing secret to fail")
+	}
+}
+
+func TestNamespaceSecretName(t *testing.T) {
+	fsys := fstest.MapFS{
+		"drop/team-a/token": {Data: []byte("a")},
+		"drop/team-b/token": {Data: []byte("b")},
+	}
+	fss := &FileSecretSync{
+		folderPath:        "drop",
+		secretMode:        "per-namespace",
+		nameTemplate:      template.Must(template.New("test").Parse(`tokens-{{ .Namespace }}`)),
+		allowedNamespaces: []string{"team-a"},
+	}
+	fss.reader.FS = fsys
+
+	name, err := fss.namespaceSecretName("team-a")
+	if err != nil {
+		t.Fatalf("namespaceSecretName failed: %v", err)
+	}
+	if name != "tokens-team-a" {
+		t.Errorf("Expected tokens-team-a, got %q", name)
+	}
+
+	// Folders outside the allowlist map to no Secret
+	if _, err := fss.namespaceSecretName("team-b"); err == nil {
+		t.Error("Expected an error for a namespace that is not allowed")
+	}
+}
@@ -0,0 +1,29 @@
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// editedExternally reports whether the data of secret no longer matches
+// the checksum stamped when it was last written, because someone changed
+// it by hand. Secrets without the annotation were never written by a sync.
+func editedExternally(secret *corev1.Secret) bool {
+	stamped, ok := secret.Annotations[checksumAnnotation]
+	return ok && stamped != dataChecksum(secret.Data)
+}
+
+// reportExternalEdit refuses to overwrite a Secret that was edited by hand,
+// counting it and recording a Warning event, so the edit can be reviewed
+// instead of being lost.
+func (fss *FileSecretSync) reportExternalEdit(ctx context.Context, secret *corev1.Secret) error {
+	message := fmt.Sprintf("Secret data was changed outside of file-secret-sync (checksum %s, last written %s); not overwriting it. Undo the edit, remove the %s annotation to accept it, or set FORCE_OVERWRITE=true",
+		dataChecksum(secret.Data), secret.Annotations[checksumAnnotation], checksumAnnotation)
+	log.Printf("Warning: secret %s: %s", secret.Name, message)
+	fss.shared().metrics.externalEdits.With(fss.metricLabels()).Inc()
+	fss.recordEvent(ctx, secret.Name, corev1.EventTypeWarning, "ExternalEdit", message)
+	return fmt.Errorf("secret %s was changed outside of file-secret-sync", secret.Name)
+}
@@ -0,0 +1,88 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSyncFilesExternalEdit(t *testing.T) {
+	testCases := []struct {
+		name           string
+		forceOverwrite bool
+		expectErr      bool
+		expected       string
+	}{
+		{"protected", false, true, "edited by hand"},
+		{"force overwrite", true, false, "from file v2"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			os.WriteFile(filepath.Join(tempDir, "password"), []byte("from file v1"), 0644)
+
+			client := fake.NewSimpleClientset()
+			fss := &FileSecretSync{
+				client:         client,
+				namespace:      "test-namespace",
+				secretName:     "test-secret",
+				folderPath:     tempDir,
+				forceOverwrite: tc.forceOverwrite,
+			}
+			if err := fss.syncFiles(); err != nil {
+				t.Fatalf("syncFiles failed: %v", err)
+			}
+
+			// Someone edits the Secret, then the file changes
+			secrets := client.CoreV1().Secrets("test-namespace")
+			secret, _ := secrets.Get(context.Background(), "test-secret", metav1.GetOptions{})
+			secret.Data["password"] = []byte("edited by hand")
+			secrets.Update(context.Background(), secret, metav1.UpdateOptions{})
+			os.WriteFile(filepath.Join(tempDir, "password"), []byte("from file v2"), 0644)
+
+			err := fss.syncFiles()
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("syncFiles() error = %v, expectErr %v", err, tc.expectErr)
+			}
+			secret, _ = secrets.Get(context.Background(), "test-secret", metav1.GetOptions{})
+			if string(secret.Data["password"]) != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, secret.Data["password"])
+			}
+			events, _ := client.CoreV1().Events("test-namespace").List(context.Background(), metav1.ListOptions{})
+			if tc.expectErr && (len(events.Items) != 1 || events.Items[0].Reason != "ExternalEdit") {
+				t.Errorf("Expected an ExternalEdit event, got %+v", events.Items)
+			}
+		})
+	}
+}
+
+func TestSyncFilesExternalEditMatchingFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "password"), []byte("v1"), 0644)
+
+	client := fake.NewSimpleClientset()
+	fss := &FileSecretSync{client: client, namespace: "test-namespace", secretName: "test-secret", folderPath: tempDir}
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+
+	// The same change was made by hand and to the files, so nothing is lost
+	secrets := client.CoreV1().Secrets("test-namespace")
+	secret, _ := secrets.Get(context.Background(), "test-secret", metav1.GetOptions{})
+	secret.Data["password"] = []byte("v2")
+	secrets.Update(context.Background(), secret, metav1.UpdateOptions{})
+	os.WriteFile(filepath.Join(tempDir, "password"), []byte("v2"), 0644)
+
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+	secret, _ = secrets.Get(context.Background(), "test-secret", metav1.GetOptions{})
+	if secret.Annotations[checksumAnnotation] != dataChecksum(secret.Data) {
+		t.Errorf("Expected the checksum to be stamped again, got %v", secret.Annotations)
+	}
+}
@@ -0,0 +1,162 @@
+package syncer
+
+import (
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"time"
+
+	"go-file-secret-sync/pkg/clock"
+)
+
+// Failure policies, selecting what happens when a sync fails
+const (
+	failurePolicyRetry = "retry"
+	failurePolicyExit  = "exit"
+)
+
+// Default bounds of the delay between retries of a failed sync
+const (
+	minRetryBackoff = 1 * time.Second
+	maxRetryBackoff = 5 * time.Minute
+)
+
+// retryPolicy configures the retries of failed syncs. The zero value
+// retries forever with the default bounds and no jitter.
+type retryPolicy struct {
+	initial time.Duration
+	max     time.Duration
+	// Fraction of each delay it is randomly lengthened or shortened by,
+	// so many instances failing at once do not retry in lockstep
+	jitter float64
+	// Retries in a row before waiting for the next change, or zero for
+	// no limit
+	maxRetries int
+}
+
+// loadRetryPolicy reads the retry policy from RETRY_INITIAL_BACKOFF,
+// RETRY_MAX_BACKOFF, RETRY_JITTER and MAX_RETRIES.
+func loadRetryPolicy() (retryPolicy, error) {
+	initial, err := time.ParseDuration(envOrDefault("RETRY_INITIAL_BACKOFF", minRetryBackoff.String()))
+	if err != nil || initial <= 0 {
+		return retryPolicy{}, fmt.Errorf("invalid RETRY_INITIAL_BACKOFF %q", os.Getenv("RETRY_INITIAL_BACKOFF"))
+	}
+	maxBackoff, err := time.ParseDuration(envOrDefault("RETRY_MAX_BACKOFF", maxRetryBackoff.String()))
+	if err != nil || maxBackoff <= 0 {
+		return retryPolicy{}, fmt.Errorf("invalid RETRY_MAX_BACKOFF %q", os.Getenv("RETRY_MAX_BACKOFF"))
+	}
+	if maxBackoff < initial {
+		return retryPolicy{}, fmt.Errorf("RETRY_MAX_BACKOFF %s is shorter than RETRY_INITIAL_BACKOFF %s", maxBackoff, initial)
+	}
+	jitter, err := strconv.ParseFloat(envOrDefault("RETRY_JITTER", "0"), 64)
+	if err != nil || jitter < 0 || jitter > 1 {
+		return retryPolicy{}, fmt.Errorf("invalid RETRY_JITTER %q, expected a fraction between 0 and 1", os.Getenv("RETRY_JITTER"))
+	}
+	maxRetries, err := strconv.Atoi(envOrDefault("MAX_RETRIES", "0"))
+	if err != nil || maxRetries < 0 {
+		return retryPolicy{}, fmt.Errorf("invalid MAX_RETRIES %q", os.Getenv("MAX_RETRIES"))
+	}
+	return retryPolicy{initial: initial, max: maxBackoff, jitter: jitter, maxRetries: maxRetries}, nil
+}
+
+// parseFailurePolicy validates a FAILURE_POLICY value.
+func parseFailurePolicy(policy string) (string, error) {
+	switch policy {
+	case "", failurePolicyRetry:
+		return failurePolicyRetry, nil
+	case failurePolicyExit:
+		return failurePolicyExit, nil
+	}
+	return "", fmt.Errorf("unknown failure policy %q, expected %q or %q", policy, failurePolicyRetry, failurePolicyExit)
+}
+
+// syncRetry schedules retries of failed syncs with exponential backoff.
+type syncRetry struct {
+	timer   clock.Timer
+	policy  retryPolicy
+	backoff time.Duration
+	retries int
+	// Returns a number in [0, 1) for jitter
+	random func() float64
+}
+
+func newSyncRetry(c clock.Clock, policy retryPolicy) *syncRetry {
+	if policy.initial == 0 {
+		policy.initial = minRetryBackoff
+	}
+	if policy.max == 0 {
+		policy.max = maxRetryBackoff
+	}
+	timer := c.NewTimer(0)
+	<-timer.C() // drain the timer
+	return &syncRetry{timer: timer, policy: policy, backoff: policy.initial, random: rand.Float64}
+}
+
+// exhausted reports whether the retries allowed in a row have been used.
+func (r *syncRetry) exhausted() bool {
+	return r.policy.maxRetries > 0 && r.retries >= r.policy.maxRetries
+}
+
+// schedule starts the timer for the next retry and returns its delay.
+func (r *syncRetry) schedule() time.Duration {
+	delay := r.backoff
+	if r.policy.jitter > 0 {
+		delay += time.Duration(float64(delay) * r.policy.jitter * (2*r.random() - 1))
+	}
+	r.timer.Reset(delay)
+	r.backoff = min(r.backoff*2, r.policy.max)
+	r.retries++
+	return delay
+}
+
+// reset cancels a pending retry after a successful sync.
+func (r *syncRetry) reset() {
+	r.timer.Stop()
+	r.backoff = r.policy.initial
+	r.retries = 0
+}
+
+// shouldExit counts a failed sync and reports whether the process should
+// exit: always with the exit policy, otherwise once maxFailures syncs in
+// a row have failed.
+func (fss *FileSecretSync) shouldExit() bool {
+	fss.consecutiveFailures++
+	if fss.failurePolicy == failurePolicyExit {
+		return true
+	}
+	return fss.maxFailures > 0 && fss.consecutiveFailures >= fss.maxFailures
+}
+
+// runSync syncs the files and applies the failure policy when that fails:
+// either Run stops with the error, so the process exits, the pod restarts
+// and alerts fire, or the sync is retried with backoff until it succeeds.
+func (fss *FileSecretSync) runSync() {
+	err := fss.syncFiles()
+	if err == nil {
+		fss.consecutiveFailures = 0
+		if fss.retry != nil {
+			fss.retry.reset()
+		}
+		return
+	}
+
+	if fss.shouldExit() {
+		if fss.exit == nil {
+			log.Printf("Sync failed %d times in a row, giving up: %v", fss.consecutiveFailures, err)
+			return
+		}
+		fss.exit(fmt.Errorf("sync failed %d times in a row: %w", fss.consecutiveFailures, err))
+		return
+	}
+	if fss.retry == nil {
+		log.Printf("Sync failed: %v", err)
+		return
+	}
+	if fss.retry.exhausted() {
+		log.Printf("Sync failed after %d retries, waiting for the next change: %v", fss.retry.retries, err)
+		return
+	}
+	log.Printf("Sync failed, retrying in %s: %v", fss.retry.schedule(), err)
+}
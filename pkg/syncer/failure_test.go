@@ -0,0 +1,242 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-file-secret-sync/pkg/clock"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestParseFailurePolicy(t *testing.T) {
+	testCases := []struct {
+		policy    string
+		expected  string
+		expectErr bool
+	}{
+		{"", failurePolicyRetry, false},
+		{"retry", failurePolicyRetry, false},
+		{"exit", failurePolicyExit, false},
+		{"crash", "", true},
+	}
+
+	for _, tc := range testCases {
+		policy, err := parseFailurePolicy(tc.policy)
+		if (err != nil) != tc.expectErr || policy != tc.expected {
+			t.Errorf("parseFailurePolicy(%q) = %q, %v; expected %q", tc.policy, policy, err, tc.expected)
+		}
+	}
+}
+
+func TestSyncRetryBackoff(t *testing.T) {
+	r := newSyncRetry(clock.Real, retryPolicy{})
+	defer r.timer.Stop()
+
+	var delays []time.Duration
+	for i := 0; i < 10; i++ {
+		delays = append(delays, r.schedule())
+	}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second,
+		32 * time.Second, 64 * time.Second, 128 * time.Second, 256 * time.Second, maxRetryBackoff}
+	for i := range expected {
+		if delays[i] != expected[i] {
+			t.Errorf("Expected retry %d after %s, got %s", i, expected[i], delays[i])
+		}
+	}
+
+	r.reset()
+	if delay := r.schedule(); delay != minRetryBackoff {
+		t.Errorf("Expected backoff to reset to %s, got %s", minRetryBackoff, delay)
+	}
+}
+
+func TestLoadRetryPolicy(t *testing.T) {
+	testCases := []struct {
+		name      string
+		env       map[string]string
+		expected  retryPolicy
+		expectErr bool
+	}{
+		{"defaults", nil, retryPolicy{initial: minRetryBackoff, max: maxRetryBackoff}, false},
+		{"custom", map[string]string{"RETRY_INITIAL_BACKOFF": "500ms", "RETRY_MAX_BACKOFF": "1m", "RETRY_JITTER": "0.2", "MAX_RETRIES": "5"},
+			retryPolicy{initial: 500 * time.Millisecond, max: time.Minute, jitter: 0.2, maxRetries: 5}, false},
+		{"zero initial backoff", map[string]string{"RETRY_INITIAL_BACKOFF": "0s"}, retryPolicy{}, true},
+		{"cap below initial backoff", map[string]string{"RETRY_INITIAL_BACKOFF": "10s", "RETRY_MAX_BACKOFF": "5s"}, retryPolicy{}, true},
+		{"jitter above one", map[string]string{"RETRY_JITTER": "1.5"}, retryPolicy{}, true},
+		{"negative max retries", map[string]string{"MAX_RETRIES": "-1"}, retryPolicy{}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, name := range []string{"RETRY_INITIAL_BACKOFF", "RETRY_MAX_BACKOFF", "RETRY_JITTER", "MAX_RETRIES"} {
+				t.Setenv(name, tc.env[name])
+			}
+			policy, err := loadRetryPolicy()
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("loadRetryPolicy() error = %v, expectErr %v", err, tc.expectErr)
+			}
+			if policy != tc.expected {
+				t.Errorf("Expected %+v, got %+v", tc.expected, policy)
+			}
+		})
+	}
+}
+
+func TestSyncRetryPolicy(t *testing.T) {
+	r := newSyncRetry(clock.Real, retryPolicy{initial: 10 * time.Second, max: 30 * time.Second, jitter: 0.5, maxRetries: 4})
+	defer r.timer.Stop()
+
+	// Jitter at both extremes of the random range
+	randoms := []float64{0, 0.5, 0.999999999, 0.5}
+	r.random = func() float64 {
+		value := randoms[0]
+		randoms = randoms[1:]
+		return value
+	}
+
+	var delays []time.Duration
+	for !r.exhausted() {
+		delays = append(delays, r.schedule().Round(time.Millisecond))
+	}
+	expected := []time.Duration{5 * time.Second, 20 * time.Second, 45 * time.Second, 30 * time.Second}
+	if len(delays) != len(expected) {
+		t.Fatalf("Expected %d retries, got %v", len(expected), delays)
+	}
+	for i := range expected {
+		if delays[i] != expected[i] {
+			t.Errorf("Expected retry %d after %s, got %s", i, expected[i], delays[i])
+		}
+	}
+
+	r.reset()
+	if r.exhausted() {
+		t.Error("Expected a successful sync to restore the retries")
+	}
+}
+
+func TestStartMonitoringRetriesFailedSync(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("v1"), 0644)
+
+	client := fake.NewSimpleClientset()
+	failures := 1
+	client.PrependReactor("create", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if failures > 0 {
+			failures--
+			return true, nil, errors.New("API unavailable")
+		}
+		return false, nil, nil
+	})
+
+	clk := clock.NewFake(time.Now())
+	fss := &FileSecretSync{
+		client:        client,
+		namespace:     "test-namespace",
+		secretName:    "test-secret",
+		folderPath:    tempDir,
+		tree:          newTestTree(t, tempDir),
+		stop:          make(chan struct{}),
+		failurePolicy: failurePolicyRetry,
+		clock:         clk,
+		retry:         newSyncRetry(clk, retryPolicy{}),
+	}
+
+	// The initial sync fails and is retried without any file event
+	fss.runSync()
+	done := make(chan error)
+	go func() { done <- fss.startMonitoring() }()
+	defer func() {
+		fss.stopMonitoring()
+		<-done
+	}()
+
+	// Nothing is retried before the backoff has passed
+	clk.Advance(minRetryBackoff - time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	if _, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{}); err == nil {
+		t.Fatal("Expected no retry before the backoff passed")
+	}
+	clk.Advance(time.Millisecond)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{}); err == nil {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("Timed out waiting for the failed sync to be retried")
+}
+
+func TestShouldExit(t *testing.T) {
+	testCases := []struct {
+		name        string
+		policy      string
+		maxFailures int
+		expected    []bool
+	}{
+		{"retry forever", failurePolicyRetry, 0, []bool{false, false, false, false}},
+		{"exit after three failures", failurePolicyRetry, 3, []bool{false, false, true, true}},
+		{"exit policy", failurePolicyExit, 0, []bool{true}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fss := &FileSecretSync{failurePolicy: tc.policy, maxFailures: tc.maxFailures}
+			for i, expected := range tc.expected {
+				if exit := fss.shouldExit(); exit != expected {
+					t.Errorf("Failure %d: expected exit=%v, got %v", i+1, expected, exit)
+				}
+			}
+		})
+	}
+}
+
+func TestRunSyncResetsConsecutiveFailures(t *testing.T) {
+	fss := &FileSecretSync{
+		client:              fake.NewSimpleClientset(),
+		namespace:           "test-namespace",
+		secretName:          "test-secret",
+		folderPath:          t.TempDir(),
+		failurePolicy:       failurePolicyRetry,
+		maxFailures:         3,
+		consecutiveFailures: 2,
+	}
+	fss.runSync()
+	if fss.consecutiveFailures != 0 {
+		t.Errorf("Expected a successful sync to reset the failure count, got %d", fss.consecutiveFailures)
+	}
+}
+
+func TestRunSyncStopsRetrying(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	fss := &FileSecretSync{
+		client:        fake.NewSimpleClientset(),
+		namespace:     "test-namespace",
+		secretName:    "test-secret",
+		folderPath:    filepath.Join(t.TempDir(), "missing"),
+		failurePolicy: failurePolicyRetry,
+		clock:         clk,
+		retry:         newSyncRetry(clk, retryPolicy{maxRetries: 1}),
+	}
+
+	fss.runSync()
+	if clk.Active() != 1 {
+		t.Fatal("Expected the failed sync to be retried")
+	}
+	clk.Advance(minRetryBackoff)
+
+	// The retry fails too, and no further retry is scheduled
+	fss.runSync()
+	if clk.Active() != 0 {
+		t.Error("Expected no retry after MAX_RETRIES retries")
+	}
+}
@@ -0,0 +1,179 @@
+package syncer
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"go-file-secret-sync/pkg/source"
+
+	"github.com/fsnotify/fsnotify"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// namespaceFanOut maps every subfolder of a folder to a Secret of the
+// same name in the namespace named after the subfolder, for
+// SECRET_MODE=per-namespace.
+type namespaceFanOut struct {
+	root   string
+	secret string
+	// SECRET_NAME_TEMPLATE, naming the Secret instead of secret when set
+	nameTemplate *template.Template
+	nameTime     time.Time
+	// Namespaces that may be written to, as patterns matched by
+	// MatchesAny; empty allows none
+	allowed []string
+	// Filter of the base configuration, kept by every mapping
+	include, exclude []string
+	// Subfolders already reported as skipped, so they are logged once
+	skipped map[string]bool
+}
+
+// mappings returns a mapping for every subfolder of the root on fsys
+// that names an allowed namespace. Hidden subfolders, such as the ..data
+// folders of ConfigMap volumes, and files are ignored.
+func (f *namespaceFanOut) mappings(fsys fs.FS) (*syncConfig, error) {
+	entries, err := fs.ReadDir(fsys, f.root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read folder contents: %w", err)
+	}
+
+	cfg := &syncConfig{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if reason := f.rejects(name); reason != "" {
+			f.skip(name, reason)
+			continue
+		}
+		secret := f.secret
+		if f.nameTemplate != nil {
+			var err error
+			secret, err = renderSecretName(f.nameTemplate, secretNameData{Dir: name, Namespace: name, Now: f.nameTime})
+			if err != nil {
+				f.skip(name, err.Error())
+				continue
+			}
+		}
+		delete(f.skipped, name)
+		cfg.Mappings = append(cfg.Mappings, mappingConfig{
+			Folder:    filepath.Join(f.root, name),
+			Secret:    secret,
+			Namespace: name,
+			Include:   f.include,
+			Exclude:   f.exclude,
+		})
+	}
+	return cfg, nil
+}
+
+// skip logs that the subfolder name is not synced, once until it is
+// synced again.
+func (f *namespaceFanOut) skip(name, reason string) {
+	if !f.skipped[name] {
+		log.Printf("Warning: not syncing folder %s: %s", name, reason)
+		f.skipped[name] = true
+	}
+}
+
+// rejects returns why the subfolder name cannot be synced, or "" when it
+// can.
+func (f *namespaceFanOut) rejects(name string) string {
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return "not a valid namespace name: " + strings.Join(errs, ", ")
+	}
+	if !source.MatchesAny(f.allowed, name) {
+		return "namespace is not allowed by ALLOWED_NAMESPACES"
+	}
+	return ""
+}
+
+// newNamespaceFanOut returns the fan-out of the folder of base.
+func newNamespaceFanOut(base *FileSecretSync) *namespaceFanOut {
+	return &namespaceFanOut{
+		root:         base.folderPath,
+		secret:       base.secretName,
+		nameTemplate: base.nameTemplate,
+		nameTime:     base.nameTime,
+		allowed:      base.allowedNamespaces,
+		include:      base.reader.Filter.Include,
+		exclude:      base.reader.Filter.Exclude,
+		skipped:      make(map[string]bool),
+	}
+}
+
+// runNamespaceFanOut syncs every subfolder of the folder of base to the
+// Secret of base in the namespace named after the subfolder, using base
+// for all other settings. Mappings are started and stopped as subfolders
+// come and go. It returns after stopping all mappings on shutdown.
+func runNamespaceFanOut(base *FileSecretSync) error {
+	single := *base
+	single.secretMode = "single"
+	r := &configRunner{base: &single, running: make(map[string]*runningMapping)}
+	f := newNamespaceFanOut(base)
+
+	scan := func() {
+		cfg, err := f.mappings(base.reader.FileSystem())
+		if err != nil {
+			log.Printf("Failed to scan %s for namespaces: %v", f.root, err)
+			return
+		}
+		r.apply(cfg)
+	}
+	scan()
+
+	// Watch the folder itself for subfolders being added or removed; a
+	// custom filesystem cannot be watched, so it is polled
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	var poll <-chan time.Time
+	if base.reader.FS == nil {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return fmt.Errorf("failed to create folder watcher: %w", err)
+		}
+		defer watcher.Close()
+		if err := watcher.Add(f.root); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", f.root, err)
+		}
+		events, errs = watcher.Events, watcher.Errors
+	} else {
+		ticker := base.timers().NewTicker(base.pollInterval)
+		defer ticker.Stop()
+		poll = ticker.C()
+	}
+
+	debounceTimer := base.timers().NewTimer(0)
+	<-debounceTimer.C()
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return fmt.Errorf("folder watcher closed")
+			}
+			if event.Op&(fsnotify.Create|fsnotify.Remove|fsnotify.Rename) != 0 {
+				debounceTimer.Reset(base.debounce)
+			}
+		case err, ok := <-errs:
+			if !ok {
+				return fmt.Errorf("folder watcher closed")
+			}
+			log.Printf("Folder watcher error: %v", err)
+		case <-poll:
+			scan()
+		case <-debounceTimer.C():
+			scan()
+		case <-base.rootContext().Done():
+			for _, running := range r.running {
+				running.fss.stopMonitoring()
+			}
+			return nil
+		}
+	}
+}
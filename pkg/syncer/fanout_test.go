@@ -0,0 +1,181 @@
+package syncer
+
+import (
+	"context"
+	"maps"
+	"testing"
+	"testing/fstest"
+	"text/template"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNamespaceFanOutMappings(t *testing.T) {
+	fsys := fstest.MapFS{
+		"drop/team-a/token":        {Data: []byte("a")},
+		"drop/team-b/token":        {Data: []byte("b")},
+		"drop/Team_C/token":        {Data: []byte("c")},
+		"drop/..data/team-a/token": {Data: []byte("a")},
+		"drop/readme.txt":          {Data: []byte("notes")},
+		"drop/kube-system/token":   {Data: []byte("k")},
+	}
+
+	tests := []struct {
+		name    string
+		allowed []string
+		want    []string
+	}{
+		{"no allowlist", nil, nil},
+		{"all valid names", []string{"*"}, []string{"kube-system", "team-a", "team-b"}},
+		{"allowlist", []string{"team-*"}, []string{"team-a", "team-b"}},
+		{"nothing allowed", []string{"tenant-*"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &namespaceFanOut{
+				root:    "drop",
+				secret:  "tokens",
+				allowed: tt.allowed,
+				exclude: []string{"*.bak"},
+				skipped: make(map[string]bool),
+			}
+			cfg, err := f.mappings(fsys)
+			if err != nil {
+				t.Fatalf("mappings() error: %v", err)
+			}
+
+			var got []string
+			for _, m := range cfg.Mappings {
+				got = append(got, m.Namespace)
+				if m.Folder != "drop/"+m.Namespace || m.Secret != "tokens" {
+					t.Errorf("Unexpected mapping %+v", m)
+				}
+				if len(m.Exclude) != 1 {
+					t.Errorf("Expected the base filter to be kept, got %+v", m)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected namespaces %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Expected namespaces %v, got %v", tt.want, got)
+				}
+			}
+			if !f.skipped["Team_C"] {
+				t.Error("Expected the invalid folder name to be reported")
+			}
+		})
+	}
+}
+
+func TestNamespaceFanOutApply(t *testing.T) {
+	fsys := fstest.MapFS{
+		"drop/team-a/token": {Data: []byte("a")},
+		"drop/team-b/token": {Data: []byte("b")},
+	}
+
+	client := fake.NewSimpleClientset()
+	base := &FileSecretSync{
+		client:     client,
+		namespace:  "sync-namespace",
+		secretMode: "single",
+	}
+	// Mappings poll their own copy, as the test changes fsys
+	base.reader.FS = maps.Clone(fsys)
+	r := &configRunner{base: base, running: make(map[string]*runningMapping)}
+	defer func() {
+		for _, running := range r.running {
+			running.fss.stopMonitoring()
+		}
+	}()
+	f := &namespaceFanOut{root: "drop", secret: "tokens", allowed: []string{"*"}, skipped: make(map[string]bool)}
+
+	cfg, err := f.mappings(fsys)
+	if err != nil {
+		t.Fatalf("mappings() error: %v", err)
+	}
+	r.apply(cfg)
+
+	for namespace, want := range map[string]string{"team-a": "a", "team-b": "b"} {
+		data := waitForData(t, client, namespace, "tokens", func(map[string][]byte) bool { return true })
+		if string(data["token"]) != want {
+			t.Errorf("Expected %s in namespace %s, got %v", want, namespace, data)
+		}
+	}
+	if _, err := client.CoreV1().Secrets("sync-namespace").Get(context.Background(), "tokens", metav1.GetOptions{}); err == nil {
+		t.Error("Expected no Secret in the namespace of the syncer")
+	}
+
+	// Removing a folder stops its mapping
+	delete(fsys, "drop/team-b/token")
+	cfg, err = f.mappings(fsys)
+	if err != nil {
+		t.Fatalf("mappings() error: %v", err)
+	}
+	r.apply(cfg)
+	if len(r.running) != 1 {
+		t.Errorf("Expected one running mapping, got %d", len(r.running))
+	}
+}
+
+func TestNamespaceFanOutNameTemplate(t *testing.T) {
+	fsys := fstest.MapFS{
+		"drop/team-a/token": {Data: []byte("a")},
+		"drop/team-b/token": {Data: []byte("b")},
+	}
+	f := &namespaceFanOut{
+		root:         "drop",
+		nameTemplate: template.Must(template.New("test").Parse(`{{ if eq .Dir "team-b" }}_{{ end }}tokens-{{ .Namespace }}`)),
+		nameTime:     time.Now(),
+		allowed:      []string{"*"},
+		skipped:      make(map[string]bool),
+	}
+
+	cfg, err := f.mappings(fsys)
+	if err != nil {
+		t.Fatalf("mappings() error: %v", err)
+	}
+	if len(cfg.Mappings) != 1 || cfg.Mappings[0].Secret != "tokens-team-a" {
+		t.Errorf("Expected only team-a with a rendered name, got %+v", cfg.Mappings)
+	}
+	if !f.skipped["team-b"] {
+		t.Error("Expected the folder with an invalid rendered name to be reported")
+	}
+}
+
+func TestDetectMappingDriftPerNamespace(t *testing.T) {
+	fsys := fstest.MapFS{
+		"drop/team-a/token": {Data: []byte("a")},
+		"drop/team-b/token": {Data: []byte("b")},
+	}
+	client := fake.NewSimpleClientset()
+	fss := &FileSecretSync{
+		client:            client,
+		namespace:         "sync-namespace",
+		folderPath:        "drop",
+		secretName:        "tokens",
+		secretMode:        "per-namespace",
+		allowedNamespaces: []string{"team-*"},
+	}
+	fss.reader.FS = fsys
+
+	// The root folder is never compared as one Secret
+	if _, err := fss.detectDrift(context.Background()); err == nil {
+		t.Error("Expected the root folder to be rejected as a Secret")
+	}
+
+	drifts, err := fss.detectMappingDrift(context.Background(), "")
+	if err != nil {
+		t.Fatalf("detectMappingDrift failed: %v", err)
+	}
+	if len(drifts) != 2 || drifts[0].name != "team-a/tokens" || drifts[1].name != "team-b/tokens" {
+		t.Fatalf("Expected missing Secrets in both namespaces, got %+v", drifts)
+	}
+	if string(drifts[0].desired["token"]) != "a" {
+		t.Errorf("Expected the key of the folder, got %v", drifts[0].desired)
+	}
+}
@@ -0,0 +1,63 @@
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// fluxSignatureHeader carries the HMAC-SHA256 signature of the body
+// checked by Flux Receivers of type generic-hmac.
+const fluxSignatureHeader = "X-Signature"
+
+// fluxNotifier POSTs to a Flux notification-controller Receiver after
+// syncs that changed keys, so the Flux resources it lists reconcile with
+// the new Secret content right away.
+type fluxNotifier struct {
+	url        string
+	token      []byte
+	httpClient *http.Client
+}
+
+// newFluxNotifier creates a notifier for FLUX_RECEIVER_URL, signing with
+// FLUX_RECEIVER_TOKEN when set. It returns nil when no URL is set.
+func newFluxNotifier() (notifier, error) {
+	url := os.Getenv("FLUX_RECEIVER_URL")
+	if url == "" {
+		return nil, nil
+	}
+
+	httpClient, err := newHTTPClient(os.Getenv("FLUX_RECEIVER_CACERT"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure FLUX_RECEIVER_CACERT: %w", err)
+	}
+	return &fluxNotifier{
+		url:        url,
+		token:      []byte(os.Getenv("FLUX_RECEIVER_TOKEN")),
+		httpClient: httpClient,
+	}, nil
+}
+
+func (f *fluxNotifier) String() string {
+	// The path of the URL is derived from the Receiver's token
+	return "Flux receiver"
+}
+
+func (f *fluxNotifier) notify(ctx context.Context, n syncNotification) error {
+	// Reconciling only helps when the Secret content changed
+	if n.Result != "success" || len(n.ChangedKeys) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	header := http.Header{}
+	if len(f.token) > 0 {
+		header.Set(fluxSignatureHeader, signPayload(f.token, body))
+	}
+	return postJSON(ctx, f.httpClient, f.url, body, header)
+}
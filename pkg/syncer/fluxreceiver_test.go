@@ -0,0 +1,67 @@
+package syncer
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFluxNotifier(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(fluxSignatureHeader) != signPayload([]byte("receiver-token"), body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		calls++
+	}))
+	defer server.Close()
+
+	t.Setenv("FLUX_RECEIVER_URL", server.URL+"/hook/abc123")
+	t.Setenv("FLUX_RECEIVER_TOKEN", "receiver-token")
+	f, err := newFluxNotifier()
+	if err != nil {
+		t.Fatalf("newFluxNotifier failed: %v", err)
+	}
+
+	changed := syncNotification{
+		Result:      "success",
+		Namespace:   "test-namespace",
+		Secret:      "test-secret",
+		ChangedKeys: []notifiedChange{{"test-secret", "ca.pem", "changed"}},
+	}
+	testCases := []struct {
+		name     string
+		n        syncNotification
+		expected int
+	}{
+		{"changed", changed, 1},
+		{"unchanged", syncNotification{Result: "success", Secret: "test-secret"}, 0},
+		{"failed", syncNotification{Result: "failure", Error: "forbidden", ChangedKeys: changed.ChangedKeys}, 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls = 0
+			if err := f.notify(context.Background(), tc.n); err != nil {
+				t.Fatalf("notify failed: %v", err)
+			}
+			if calls != tc.expected {
+				t.Errorf("Expected %d calls to the receiver, got %d", tc.expected, calls)
+			}
+		})
+	}
+
+	// A receiver rejecting the signature fails the delivery
+	wrong := &fluxNotifier{url: server.URL, token: []byte("other-token"), httpClient: server.Client()}
+	if err := wrong.notify(context.Background(), changed); err == nil {
+		t.Error("Expected notify to fail on a non-2xx response")
+	}
+
+	t.Setenv("FLUX_RECEIVER_URL", "")
+	if f, err := newFluxNotifier(); f != nil || err != nil {
+		t.Errorf("Expected no notifier without a URL, got %v (%v)", f, err)
+	}
+}
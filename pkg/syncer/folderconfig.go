@@ -0,0 +1,166 @@
+package syncer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"go-file-secret-sync/pkg/redact"
+	"go-file-secret-sync/pkg/source"
+
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// folderConfigFile is the file in a synced folder that overrides how the
+// folder is synced, when FOLDER_CONFIG is enabled. It is never synced
+// itself.
+const folderConfigFile = ".filesecretsync.yaml"
+
+// folderConfig is the content of folderConfigFile. Settings that are not
+// set keep the configuration of the deployment.
+type folderConfig struct {
+	// Secret the folder is written to
+	Secret string `yaml:"secret"`
+	// Type of the Secret
+	Type corev1.SecretType `yaml:"type"`
+	// Keys to store files under instead of the keys derived from their
+	// paths, by derived key
+	Keys map[string]string `yaml:"keys"`
+	// Files to sync and to leave out, replacing the patterns of the
+	// CONFIG_FILE mapping
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+}
+
+// loadFolderConfig reads the folderConfigFile of folder, returning nil
+// when FOLDER_CONFIG is disabled or the folder has none. Unknown fields
+// are rejected so that typos do not go unnoticed.
+func (fss *FileSecretSync) loadFolderConfig(folder string) (*folderConfig, error) {
+	if !fss.useFolderConfig {
+		return nil, nil
+	}
+	content, err := fs.ReadFile(fss.reader.FileSystem(), filepath.Join(folder, folderConfigFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", folderConfigFile, err)
+	}
+
+	var cfg folderConfig
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", folderConfigFile, err)
+	}
+	if err := fss.validateFolderConfig(&cfg); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", folderConfigFile, err)
+	}
+	return &cfg, nil
+}
+
+// validateFolderConfig checks cfg against the rest of the configuration.
+func (fss *FileSecretSync) validateFolderConfig(cfg *folderConfig) error {
+	if cfg.Secret != "" {
+		if errs := validation.IsDNS1123Subdomain(cfg.Secret); len(errs) > 0 {
+			return fmt.Errorf("secret %q is not a valid Secret name: %s", cfg.Secret, strings.Join(errs, ", "))
+		}
+	}
+	if (cfg.Secret != "" || cfg.Type != "") && fss.secretMode == "per-file" {
+		return fmt.Errorf("secret and type are not supported with SECRET_MODE=per-file")
+	}
+	if cfg.Type != "" && secretTypeFor(fss.dataFormat) != corev1.SecretTypeOpaque {
+		return fmt.Errorf("type cannot be set with DATA_FORMAT=%s", fss.dataFormat)
+	}
+	for from, to := range cfg.Keys {
+		if errs := validation.IsConfigMapKey(to); len(errs) > 0 {
+			return fmt.Errorf("key for %s is not a valid Secret key: %s", redact.Name(from), strings.Join(errs, ", "))
+		}
+	}
+	if err := source.ValidatePatterns(cfg.Include); err != nil {
+		return fmt.Errorf("include: %w", err)
+	}
+	if err := source.ValidatePatterns(cfg.Exclude); err != nil {
+		return fmt.Errorf("exclude: %w", err)
+	}
+	return nil
+}
+
+// readFolder reads the files below folder, applying the filters and keys
+// of its folderConfigFile, and returns them with the folder
+// configuration, which is nil when the folder has none.
+func (fss *FileSecretSync) readFolder(folder string) (map[string]source.File, *folderConfig, error) {
+	cfg, err := fss.loadFolderConfig(folder)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader := fss.reader
+	if cfg != nil && cfg.Include != nil {
+		reader.Filter.Include = cfg.Include
+	}
+	if cfg != nil && cfg.Exclude != nil {
+		reader.Filter.Exclude = cfg.Exclude
+	}
+	// Without a slash the pattern matches the file name, so folder
+	// configurations in subfolders are left out as well
+	if fss.useFolderConfig {
+		reader.Filter.Exclude = append(slices.Clone(reader.Filter.Exclude), folderConfigFile)
+	}
+
+	files, err := reader.ReadDir(folder)
+	fss.keepForWipe(files)
+	if err != nil || cfg == nil {
+		return files, cfg, err
+	}
+	files, err = cfg.renameKeys(files)
+	return files, cfg, err
+}
+
+// managedBySync reports whether secret carries the label of the Secrets
+// file-secret-sync creates.
+func managedBySync(secret *corev1.Secret) bool {
+	return secret.Labels["app.kubernetes.io/managed-by"] == "file-secret-sync"
+}
+
+// renameKeys stores files under the keys set in the folder
+// configuration.
+func (cfg *folderConfig) renameKeys(files map[string]source.File) (map[string]source.File, error) {
+	if len(cfg.Keys) == 0 {
+		return files, nil
+	}
+	renamed := make(map[string]source.File, len(files))
+	for key, file := range files {
+		if to, ok := cfg.Keys[key]; ok {
+			key = to
+		}
+		if _, exists := renamed[key]; exists {
+			return nil, fmt.Errorf("%s: more than one file is stored under key %s", folderConfigFile, redact.Name(key))
+		}
+		renamed[key] = file
+	}
+	return renamed, nil
+}
+
+// applyFolderConfig returns the name of the Secret the folder of cfg is
+// written to, instead of name, and remembers the type of that Secret and
+// whether cfg named it.
+func (fss *FileSecretSync) applyFolderConfig(cfg *folderConfig, name string) string {
+	if cfg == nil {
+		return name
+	}
+	if cfg.Secret != "" {
+		name = cfg.Secret
+		fss.folderSecrets[name] = true
+	}
+	if cfg.Type != "" {
+		fss.folderTypes[name] = cfg.Type
+	}
+	return name
+}
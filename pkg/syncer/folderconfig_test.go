@@ -0,0 +1,197 @@
+package syncer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"go-file-secret-sync/pkg/source"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestLoadFolderConfig(t *testing.T) {
+	testCases := []struct {
+		name       string
+		content    string
+		secretMode string
+		dataFormat string
+		expectErr  string
+	}{
+		{"valid", "secret: app-tls\ntype: kubernetes.io/tls\nkeys:\n  cert.pem: tls.crt\ninclude: ['*.pem']\n", "single", "files", ""},
+		{"unknown field", "secrets: app\n", "single", "files", "field secrets not found"},
+		{"invalid secret name", "secret: App_TLS\n", "single", "files", "not a valid Secret name"},
+		{"invalid key", "keys:\n  cert.pem: tls/crt\n", "single", "files", "not a valid Secret key"},
+		{"invalid pattern", "include: ['[']\n", "single", "files", "include"},
+		{"type with data format", "type: kubernetes.io/tls\n", "single", "dockerconfigjson", "DATA_FORMAT=dockerconfigjson"},
+		{"secret in per-file mode", "secret: app\n", "per-file", "files", "per-file"},
+		{"keys in per-file mode", "keys:\n  a: b\n", "per-file", "files", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fss := &FileSecretSync{useFolderConfig: true, secretMode: tc.secretMode, dataFormat: tc.dataFormat}
+			fss.reader.FS = fstest.MapFS{"data/" + folderConfigFile: {Data: []byte(tc.content)}}
+			cfg, err := fss.loadFolderConfig("data")
+			if tc.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectErr) {
+					t.Fatalf("Expected error containing %q, got %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil || cfg == nil {
+				t.Fatalf("Expected a folder configuration, got %v (%v)", cfg, err)
+			}
+		})
+	}
+
+	// Disabled, or no file in the folder
+	fss := &FileSecretSync{}
+	fss.reader.FS = fstest.MapFS{"data/" + folderConfigFile: {Data: []byte("secret: app\n")}}
+	if cfg, err := fss.loadFolderConfig("data"); cfg != nil || err != nil {
+		t.Errorf("Expected no folder configuration when disabled, got %v (%v)", cfg, err)
+	}
+	fss.useFolderConfig = true
+	if cfg, err := fss.loadFolderConfig("other"); cfg != nil || err != nil {
+		t.Errorf("Expected no folder configuration for a folder without one, got %v (%v)", cfg, err)
+	}
+}
+
+func TestRenameKeys(t *testing.T) {
+	files := map[string]source.File{
+		"a": {Content: []byte("1")},
+		"b": {Content: []byte("2")},
+	}
+
+	swapped, err := (&folderConfig{Keys: map[string]string{"a": "b", "b": "a", "missing": "c"}}).renameKeys(files)
+	if err != nil {
+		t.Fatalf("renameKeys() error: %v", err)
+	}
+	if len(swapped) != 2 || string(swapped["a"].Content) != "2" || string(swapped["b"].Content) != "1" {
+		t.Errorf("Expected swapped keys, got %v", swapped)
+	}
+
+	if _, err := (&folderConfig{Keys: map[string]string{"a": "b"}}).renameKeys(files); err == nil {
+		t.Error("Expected an error for two files under one key")
+	}
+}
+
+func TestCollectSecretsFolderConfig(t *testing.T) {
+	fss := &FileSecretSync{
+		folderPath:      "data",
+		secretName:      "app",
+		secretMode:      "single",
+		dataFormat:      "files",
+		useFolderConfig: true,
+	}
+	fss.reader.FS = fstest.MapFS{
+		"data/" + folderConfigFile: {Data: []byte("secret: app-tls\ntype: kubernetes.io/tls\nkeys:\n  cert.pem: tls.crt\n  key.pem: tls.key\ninclude: ['*.pem']\n")},
+		"data/cert.pem":            {Data: []byte("cert")},
+		"data/key.pem":             {Data: []byte("key")},
+		"data/README.md":           {Data: []byte("notes")},
+	}
+
+	secrets, err := fss.collectSecrets(context.Background())
+	if err != nil {
+		t.Fatalf("collectSecrets failed: %v", err)
+	}
+	data, ok := secrets["app-tls"]
+	if len(secrets) != 1 || !ok {
+		t.Fatalf("Expected only the Secret of the folder configuration, got %v", secrets)
+	}
+	if len(data) != 2 || string(data["tls.crt"]) != "cert" || string(data["tls.key"]) != "key" {
+		t.Errorf("Expected renamed keys of the included files, got %v", data)
+	}
+	if got := fss.secretType("app-tls"); got != corev1.SecretTypeTLS {
+		t.Errorf("Expected type %s, got %s", corev1.SecretTypeTLS, got)
+	}
+
+	// Without FOLDER_CONFIG the file is an ordinary file
+	fss.useFolderConfig = false
+	secrets, err = fss.collectSecrets(context.Background())
+	if err != nil {
+		t.Fatalf("collectSecrets failed: %v", err)
+	}
+	if data := secrets["app"]; len(data) != 4 || fss.secretType("app") != corev1.SecretTypeOpaque {
+		t.Errorf("Expected all files in an Opaque Secret, got %v", secrets)
+	}
+}
+
+func TestFolderConfigSecretOwnership(t *testing.T) {
+	testCases := []struct {
+		name           string
+		labels         map[string]string
+		forceOverwrite bool
+		expectErr      bool
+	}{
+		{name: "managed", labels: map[string]string{"app.kubernetes.io/managed-by": "file-secret-sync"}},
+		{name: "unmanaged", labels: map[string]string{"app": "payments"}, expectErr: true},
+		{name: "unmanaged with FORCE_OVERWRITE", forceOverwrite: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "payments-db", Namespace: "test-namespace", Labels: tc.labels},
+				Data:       map[string][]byte{"password": []byte("original")},
+			})
+			fss := &FileSecretSync{
+				client:          client,
+				namespace:       "test-namespace",
+				folderPath:      "data",
+				secretName:      "app",
+				secretMode:      "single",
+				dataFormat:      "files",
+				useFolderConfig: true,
+				forceOverwrite:  tc.forceOverwrite,
+			}
+			fss.reader.FS = fstest.MapFS{
+				"data/" + folderConfigFile: {Data: []byte("secret: payments-db\n")},
+				"data/password":            {Data: []byte("stolen")},
+			}
+
+			err := fss.syncFiles()
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("syncFiles() error = %v, expectErr %v", err, tc.expectErr)
+			}
+			secret, _ := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "payments-db", metav1.GetOptions{})
+			if overwritten := string(secret.Data["password"]) == "stolen"; overwritten == tc.expectErr {
+				t.Errorf("Expected overwritten=%v, got data %q", !tc.expectErr, secret.Data["password"])
+			}
+		})
+	}
+}
+
+func TestCollectSecretsFolderConfigConflicts(t *testing.T) {
+	fss := &FileSecretSync{
+		folderPath:      "data",
+		secretMode:      "per-directory",
+		dataFormat:      "files",
+		useFolderConfig: true,
+	}
+	fss.reader.FS = fstest.MapFS{
+		"data/app/" + folderConfigFile:        {Data: []byte("secret: shared\n")},
+		"data/app/token":                      {Data: []byte("a")},
+		"data/app/nested/" + folderConfigFile: {Data: []byte("secret: nested\n")},
+		"data/db/" + folderConfigFile:         {Data: []byte("secret: shared\n")},
+		"data/db/password":                    {Data: []byte("b")},
+	}
+
+	_, err := fss.collectSecrets(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "folders app and db are both synced to secret shared") {
+		t.Errorf("Expected folders sharing a Secret to fail the sync, got %v", err)
+	}
+
+	// Folder configurations in subfolders are never synced
+	delete(fss.reader.FS.(fstest.MapFS), "data/db/"+folderConfigFile)
+	secrets, err := fss.collectSecrets(context.Background())
+	if err != nil {
+		t.Fatalf("collectSecrets failed: %v", err)
+	}
+	if data := secrets["shared"]; len(data) != 1 || string(data["token"]) != "a" {
+		t.Errorf("Expected only the token in the Secret of app, got %v", secrets)
+	}
+}
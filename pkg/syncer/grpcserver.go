@@ -0,0 +1,143 @@
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"go-file-secret-sync/controlpb"
+)
+
+// controlServer implements the gRPC control API on top of the same state
+// as the HTTP admin API.
+type controlServer struct {
+	controlpb.UnimplementedControlServer
+	state *syncerState
+	// Closed on shutdown, ending event streams
+	done <-chan struct{}
+}
+
+func (c controlServer) TriggerSync(ctx context.Context, req *controlpb.TriggerSyncRequest) (*controlpb.TriggerSyncResponse, error) {
+	n := c.state.health.triggerSync()
+	log.Printf("Sync of %d mappings requested through the gRPC API", n)
+	return &controlpb.TriggerSyncResponse{Mappings: int32(n)}, nil
+}
+
+func (c controlServer) GetStatus(ctx context.Context, req *controlpb.GetStatusRequest) (*controlpb.GetStatusResponse, error) {
+	resp := &controlpb.GetStatusResponse{Ready: c.state.health.ready()}
+	for _, s := range c.state.health.statuses() {
+		m := &controlpb.MappingStatus{
+			Folder:    s.Folder,
+			Namespace: s.Namespace,
+			Secret:    s.Secret,
+			Result:    s.Result,
+			Error:     s.Error,
+			Keys:      int32(s.Keys),
+		}
+		if s.LastAttempt != nil {
+			m.LastAttempt = timestamppb.New(*s.LastAttempt)
+		}
+		if s.LastSuccess != nil {
+			m.LastSuccess = timestamppb.New(*s.LastSuccess)
+		}
+		resp.Mappings = append(resp.Mappings, m)
+	}
+	return resp, nil
+}
+
+func (c controlServer) StreamEvents(req *controlpb.StreamEventsRequest, stream grpc.ServerStreamingServer[controlpb.Event]) error {
+	ch, unsubscribe := c.state.events.subscribe()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-c.done:
+			return nil
+		case event := <-ch:
+			if err := stream.Send(eventToProto(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func eventToProto(event syncEvent) *controlpb.Event {
+	e := &controlpb.Event{
+		Type:      event.Type,
+		Time:      timestamppb.New(event.Time),
+		Folder:    event.Folder,
+		Namespace: event.Namespace,
+		Secret:    event.Secret,
+		Error:     event.Error,
+	}
+	for _, change := range event.Changes {
+		e.Changes = append(e.Changes, &controlpb.KeyChange{Key: change.Key, Change: change.Change})
+	}
+	return e
+}
+
+// newGRPCServer creates a gRPC server with the control API, requiring
+// token as a bearer token in the authorization metadata of every call.
+// Event streams end once ctx is done.
+func (s *syncerState) newGRPCServer(ctx context.Context, token string) *grpc.Server {
+	authorize := func(ctx context.Context) error {
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, value := range md.Get("authorization") {
+			if bearerTokenValid(value, token) {
+				return nil
+			}
+		}
+		return status.Error(codes.Unauthenticated, "invalid or missing bearer token")
+	}
+
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := authorize(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := authorize(ss.Context()); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	)
+	controlpb.RegisterControlServer(server, controlServer{state: s, done: ctx.Done()})
+	return server
+}
+
+// startGRPCServer serves the gRPC control API on addr in the background.
+// It returns nil when addr is empty. Event streams end once ctx is done.
+// When the server fails after starting, fail is called with the error.
+func (s *syncerState) startGRPCServer(ctx context.Context, addr, token string, fail func(error)) (*grpc.Server, error) {
+	if addr == "" {
+		return nil, nil
+	}
+	if token == "" {
+		return nil, fmt.Errorf("GRPC_ADDR requires ADMIN_TOKEN to be set")
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	server := s.newGRPCServer(ctx, token)
+	go func() {
+		log.Printf("Serving the gRPC control API on %s", addr)
+		if err := server.Serve(listener); err != nil {
+			fail(fmt.Errorf("gRPC server failed: %w", err))
+		}
+	}()
+	return server, nil
+}
@@ -0,0 +1,142 @@
+package syncer
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"go-file-secret-sync/controlpb"
+)
+
+func newTestControlClient(t *testing.T, state *syncerState) controlpb.ControlClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := state.newGRPCServer(context.Background(), "secret-token")
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return controlpb.NewControlClient(conn)
+}
+
+func TestControlAPI(t *testing.T) {
+	state := newSyncerState()
+	client := newTestControlClient(t, state)
+
+	fss := &FileSecretSync{
+		folderPath: "/secrets/grpc",
+		namespace:  "test-namespace",
+		secretName: "grpc-secret",
+		syncNow:    make(chan struct{}, 1),
+		state:      state,
+	}
+	state.health.track(fss)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Calls without the token are rejected
+	for _, token := range []string{"", "wrong-token"} {
+		callCtx := ctx
+		if token != "" {
+			callCtx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+		}
+		if _, err := client.TriggerSync(callCtx, &controlpb.TriggerSyncRequest{}); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("Expected Unauthenticated with token %q, got %v", token, err)
+		}
+		stream, err := client.StreamEvents(callCtx, &controlpb.StreamEventsRequest{})
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		if status.Code(err) != codes.Unauthenticated {
+			t.Errorf("Expected Unauthenticated stream with token %q, got %v", token, err)
+		}
+	}
+	select {
+	case <-fss.syncNow:
+		t.Error("Expected no sync to be requested without a valid token")
+	default:
+	}
+
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer secret-token")
+	if _, err := client.TriggerSync(ctx, &controlpb.TriggerSyncRequest{}); err != nil {
+		t.Fatalf("TriggerSync failed: %v", err)
+	}
+	select {
+	case <-fss.syncNow:
+	default:
+		t.Error("Expected a sync to be requested")
+	}
+
+	state.health.recordSync(fss, nil)
+	resp, err := client.GetStatus(ctx, &controlpb.GetStatusRequest{})
+	if err != nil {
+		t.Fatalf("GetStatus failed: %v", err)
+	}
+	found := false
+	for _, m := range resp.Mappings {
+		if m.Folder == fss.folderPath {
+			found = true
+			if m.Result != "success" || m.Secret != "grpc-secret" || m.LastSuccess == nil {
+				t.Errorf("Unexpected status: %v", m)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Expected status of %s, got %v", fss.folderPath, resp.Mappings)
+	}
+}
+
+func TestControlAPIStreamEvents(t *testing.T) {
+	state := newSyncerState()
+	client := newTestControlClient(t, state)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer secret-token")
+
+	stream, err := client.StreamEvents(ctx, &controlpb.StreamEventsRequest{})
+	if err != nil {
+		t.Fatalf("StreamEvents failed: %v", err)
+	}
+
+	// Keep emitting until the stream has subscribed
+	fss := &FileSecretSync{folderPath: "/secrets/grpc-events", namespace: "test-namespace", state: state}
+	received := make(chan *controlpb.Event, 1)
+	go func() {
+		event, err := stream.Recv()
+		if err == nil {
+			received <- event
+		}
+	}()
+	for {
+		fss.emitKeysChanged("grpc-secret", []keyChange{{"ca.pem", "added"}})
+		select {
+		case event := <-received:
+			if event.Type != eventKeysChanged || event.Folder != fss.folderPath || event.Secret != "grpc-secret" ||
+				len(event.Changes) != 1 || event.Changes[0].Key != "ca.pem" || event.Changes[0].Change != "added" {
+				t.Errorf("Unexpected event: %v", event)
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for an event")
+		}
+	}
+}
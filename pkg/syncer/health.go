@@ -0,0 +1,187 @@
+package syncer
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go-file-secret-sync/pkg/redact"
+)
+
+// healthState tracks the sync state of every running folder mapping for
+// the health endpoints.
+type healthState struct {
+	mu       sync.Mutex
+	mappings map[*FileSecretSync]*mappingHealth
+
+	// How long changes may stay unsynced before liveness fails; zero
+	// disables the check
+	maxStaleness time.Duration
+
+	// Called whenever the state changes, e.g. to publish it
+	onChange func()
+}
+
+// mappingHealth is the sync state of a single folder mapping.
+type mappingHealth struct {
+	folder    string
+	namespace string
+	secret    string
+
+	synced       bool
+	lastAttempt  time.Time
+	lastSuccess  time.Time
+	lastError    string
+	keys         int
+	keyNames     []string
+	recentErrors []syncError
+	pendingSince time.Time
+}
+
+// maxRecentErrors is how many errors are kept per mapping for the
+// dashboard.
+const maxRecentErrors = 5
+
+// syncError is a failed sync of a mapping.
+type syncError struct {
+	At      time.Time
+	Message string
+}
+
+func newHealthState() *healthState {
+	return &healthState{mappings: make(map[*FileSecretSync]*mappingHealth)}
+}
+
+// track starts reporting the state of fss. Its initial sync is pending.
+func (h *healthState) track(fss *FileSecretSync) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.mappings[fss] = &mappingHealth{
+		folder:       fss.folderPath,
+		namespace:    fss.namespace,
+		secret:       fss.secretName,
+		pendingSince: time.Now(),
+	}
+	h.changed()
+}
+
+// forget stops reporting the state of fss, after its mapping was removed.
+func (h *healthState) forget(fss *FileSecretSync) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.mappings, fss)
+	h.changed()
+}
+
+// markPending records that fss has changes to sync since at, unless it
+// already had pending changes.
+func (h *healthState) markPending(fss *FileSecretSync, at time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if m, ok := h.mappings[fss]; ok && m.pendingSince.IsZero() {
+		m.pendingSince = at
+	}
+}
+
+// recordSync records the result of a sync of fss. A failed sync leaves its
+// changes pending. Untracked instances are ignored.
+func (h *healthState) recordSync(fss *FileSecretSync, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	m, ok := h.mappings[fss]
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	m.lastAttempt = now
+	defer h.changed()
+	if err != nil {
+		m.lastError = redact.Error(err).Error()
+		m.recentErrors = append(m.recentErrors, syncError{now, m.lastError})
+		if len(m.recentErrors) > maxRecentErrors {
+			m.recentErrors = m.recentErrors[1:]
+		}
+		if m.pendingSince.IsZero() {
+			m.pendingSince = now
+		}
+		return
+	}
+	m.synced = true
+	m.lastSuccess = now
+	m.lastError = ""
+	m.pendingSince = time.Time{}
+}
+
+// recordKeys records the names of the keys the Secrets of fss hold after
+// a successful sync.
+func (h *healthState) recordKeys(fss *FileSecretSync, names []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if m, ok := h.mappings[fss]; ok {
+		m.keys = len(names)
+		m.keyNames = names
+	}
+}
+
+// changed notifies the onChange callback. It must be called with mu held.
+func (h *healthState) changed() {
+	if h.onChange != nil {
+		h.onChange()
+	}
+}
+
+// ready reports whether every mapping has completed a sync without error,
+// so its Secrets are populated.
+func (h *healthState) ready() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.mappings) == 0 {
+		return false
+	}
+	for _, m := range h.mappings {
+		if !m.synced {
+			return false
+		}
+	}
+	return true
+}
+
+// stale returns how long the changes of a mapping have been pending when
+// that exceeds maxStaleness at now, which means syncing is stuck.
+func (h *healthState) stale(now time.Time) (time.Duration, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.maxStaleness <= 0 {
+		return 0, false
+	}
+	for _, m := range h.mappings {
+		if m.pendingSince.IsZero() {
+			continue
+		}
+		if pending := now.Sub(m.pendingSince); pending > h.maxStaleness {
+			return pending, true
+		}
+	}
+	return 0, false
+}
+
+// serveLive answers liveness probes, failing while syncing is stuck so
+// Kubernetes restarts the container.
+func (h *healthState) serveLive(w http.ResponseWriter, r *http.Request) {
+	if pending, stale := h.stale(time.Now()); stale {
+		http.Error(w, fmt.Sprintf("changes pending for %s without a successful sync", pending.Round(time.Second)), http.StatusServiceUnavailable)
+		return
+	}
+	w.Write([]byte("ok\n"))
+}
+
+// serveReady answers readiness probes.
+func (h *healthState) serveReady(w http.ResponseWriter, r *http.Request) {
+	if !h.ready() {
+		http.Error(w, "initial sync not completed", http.StatusServiceUnavailable)
+		return
+	}
+	w.Write([]byte("ok\n"))
+}
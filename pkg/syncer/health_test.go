@@ -0,0 +1,92 @@
+package syncer
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReadinessEndpoint(t *testing.T) {
+	state := newSyncerState()
+	server := httptest.NewServer(state.newHTTPMux())
+	defer server.Close()
+
+	expectStatus := func(expected int) {
+		t.Helper()
+		resp, err := server.Client().Get(server.URL + "/readyz")
+		if err != nil {
+			t.Fatalf("Failed to get readiness: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != expected {
+			t.Errorf("Expected status %d, got %d", expected, resp.StatusCode)
+		}
+	}
+
+	first, second := &FileSecretSync{state: state}, &FileSecretSync{state: state}
+	state.health.track(first)
+	state.health.track(second)
+	expectStatus(http.StatusServiceUnavailable)
+
+	state.health.recordSync(first, nil)
+	state.health.recordSync(second, errors.New("API unavailable"))
+	expectStatus(http.StatusServiceUnavailable)
+
+	state.health.recordSync(second, nil)
+	expectStatus(http.StatusOK)
+
+	// A later failure keeps the populated Secrets ready
+	state.health.recordSync(first, errors.New("API unavailable"))
+	expectStatus(http.StatusOK)
+
+	// Untracked instances, as in most tests, are ignored
+	state.health.recordSync(&FileSecretSync{}, errors.New("ignored"))
+	expectStatus(http.StatusOK)
+}
+
+func TestLivenessStaleness(t *testing.T) {
+	state := newSyncerState()
+	fss := &FileSecretSync{state: state}
+	state.health.track(fss)
+	state.health.recordSync(fss, nil)
+
+	state.health.maxStaleness = time.Minute
+
+	start := time.Now()
+	if _, stale := state.health.stale(start.Add(time.Hour)); stale {
+		t.Error("Expected no staleness without pending changes")
+	}
+
+	state.health.markPending(fss, start)
+	state.health.markPending(fss, start.Add(30*time.Second))
+	if _, stale := state.health.stale(start.Add(30 * time.Second)); stale {
+		t.Error("Expected no staleness within the limit")
+	}
+	if pending, stale := state.health.stale(start.Add(2 * time.Minute)); !stale || pending != 2*time.Minute {
+		t.Errorf("Expected changes to be pending since the first mark, got %s, %v", pending, stale)
+	}
+
+	// Failed syncs keep changes pending, a successful sync clears them
+	state.health.recordSync(fss, errors.New("API unavailable"))
+	if _, stale := state.health.stale(start.Add(2 * time.Minute)); !stale {
+		t.Error("Expected changes to stay pending after a failed sync")
+	}
+	state.health.recordSync(fss, nil)
+	if _, stale := state.health.stale(time.Now().Add(30 * time.Second)); stale {
+		t.Error("Expected no staleness after a successful sync")
+	}
+
+	server := httptest.NewServer(state.newHTTPMux())
+	defer server.Close()
+	state.health.markPending(fss, time.Now().Add(-time.Hour))
+	resp, err := server.Client().Get(server.URL + "/healthz")
+	if err != nil {
+		t.Fatalf("Failed to get liveness: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected liveness to fail while stuck, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,84 @@
+package syncer
+
+import (
+	"fmt"
+	"time"
+)
+
+// Hooks are optional callbacks around every sync, for programs embedding
+// the syncer to add their own metrics, notifications or gating. They are
+// called on the goroutine that syncs, so a sync waits for them to return.
+// With a configuration file they are called for the syncs of every
+// mapping, which may run concurrently.
+type Hooks struct {
+	// OnSyncStart is called before a sync reads any file. Returning an
+	// error fails the sync with that error without writing anything, so
+	// it is retried like any other failed sync.
+	OnSyncStart func(SyncInfo) error
+	// OnSyncComplete is called after every sync with its result.
+	OnSyncComplete func(SyncResult)
+	// OnError is called with the error of every failed sync.
+	OnError func(error)
+}
+
+// SyncInfo describes the mapping a sync belongs to.
+type SyncInfo struct {
+	Folder    string
+	Namespace string
+	// Secret written by the mapping; empty when it writes several
+	Secret string
+}
+
+// SyncResult is the outcome of a sync.
+type SyncResult struct {
+	SyncInfo
+	Start    time.Time
+	Duration time.Duration
+	// Keys the sync created, updated or deleted
+	Changes []KeyChange
+	// Why the sync failed; nil when it succeeded
+	Err error
+}
+
+// KeyChange is a key that changed in one of the Secrets of a sync.
+type KeyChange struct {
+	Secret string
+	Key    string
+	// "added", "changed" or "removed"
+	Change string
+}
+
+// syncInfo describes the mapping for hooks.
+func (fss *FileSecretSync) syncInfo() SyncInfo {
+	return SyncInfo{Folder: fss.folderPath, Namespace: fss.namespace, Secret: fss.secretName}
+}
+
+// startHook calls OnSyncStart and returns the error that rejects the sync.
+func (fss *FileSecretSync) startHook() error {
+	if fss.hooks.OnSyncStart == nil {
+		return nil
+	}
+	if err := fss.hooks.OnSyncStart(fss.syncInfo()); err != nil {
+		return fmt.Errorf("sync rejected by OnSyncStart: %w", err)
+	}
+	return nil
+}
+
+// completeHooks calls OnSyncComplete and, when the sync failed, OnError.
+func (fss *FileSecretSync) completeHooks(start time.Time, err error) {
+	if fss.hooks.OnSyncComplete != nil {
+		result := SyncResult{
+			SyncInfo: fss.syncInfo(),
+			Start:    start,
+			Duration: time.Since(start),
+			Err:      err,
+		}
+		for _, change := range fss.syncChanges {
+			result.Changes = append(result.Changes, KeyChange(change))
+		}
+		fss.hooks.OnSyncComplete(result)
+	}
+	if err != nil && fss.hooks.OnError != nil {
+		fss.hooks.OnError(err)
+	}
+}
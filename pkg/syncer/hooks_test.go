@@ -0,0 +1,68 @@
+package syncer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestHooks(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "password"), []byte("v1"), 0644)
+
+	var started []SyncInfo
+	var results []SyncResult
+	var errs []error
+	gate := errors.New("maintenance window")
+	var reject error
+
+	fss := &FileSecretSync{
+		client:     fake.NewSimpleClientset(),
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		folderPath: tempDir,
+		hooks: Hooks{
+			OnSyncStart: func(info SyncInfo) error {
+				started = append(started, info)
+				return reject
+			},
+			OnSyncComplete: func(result SyncResult) { results = append(results, result) },
+			OnError:        func(err error) { errs = append(errs, err) },
+		},
+	}
+
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+	expectedInfo := SyncInfo{Folder: tempDir, Namespace: "test-namespace", Secret: "test-secret"}
+	if len(started) != 1 || started[0] != expectedInfo {
+		t.Errorf("Expected OnSyncStart with %+v, got %+v", expectedInfo, started)
+	}
+	if len(results) != 1 || results[0].Err != nil || results[0].SyncInfo != expectedInfo {
+		t.Fatalf("Expected a successful result, got %+v", results)
+	}
+	expectedChange := KeyChange{Secret: "test-secret", Key: "password", Change: "added"}
+	if len(results[0].Changes) != 1 || results[0].Changes[0] != expectedChange {
+		t.Errorf("Expected change %+v, got %+v", expectedChange, results[0].Changes)
+	}
+	if len(errs) != 0 {
+		t.Errorf("Expected no OnError calls, got %v", errs)
+	}
+
+	// A rejecting OnSyncStart fails the sync before anything is written
+	reject = gate
+	os.WriteFile(filepath.Join(tempDir, "password"), []byte("v2"), 0644)
+	err := fss.syncFiles()
+	if !errors.Is(err, gate) {
+		t.Fatalf("Expected the sync to fail with the hook's error, got %v", err)
+	}
+	if len(results) != 2 || !errors.Is(results[1].Err, gate) || len(results[1].Changes) != 0 {
+		t.Errorf("Expected a failed result without changes, got %+v", results[1])
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], gate) {
+		t.Errorf("Expected OnError with the hook's error, got %v", errs)
+	}
+}
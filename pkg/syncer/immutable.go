@@ -0,0 +1,71 @@
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// isImmutable reports whether the data of secret can no longer be updated.
+func isImmutable(secret *corev1.Secret) bool {
+	return secret.Immutable != nil && *secret.Immutable
+}
+
+// recreateSecret replaces an immutable Secret whose content changed by
+// deleting and creating it again. Only Secrets managed by file-secret-sync
+// are deleted, and the delete is conditional on the UID and resource
+// version that were read, so a Secret replaced in the meantime is left
+// alone.
+func (fss *FileSecretSync) recreateSecret(ctx context.Context, secret *corev1.Secret, data map[string][]byte) error {
+	if !managedBySync(secret) {
+		return fmt.Errorf("secret %s is immutable and not managed by file-secret-sync, refusing to recreate it", secret.Name)
+	}
+
+	if err := fss.waitForWrite(ctx); err != nil {
+		return err
+	}
+	preconditions := metav1.Preconditions{UID: &secret.UID, ResourceVersion: &secret.ResourceVersion}
+	err := fss.client.CoreV1().Secrets(fss.namespace).Delete(ctx, secret.Name, metav1.DeleteOptions{Preconditions: &preconditions})
+	if err != nil {
+		return fmt.Errorf("failed to delete immutable secret %s: %w", secret.Name, err)
+	}
+	log.Printf("Deleted immutable secret %s to replace its content", secret.Name)
+
+	if err := fss.createSecret(ctx, secret.Name, data); err != nil {
+		return err
+	}
+	fss.recordEvent(ctx, secret.Name, corev1.EventTypeNormal, "Recreated", "Immutable secret was deleted and recreated with new content")
+	return nil
+}
+
+// recordEvent records an event of eventType on the named Secret. Events
+// are informational, so failing to record one is only logged.
+func (fss *FileSecretSync) recordEvent(ctx context.Context, name, eventType, reason, message string) {
+	now := metav1.NewTime(time.Now())
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: name + ".",
+			Namespace:    fss.namespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       "Secret",
+			Name:       name,
+			Namespace:  fss.namespace,
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Source:         corev1.EventSource{Component: "file-secret-sync"},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	if _, err := fss.client.CoreV1().Events(fss.namespace).Create(ctx, event, metav1.CreateOptions{}); err != nil {
+		log.Printf("Failed to record event for secret %s: %v", name, err)
+	}
+}
@@ -0,0 +1,90 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSyncFilesImmutableRecreate(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("test: value"), 0644)
+
+	client := fake.NewSimpleClientset()
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		folderPath: tempDir,
+		immutable:  true,
+	}
+
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+
+	ctx := context.Background()
+	secret, err := client.CoreV1().Secrets("test-namespace").Get(ctx, "test-secret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if !isImmutable(secret) {
+		t.Fatal("Expected secret to be created immutable")
+	}
+
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("test: updated"), 0644)
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+
+	secret, err = client.CoreV1().Secrets("test-namespace").Get(ctx, "test-secret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get recreated secret: %v", err)
+	}
+	if string(secret.Data["config.yaml"]) != "test: updated" || !isImmutable(secret) {
+		t.Errorf("Expected immutable secret with new content, got %v", secret.Data)
+	}
+
+	events, err := client.CoreV1().Events("test-namespace").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("Failed to list events: %v", err)
+	}
+	if len(events.Items) != 1 || events.Items[0].Reason != "Recreated" {
+		t.Errorf("Expected a single Recreated event, got %+v", events.Items)
+	}
+}
+
+func TestSyncFilesImmutableUnmanaged(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("test: updated"), 0644)
+
+	immutable := true
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+		Immutable:  &immutable,
+		Data:       map[string][]byte{"config.yaml": []byte("test: value")},
+	})
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		folderPath: tempDir,
+	}
+
+	if err := fss.syncFiles(); err == nil {
+		t.Error("Expected syncFiles to refuse recreating an unmanaged immutable secret")
+	}
+
+	secret, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected unmanaged secret to be kept: %v", err)
+	}
+	if string(secret.Data["config.yaml"]) != "test: value" {
+		t.Errorf("Expected unmanaged secret to be untouched, got %v", secret.Data)
+	}
+}
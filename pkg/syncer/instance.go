@@ -0,0 +1,42 @@
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// instanceAnnotation names the instance that writes a Secret, so a second
+// deployment writing the same Secret from other files is noticed instead
+// of the two overwriting each other on every sync.
+const instanceAnnotation = "file-secret-sync/instance"
+
+// claimedByOther returns the instance other than this one that claims
+// secret, or "" when the Secret is unclaimed or claimed by this instance.
+func (fss *FileSecretSync) claimedByOther(secret *corev1.Secret) string {
+	if fss.instanceID == "" {
+		return ""
+	}
+	if owner := secret.Annotations[instanceAnnotation]; owner != fss.instanceID {
+		return owner
+	}
+	return ""
+}
+
+// reportInstanceConflict counts a Secret claimed by another instance and
+// records a Warning event. It returns an error refusing the write unless
+// INSTANCE_CONFLICT=warn, in which case this instance takes the Secret
+// over.
+func (fss *FileSecretSync) reportInstanceConflict(ctx context.Context, secret *corev1.Secret, owner string) error {
+	message := fmt.Sprintf("Secret is claimed by instance %s, not %s; another deployment may be writing it from other files. Give both the same INSTANCE_ID to share it, or remove the %s annotation to hand it over",
+		owner, fss.instanceID, instanceAnnotation)
+	log.Printf("Warning: secret %s: %s", secret.Name, message)
+	fss.shared().metrics.instanceConflicts.With(fss.metricLabels()).Inc()
+	fss.recordEvent(ctx, secret.Name, corev1.EventTypeWarning, "InstanceConflict", message)
+	if fss.instanceConflict == "warn" {
+		return nil
+	}
+	return fmt.Errorf("secret %s is claimed by instance %s", secret.Name, owner)
+}
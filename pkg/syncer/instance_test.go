@@ -0,0 +1,100 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSyncFilesInstanceConflict(t *testing.T) {
+	testCases := []struct {
+		name          string
+		claimedBy     string
+		conflict      string
+		expectErr     bool
+		expectEvent   bool
+		expectedData  string
+		expectedClaim string
+	}{
+		{"unclaimed is adopted", "", "refuse", false, false, "from file", "blue"},
+		{"claimed by this instance", "blue", "refuse", false, false, "from file", "blue"},
+		{"claimed by another instance", "green", "refuse", true, true, "from green", "green"},
+		{"taken over with warning", "green", "warn", false, true, "from file", "blue"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			os.WriteFile(filepath.Join(tempDir, "password"), []byte("from file"), 0644)
+
+			existing := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+				Data:       map[string][]byte{"password": []byte("from green")},
+			}
+			if tc.claimedBy != "" {
+				existing.Annotations = map[string]string{instanceAnnotation: tc.claimedBy}
+			}
+			client := fake.NewSimpleClientset(existing)
+			fss := &FileSecretSync{
+				client:           client,
+				namespace:        "test-namespace",
+				secretName:       "test-secret",
+				folderPath:       tempDir,
+				instanceID:       "blue",
+				instanceConflict: tc.conflict,
+			}
+
+			err := fss.syncFiles()
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("syncFiles() error = %v, expectErr %v", err, tc.expectErr)
+			}
+			secret, _ := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{})
+			if string(secret.Data["password"]) != tc.expectedData {
+				t.Errorf("Expected %q, got %q", tc.expectedData, secret.Data["password"])
+			}
+			if secret.Annotations[instanceAnnotation] != tc.expectedClaim {
+				t.Errorf("Expected the Secret to be claimed by %q, got %q", tc.expectedClaim, secret.Annotations[instanceAnnotation])
+			}
+			events, _ := client.CoreV1().Events("test-namespace").List(context.Background(), metav1.ListOptions{})
+			if tc.expectEvent && (len(events.Items) != 1 || events.Items[0].Reason != "InstanceConflict") {
+				t.Errorf("Expected an InstanceConflict event, got %+v", events.Items)
+			}
+			if !tc.expectEvent && len(events.Items) != 0 {
+				t.Errorf("Expected no events, got %+v", events.Items)
+			}
+		})
+	}
+}
+
+func TestSyncFilesWithoutInstanceID(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "password"), []byte("from file"), 0644)
+
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-secret",
+			Namespace:   "test-namespace",
+			Annotations: map[string]string{instanceAnnotation: "green"},
+		},
+	})
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		folderPath: tempDir,
+	}
+
+	// Claims are only enforced by instances that have an identity
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+	secret, _ := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{})
+	if string(secret.Data["password"]) != "from file" {
+		t.Errorf("Expected the Secret to be written, got %q", secret.Data["password"])
+	}
+}
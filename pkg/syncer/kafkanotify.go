@@ -0,0 +1,112 @@
+package syncer
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// defaultKafkaTopic is the topic sync events are written to unless
+// KAFKA_TOPIC is set.
+const defaultKafkaTopic = "file-secret-sync-events"
+
+// kafkaWriter is the part of kafka.Writer used to publish events.
+type kafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// kafkaNotifier writes an event to a Kafka topic after every sync, for
+// auditing secret changes through an event bus.
+type kafkaNotifier struct {
+	writer kafkaWriter
+	topic  string
+}
+
+// newKafkaNotifier creates a notifier writing to the brokers in
+// KAFKA_BROKERS. It returns nil when KAFKA_BROKERS is not set.
+func newKafkaNotifier() (notifier, error) {
+	brokers := os.Getenv("KAFKA_BROKERS")
+	if brokers == "" {
+		return nil, nil
+	}
+
+	dialer, err := newKafkaDialer()
+	if err != nil {
+		return nil, err
+	}
+	topic := envOrDefault("KAFKA_TOPIC", defaultKafkaTopic)
+	writer := kafka.NewWriter(kafka.WriterConfig{
+		Brokers: strings.Split(brokers, ","),
+		Topic:   topic,
+		Dialer:  dialer,
+		// Keep the events of a Secret in order
+		Balancer: &kafka.Hash{},
+		// Failed deliveries are retried by the dispatcher
+		MaxAttempts: 1,
+		BatchSize:   1,
+	})
+	return &kafkaNotifier{writer: writer, topic: topic}, nil
+}
+
+// newKafkaDialer configures TLS and SASL authentication from the
+// environment.
+func newKafkaDialer() (*kafka.Dialer, error) {
+	dialer := &kafka.Dialer{ClientID: "go-file-secret-sync", Timeout: 10 * time.Second, DualStack: true}
+
+	if os.Getenv("KAFKA_TLS") == "true" {
+		dialer.TLS = &tls.Config{}
+		if caFile := os.Getenv("KAFKA_CACERT"); caFile != "" {
+			pool, err := loadCertPool(caFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to configure KAFKA_CACERT: %w", err)
+			}
+			dialer.TLS.RootCAs = pool
+		}
+	}
+
+	username, password := os.Getenv("KAFKA_USERNAME"), os.Getenv("KAFKA_PASSWORD")
+	var mechanism sasl.Mechanism
+	var err error
+	switch name := strings.ToUpper(os.Getenv("KAFKA_SASL_MECHANISM")); name {
+	case "":
+		return dialer, nil
+	case "PLAIN":
+		mechanism = plain.Mechanism{Username: username, Password: password}
+	case "SCRAM-SHA-256":
+		mechanism, err = scram.Mechanism(scram.SHA256, username, password)
+	case "SCRAM-SHA-512":
+		mechanism, err = scram.Mechanism(scram.SHA512, username, password)
+	default:
+		return nil, fmt.Errorf("unknown KAFKA_SASL_MECHANISM %q", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure SASL: %w", err)
+	}
+	dialer.SASLMechanism = mechanism
+	return dialer, nil
+}
+
+func (k *kafkaNotifier) String() string {
+	return "Kafka topic " + k.topic
+}
+
+func (k *kafkaNotifier) notify(ctx context.Context, n syncNotification) error {
+	value, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	return k.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(n.Namespace + "/" + n.Secret),
+		Value: value,
+		Time:  n.Time,
+	})
+}
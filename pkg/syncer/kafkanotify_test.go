@@ -0,0 +1,90 @@
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type fakeKafkaWriter struct {
+	messages []kafka.Message
+}
+
+func (w *fakeKafkaWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func TestNewKafkaDialer(t *testing.T) {
+	testCases := []struct {
+		name      string
+		tls       string
+		mechanism string
+		expected  string
+		valid     bool
+	}{
+		{"plaintext", "", "", "", true},
+		{"tls with plain", "true", "plain", "PLAIN", true},
+		{"scram-sha-256", "", "SCRAM-SHA-256", "SCRAM-SHA-256", true},
+		{"scram-sha-512", "true", "SCRAM-SHA-512", "SCRAM-SHA-512", true},
+		{"unknown mechanism", "", "GSSAPI", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("KAFKA_TLS", tc.tls)
+			t.Setenv("KAFKA_SASL_MECHANISM", tc.mechanism)
+			t.Setenv("KAFKA_USERNAME", "sync")
+			t.Setenv("KAFKA_PASSWORD", "password")
+
+			dialer, err := newKafkaDialer()
+			if (err == nil) != tc.valid {
+				t.Fatalf("Expected valid=%v, got error %v", tc.valid, err)
+			}
+			if err != nil {
+				return
+			}
+			if (dialer.TLS != nil) != (tc.tls == "true") {
+				t.Errorf("Expected TLS=%v, got %v", tc.tls == "true", dialer.TLS)
+			}
+			name := ""
+			if dialer.SASLMechanism != nil {
+				name = dialer.SASLMechanism.Name()
+			}
+			if name != tc.expected {
+				t.Errorf("Expected SASL mechanism %q, got %q", tc.expected, name)
+			}
+		})
+	}
+}
+
+func TestKafkaNotifier(t *testing.T) {
+	writer := &fakeKafkaWriter{}
+	k := &kafkaNotifier{writer: writer, topic: defaultKafkaTopic}
+
+	n := syncNotification{
+		Result:      "success",
+		Time:        time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+		Namespace:   "default",
+		Secret:      "app",
+		ChangedKeys: []notifiedChange{{"app", "ca.pem", "changed"}},
+	}
+	if err := k.notify(context.Background(), n); err != nil {
+		t.Fatalf("notify failed: %v", err)
+	}
+
+	if len(writer.messages) != 1 {
+		t.Fatalf("Expected one message, got %d", len(writer.messages))
+	}
+	message := writer.messages[0]
+	var event syncNotification
+	if err := json.Unmarshal(message.Value, &event); err != nil {
+		t.Fatalf("Failed to decode event: %v", err)
+	}
+	if string(message.Key) != "default/app" || !message.Time.Equal(n.Time) || event.Secret != "app" || len(event.ChangedKeys) != 1 {
+		t.Errorf("Unexpected message %s: %+v", message.Key, event)
+	}
+}
@@ -0,0 +1,45 @@
+package syncer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go-file-secret-sync/pkg/redact"
+)
+
+// maxListedKeys bounds the extra keys listed when MAX_KEYS is exceeded,
+// so a folder flooded with files does not produce a huge message.
+const maxListedKeys = 20
+
+// checkKeyLimit fails when a Secret in secrets holds more than maxKeys
+// keys, listing the keys beyond the limit. A limit of zero disables it.
+func checkKeyLimit(secrets map[string]map[string][]byte, maxKeys int) error {
+	if maxKeys <= 0 {
+		return nil
+	}
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		data := secrets[name]
+		if len(data) <= maxKeys {
+			continue
+		}
+		keys := make([]string, 0, len(data))
+		for key := range data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		extra := keys[maxKeys:]
+		listed := strings.Join(redact.Names(extra[:min(len(extra), maxListedKeys)]), ", ")
+		if len(extra) > maxListedKeys {
+			listed += fmt.Sprintf(" and %d more", len(extra)-maxListedKeys)
+		}
+		return fmt.Errorf("secret %s would hold %d keys, more than MAX_KEYS=%d; extra keys: %s", name, len(data), maxKeys, listed)
+	}
+	return nil
+}
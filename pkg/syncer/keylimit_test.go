@@ -0,0 +1,73 @@
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCheckKeyLimit(t *testing.T) {
+	flooded := make(map[string][]byte)
+	for i := 0; i < 30; i++ {
+		flooded[fmt.Sprintf("file%02d", i)] = []byte("x")
+	}
+
+	testCases := []struct {
+		name     string
+		secrets  map[string]map[string][]byte
+		maxKeys  int
+		expected string
+	}{
+		{"disabled", map[string]map[string][]byte{"a": flooded}, 0, ""},
+		{"within limit", map[string]map[string][]byte{"a": {"one": nil, "two": nil}}, 2, ""},
+		{"exceeded", map[string]map[string][]byte{"a": {"one": nil, "two": nil, "three": nil}}, 2,
+			"secret a would hold 3 keys, more than MAX_KEYS=2; extra keys: two"},
+		{"many extras", map[string]map[string][]byte{"a": flooded}, 5,
+			"extra keys: file05, file06, file07, file08, file09, file10, file11, file12, file13, file14, file15, file16, file17, file18, file19, file20, file21, file22, file23, file24 and 5 more"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkKeyLimit(tc.secrets, tc.maxKeys)
+			if tc.expected == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expected) {
+				t.Errorf("Expected error containing %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestSyncFilesMaxKeys(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"a", "b", "c"} {
+		os.WriteFile(filepath.Join(tempDir, name), []byte(name), 0644)
+	}
+
+	client := fake.NewSimpleClientset()
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		folderPath: tempDir,
+		maxKeys:    2,
+	}
+
+	err := fss.syncFiles()
+	if err == nil || !strings.Contains(err.Error(), "extra keys: c") {
+		t.Fatalf("Expected the key limit to fail the sync, got %v", err)
+	}
+	if _, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{}); err == nil {
+		t.Error("Expected no secret to be written")
+	}
+}
@@ -0,0 +1,50 @@
+package syncer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// kustomizeHash returns the suffix kustomize's secretGenerator appends to
+// the name of a Secret of secretType holding data, so Secrets generated
+// by kustomize elsewhere and written here get identical names.
+func kustomizeHash(name string, secretType corev1.SecretType, data map[string][]byte) string {
+	if data == nil {
+		data = map[string][]byte{}
+	}
+	// kustomize hashes the JSON of these fields, with keys in sorted
+	// order and values base64-encoded as in the manifest
+	encoded, _ := json.Marshal(map[string]any{
+		"kind": "Secret",
+		"type": secretType,
+		"name": name,
+		"data": data,
+	})
+	sum := sha256.Sum256(encoded)
+	return kustomizeEncode(hex.EncodeToString(sum[:]))
+}
+
+// kustomizeEncode shortens a hex hash to versionHashLength characters,
+// replacing some of them like kustomize does so the suffix never spells
+// out words.
+func kustomizeEncode(hash string) string {
+	encoded := []byte(hash[:versionHashLength])
+	for i, c := range encoded {
+		switch c {
+		case '0':
+			encoded[i] = 'g'
+		case '1':
+			encoded[i] = 'h'
+		case '3':
+			encoded[i] = 'k'
+		case 'a':
+			encoded[i] = 'm'
+		case 'e':
+			encoded[i] = 't'
+		}
+	}
+	return string(encoded)
+}
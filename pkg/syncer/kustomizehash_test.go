@@ -0,0 +1,49 @@
+package syncer
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestKustomizeHash(t *testing.T) {
+	// Test vectors of kustomize's and kubectl's secret hashers
+	testCases := []struct {
+		name     string
+		data     map[string][]byte
+		expected string
+	}{
+		{"empty data", nil, "t75bgf6ctb"},
+		{"one key", map[string][]byte{"one": []byte("")}, "74bd68bm66"},
+		{"three keys", map[string][]byte{"two": []byte("2"), "one": []byte(""), "three": []byte("3")}, "dgcb6h9tmk"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := kustomizeHash("", "my-type", tc.data); got != tc.expected {
+				t.Errorf("kustomizeHash() = %q, expected %q", got, tc.expected)
+			}
+		})
+	}
+
+	// The name and type are part of the hash
+	data := map[string][]byte{"one": []byte("1")}
+	hash := kustomizeHash("app", corev1.SecretTypeOpaque, data)
+	if kustomizeHash("other", corev1.SecretTypeOpaque, data) == hash || kustomizeHash("app", corev1.SecretTypeTLS, data) == hash {
+		t.Error("Expected the hash to change with the name and type")
+	}
+}
+
+func TestVersionSecretsKustomize(t *testing.T) {
+	data := map[string][]byte{"config.yaml": []byte("test: value")}
+	fss := &FileSecretSync{versionHash: "kustomize"}
+
+	versioned := fss.versionSecrets(map[string]map[string][]byte{"app": data})
+	expected := "app-" + kustomizeHash("app", corev1.SecretTypeOpaque, data)
+	if _, ok := versioned[expected]; !ok || len(versioned) != 1 {
+		t.Errorf("Expected Secret %s, got %v", expected, versioned)
+	}
+	if fss.versionBases[expected] != "app" {
+		t.Errorf("Expected base name app, got %v", fss.versionBases)
+	}
+}
@@ -0,0 +1,92 @@
+package syncer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"go-file-secret-sync/pkg/source"
+)
+
+// secretManifest is the inventory stored under MANIFEST_KEY, describing
+// the synced files to consuming applications.
+type secretManifest struct {
+	// When the files last changed, so unchanged files do not update the
+	// Secret
+	SyncedAt time.Time       `json:"syncedAt"`
+	Files    []manifestEntry `json:"files"`
+}
+
+// manifestEntry describes one synced file.
+type manifestEntry struct {
+	Key    string `json:"key"`
+	Path   string `json:"path"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// manifestEntries describes files, sorted by key.
+func manifestEntries(files map[string]source.File) []manifestEntry {
+	entries := make([]manifestEntry, 0, len(files))
+	for key, file := range files {
+		sum := sha256.Sum256(file.Content)
+		entries = append(entries, manifestEntry{Key: key, Path: file.Path, Size: len(file.Content), SHA256: hex.EncodeToString(sum[:])})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries
+}
+
+// addManifest stores the manifest of files in data, the packed data of the
+// named Secret. The time of the previous manifest is kept while the files
+// stay the same, also across restarts by reading it from the Secret.
+func (fss *FileSecretSync) addManifest(ctx context.Context, name string, files map[string]source.File, data map[string][]byte) error {
+	if _, exists := data[fss.manifestKey]; exists {
+		return fmt.Errorf("key %s is provided by both a file and the manifest", fss.manifestKey)
+	}
+
+	manifest := secretManifest{Files: manifestEntries(files)}
+	previous, ok := fss.manifests[name]
+	if !ok {
+		previous, ok = fss.readManifest(ctx, name)
+	}
+	if ok && reflect.DeepEqual(previous.Files, manifest.Files) {
+		manifest.SyncedAt = previous.SyncedAt
+	} else {
+		manifest.SyncedAt = fss.timers().Now().UTC().Truncate(time.Second)
+	}
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	data[fss.manifestKey] = content
+	if fss.manifests == nil {
+		fss.manifests = make(map[string]secretManifest)
+	}
+	fss.manifests[name] = manifest
+	return nil
+}
+
+// readManifest returns the manifest stored in the named Secret, if it
+// exists and holds a valid one.
+func (fss *FileSecretSync) readManifest(ctx context.Context, name string) (secretManifest, bool) {
+	if fss.client == nil {
+		return secretManifest{}, false
+	}
+	secret, err := fss.client.CoreV1().Secrets(fss.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return secretManifest{}, false
+	}
+	var manifest secretManifest
+	if err := json.Unmarshal(secret.Data[fss.manifestKey], &manifest); err != nil {
+		return secretManifest{}, false
+	}
+	return manifest, true
+}
@@ -0,0 +1,91 @@
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"go-file-secret-sync/pkg/clock"
+)
+
+func TestSyncFilesManifest(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "password"), []byte("hunter2"), 0644)
+	os.MkdirAll(filepath.Join(tempDir, "certs"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "certs", "ca.pem"), []byte("CA"), 0644)
+
+	client := fake.NewSimpleClientset()
+	clk := clock.NewFake(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	newSyncer := func() *FileSecretSync {
+		return &FileSecretSync{
+			client:      client,
+			clock:       clk,
+			namespace:   "test-namespace",
+			secretName:  "test-secret",
+			folderPath:  tempDir,
+			manifestKey: "_manifest.json",
+		}
+	}
+	readManifest := func() secretManifest {
+		t.Helper()
+		secret, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get secret: %v", err)
+		}
+		var manifest secretManifest
+		if err := json.Unmarshal(secret.Data["_manifest.json"], &manifest); err != nil {
+			t.Fatalf("Invalid manifest: %v", err)
+		}
+		return manifest
+	}
+
+	fss := newSyncer()
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+	manifest := readManifest()
+	expected := []manifestEntry{
+		{Key: "certs.ca.pem", Path: "certs/ca.pem", Size: 2, SHA256: "4b650e5c4785025dee7bd65e3c5c527356717d7a1c0bfef5b4ada8ca1e9cbe17"},
+		{Key: "password", Path: "password", Size: 7, SHA256: "f52fbd32b2b3b86ff88ef6c490628285f482af15ddcb29541f94bcf526a3f6c7"},
+	}
+	if !reflect.DeepEqual(manifest.Files, expected) {
+		t.Errorf("Expected files %+v, got %+v", expected, manifest.Files)
+	}
+	firstSync := manifest.SyncedAt
+	if !firstSync.Equal(clk.Now()) {
+		t.Errorf("Expected the manifest to be stamped %v, got %v", clk.Now(), firstSync)
+	}
+
+	// Unchanged files keep the manifest, also after a restart
+	clk.Advance(time.Hour)
+	if err := newSyncer().syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+	if syncedAt := readManifest().SyncedAt; !syncedAt.Equal(firstSync) {
+		t.Errorf("Expected the manifest of unchanged files to keep %v, got %v", firstSync, syncedAt)
+	}
+
+	// Changed files are stamped again
+	os.WriteFile(filepath.Join(tempDir, "password"), []byte("correct horse"), 0644)
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+	if syncedAt := readManifest().SyncedAt; !syncedAt.Equal(clk.Now()) {
+		t.Errorf("Expected the manifest to be stamped %v, got %v", clk.Now(), syncedAt)
+	}
+}
+
+func TestAddManifestConflict(t *testing.T) {
+	fss := &FileSecretSync{manifestKey: "_manifest.json"}
+	data := map[string][]byte{"_manifest.json": []byte("{}")}
+	if err := fss.addManifest(context.Background(), "test-secret", nil, data); err == nil {
+		t.Error("Expected a file named like the manifest to fail")
+	}
+}
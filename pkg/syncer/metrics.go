@@ -0,0 +1,201 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// mappingLabels identify the folder mapping a metric belongs to.
+var mappingLabels = []string{"folder", "namespace", "secret"}
+
+// syncMetrics are the metrics exposed on /metrics.
+type syncMetrics struct {
+	// A dedicated registry keeps the output limited to this
+	// application's metrics
+	registry *prometheus.Registry
+
+	watcherOverflows   *prometheus.CounterVec
+	lastSuccessfulSync *prometheus.GaugeVec
+	polledDirectories  *prometheus.GaugeVec
+	syncDuration       *prometheus.HistogramVec
+	syncFailures       *prometheus.CounterVec
+	invalidFiles       *prometheus.CounterVec
+	emptySourceBlocks  *prometheus.CounterVec
+	externalEdits      *prometheus.CounterVec
+	instanceConflicts  *prometheus.CounterVec
+	syncedKeys         *prometheus.GaugeVec
+	syncedBytes        *prometheus.GaugeVec
+}
+
+func newSyncMetrics() *syncMetrics {
+	m := &syncMetrics{
+		registry: prometheus.NewRegistry(),
+		watcherOverflows: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "file_secret_sync_watcher_overflows_total",
+			Help: "Number of times the file watcher's event queue overflowed and the folder was rescanned.",
+		}, mappingLabels),
+		lastSuccessfulSync: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "file_secret_sync_last_success_timestamp_seconds",
+			Help: "Unix time of the last sync that completed without error.",
+		}, mappingLabels),
+		polledDirectories: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "file_secret_sync_polled_directories",
+			Help: "Number of directory trees polled instead of watched because the inotify watch limit was reached.",
+		}, mappingLabels),
+		syncDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "file_secret_sync_sync_duration_seconds",
+			Help:    "Duration of syncs, including failed ones.",
+			Buckets: prometheus.ExponentialBuckets(0.01, 4, 8),
+		}, mappingLabels),
+		syncFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "file_secret_sync_sync_failures_total",
+			Help: "Number of syncs that failed.",
+		}, mappingLabels),
+		invalidFiles: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "file_secret_sync_invalid_files_total",
+			Help: "Number of times a file failing validation blocked a sync.",
+		}, mappingLabels),
+		emptySourceBlocks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "file_secret_sync_empty_source_blocks_total",
+			Help: "Number of syncs that found no files and were refused to keep the Secrets from being emptied.",
+		}, mappingLabels),
+		externalEdits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "file_secret_sync_external_edits_total",
+			Help: "Number of writes refused because the Secret's data was changed by hand.",
+		}, mappingLabels),
+		instanceConflicts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "file_secret_sync_instance_conflicts_total",
+			Help: "Number of writes to a Secret claimed by another instance.",
+		}, mappingLabels),
+		syncedKeys: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "file_secret_sync_keys",
+			Help: "Number of keys in the Secrets of the last successful sync.",
+		}, mappingLabels),
+		syncedBytes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "file_secret_sync_bytes",
+			Help: "Total size of the data in the Secrets of the last successful sync.",
+		}, mappingLabels),
+	}
+	m.registry.MustRegister(buildInfo, m.watcherOverflows, m.lastSuccessfulSync, m.polledDirectories,
+		m.syncDuration, m.syncFailures, m.invalidFiles, m.emptySourceBlocks, m.externalEdits, m.instanceConflicts,
+		m.syncedKeys, m.syncedBytes)
+	return m
+}
+
+// metricLabels returns the labels identifying the mapping of fss.
+func (fss *FileSecretSync) metricLabels() prometheus.Labels {
+	return prometheus.Labels{"folder": fss.folderPath, "namespace": fss.namespace, "secret": fss.secretName}
+}
+
+// initMetrics creates the series of the mapping of fss, so they are
+// exported with zero values before anything happened.
+func (fss *FileSecretSync) initMetrics() {
+	m, labels := fss.shared().metrics, fss.metricLabels()
+	m.watcherOverflows.With(labels)
+	m.polledDirectories.With(labels)
+	m.syncFailures.With(labels)
+	m.invalidFiles.With(labels)
+	m.emptySourceBlocks.With(labels)
+	m.externalEdits.With(labels)
+	m.instanceConflicts.With(labels)
+}
+
+// forgetMetrics deletes the series of the mapping of fss, after it was
+// removed.
+func (fss *FileSecretSync) forgetMetrics() {
+	m, labels := fss.shared().metrics, fss.metricLabels()
+	for _, vec := range []*prometheus.MetricVec{
+		m.watcherOverflows.MetricVec, m.lastSuccessfulSync.MetricVec, m.polledDirectories.MetricVec,
+		m.syncDuration.MetricVec, m.syncFailures.MetricVec, m.invalidFiles.MetricVec, m.emptySourceBlocks.MetricVec,
+		m.externalEdits.MetricVec, m.instanceConflicts.MetricVec, m.syncedKeys.MetricVec, m.syncedBytes.MetricVec,
+	} {
+		vec.Delete(labels)
+	}
+}
+
+// observeSync records the duration and outcome of a sync.
+func (fss *FileSecretSync) observeSync(duration time.Duration, err error) {
+	m, labels := fss.shared().metrics, fss.metricLabels()
+	m.syncDuration.With(labels).Observe(duration.Seconds())
+	if err != nil {
+		m.syncFailures.With(labels).Inc()
+		return
+	}
+	m.lastSuccessfulSync.With(labels).SetToCurrentTime()
+}
+
+// observeData records the size of the Secrets written by a successful
+// sync, in the metrics and the published status.
+func (fss *FileSecretSync) observeData(secrets map[string]map[string][]byte) {
+	var names []string
+	size := 0
+	for name, data := range secrets {
+		for key, value := range data {
+			size += len(value)
+			// Qualify keys when the mapping writes several Secrets
+			if len(secrets) > 1 {
+				key = name + "/" + key
+			}
+			names = append(names, key)
+		}
+	}
+	sort.Strings(names)
+	state, labels := fss.shared(), fss.metricLabels()
+	state.metrics.syncedKeys.With(labels).Set(float64(len(names)))
+	state.metrics.syncedBytes.With(labels).Set(float64(size))
+	state.health.recordKeys(fss, names)
+}
+
+// newHTTPMux returns the handler of the HTTP server started by
+// startHTTPServer.
+func (s *syncerState) newHTTPMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(s.metrics.registry, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/readyz", s.health.serveReady)
+	mux.HandleFunc("/healthz", s.health.serveLive)
+	return mux
+}
+
+// startHTTPServer serves metrics and health endpoints on addr in the
+// background, along with the admin API when adminToken is set and the
+// dashboard when enabled, behind adminToken as well when it is set. It
+// returns nil when addr is empty. Requests are
+// cancelled, ending event streams, once ctx is done. When the server
+// fails after starting, fail is called with the error.
+func (s *syncerState) startHTTPServer(ctx context.Context, addr, adminToken string, dashboard bool, fail func(error)) (*http.Server, error) {
+	if addr == "" {
+		return nil, nil
+	}
+	mux := s.newHTTPMux()
+	if dashboard {
+		s.registerDashboard(mux, adminToken)
+	}
+	if adminToken != "" {
+		s.registerAdminAPI(mux, adminToken)
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	server := &http.Server{
+		Handler:     mux,
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		log.Printf("Serving metrics and health endpoints on %s", addr)
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fail(fmt.Errorf("HTTP server failed: %w", err))
+		}
+	}()
+	return server, nil
+}
@@ -0,0 +1,67 @@
+package syncer
+
+import (
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "a"), []byte("12345"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "b"), []byte("678"), 0644)
+
+	fss := &FileSecretSync{
+		client:     fake.NewSimpleClientset(),
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		folderPath: tempDir,
+	}
+	fss.initMetrics()
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+
+	server := httptest.NewServer(fss.shared().newHTTPMux())
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("Failed to get metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	labels := `{folder="` + tempDir + `",namespace="test-namespace",secret="test-secret"}`
+	for _, series := range []string{
+		"file_secret_sync_watcher_overflows_total" + labels + " 0",
+		"file_secret_sync_sync_failures_total" + labels + " 0",
+		"file_secret_sync_keys" + labels + " 2",
+		"file_secret_sync_bytes" + labels + " 8",
+		"file_secret_sync_sync_duration_seconds_count" + labels + " 1",
+		"file_secret_sync_last_success_timestamp_seconds" + labels,
+	} {
+		if !strings.Contains(string(body), series) {
+			t.Errorf("Expected %s in metrics, got:\n%s", series, body)
+		}
+	}
+}
+
+func TestForgetMetrics(t *testing.T) {
+	fss := &FileSecretSync{folderPath: "/removed", namespace: "test-namespace", secretName: "removed"}
+	fss.initMetrics()
+	fss.observeSync(0, nil)
+	syncFailures := fss.shared().metrics.syncFailures
+	before := testutil.CollectAndCount(syncFailures)
+	fss.forgetMetrics()
+
+	if after := testutil.CollectAndCount(syncFailures); after != before-1 {
+		t.Errorf("Expected the series of a removed mapping to be deleted, %d of %d left", after, before)
+	}
+}
@@ -0,0 +1,64 @@
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// loadNamespaceLabels parses NAMESPACE_LABELS, a comma-separated list of
+// key=value labels set on namespaces created by CREATE_NAMESPACE.
+func loadNamespaceLabels() (map[string]string, error) {
+	labels := map[string]string{"app.kubernetes.io/managed-by": "file-secret-sync"}
+	for _, pair := range strings.Split(os.Getenv("NAMESPACE_LABELS"), ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("label %q is not key=value", pair)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid label key %q: %s", key, strings.Join(errs, ", "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid value for label %s: %s", key, strings.Join(errs, ", "))
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
+
+// ensureNamespace creates the namespace Secrets are written to, after
+// creating a Secret failed because it does not exist. A namespace created
+// concurrently by someone else is fine.
+func (fss *FileSecretSync) ensureNamespace(ctx context.Context) error {
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   fss.namespace,
+			Labels: fss.namespaceLabels,
+		},
+	}
+
+	if err := fss.waitForWrite(ctx); err != nil {
+		return err
+	}
+	_, err := fss.client.CoreV1().Namespaces().Create(ctx, namespace, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to create namespace %s: %w", fss.namespace, err)
+	}
+
+	log.Printf("Created namespace %s", fss.namespace)
+	return nil
+}
@@ -0,0 +1,112 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestLoadNamespaceLabels(t *testing.T) {
+	testCases := []struct {
+		name      string
+		value     string
+		expected  map[string]string
+		expectErr bool
+	}{
+		{"unset", "", map[string]string{"app.kubernetes.io/managed-by": "file-secret-sync"}, false},
+		{"labels", "team=payments, example.com/env=prod", map[string]string{
+			"app.kubernetes.io/managed-by": "file-secret-sync",
+			"team":                         "payments",
+			"example.com/env":              "prod",
+		}, false},
+		{"empty value", "team=", map[string]string{"app.kubernetes.io/managed-by": "file-secret-sync", "team": ""}, false},
+		{"missing value", "team", nil, true},
+		{"invalid key", "bad key=x", nil, true},
+		{"invalid value", "team=not valid", nil, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("NAMESPACE_LABELS", tc.value)
+			labels, err := loadNamespaceLabels()
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("loadNamespaceLabels() error = %v, expectErr %v", err, tc.expectErr)
+			}
+			if tc.expectErr {
+				return
+			}
+			if len(labels) != len(tc.expected) {
+				t.Errorf("Expected labels %v, got %v", tc.expected, labels)
+			}
+			for key, value := range tc.expected {
+				if labels[key] != value {
+					t.Errorf("Expected label %s=%q, got %q", key, value, labels[key])
+				}
+			}
+		})
+	}
+}
+
+func TestSyncFilesCreatesNamespace(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("test: value"), 0644)
+
+	testCases := []struct {
+		name            string
+		createNamespace bool
+		expectErr       bool
+	}{
+		{"disabled", false, true},
+		{"enabled", true, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			namespaces := corev1.SchemeGroupVersion.WithResource("namespaces")
+			// The fake client does not check that the namespace exists
+			client.PrependReactor("create", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				if _, err := client.Tracker().Get(namespaces, "", action.GetNamespace()); err != nil {
+					return true, nil, errors.NewNotFound(namespaces.GroupResource(), action.GetNamespace())
+				}
+				return false, nil, nil
+			})
+
+			fss := &FileSecretSync{
+				client:          client,
+				namespace:       "new-namespace",
+				secretName:      "test-secret",
+				folderPath:      tempDir,
+				createNamespace: tc.createNamespace,
+				namespaceLabels: map[string]string{"team": "payments"},
+			}
+			err := fss.syncFiles()
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("syncFiles() error = %v, expectErr %v", err, tc.expectErr)
+			}
+			if tc.expectErr {
+				return
+			}
+
+			ctx := context.Background()
+			namespace, err := client.CoreV1().Namespaces().Get(ctx, "new-namespace", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Expected the namespace to be created: %v", err)
+			}
+			if namespace.Labels["team"] != "payments" {
+				t.Errorf("Expected the namespace to be labelled, got %v", namespace.Labels)
+			}
+			if _, err := client.CoreV1().Secrets("new-namespace").Get(ctx, "test-secret", metav1.GetOptions{}); err != nil {
+				t.Errorf("Expected the secret to be created: %v", err)
+			}
+		})
+	}
+}
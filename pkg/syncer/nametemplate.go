@@ -0,0 +1,95 @@
+package syncer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"go-file-secret-sync/pkg/redact"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// secretNameData is what SECRET_NAME_TEMPLATE is executed with.
+type secretNameData struct {
+	// Directory the Secret is synced from: the subdirectory in
+	// per-directory and per-namespace mode, else the synced folder
+	Dir string
+	// Key of the file in per-file mode or of FILE_TO_READ; else empty
+	File string
+	// Namespace the Secret is written to
+	Namespace string
+	// Date the syncer started as YYYY-MM-DD, in UTC
+	Date string
+	// Time the syncer started, in UTC
+	Now time.Time
+}
+
+// nameTemplateFuncs are the functions available to SECRET_NAME_TEMPLATE
+// besides the text/template builtins. The string is the last argument of
+// replace, so it can be piped into.
+var nameTemplateFuncs = template.FuncMap{
+	"env":   os.Getenv,
+	"lower": strings.ToLower,
+	"replace": func(old, new, s string) string {
+		return strings.ReplaceAll(s, old, new)
+	},
+}
+
+// loadNameTemplate parses SECRET_NAME_TEMPLATE, returning nil when it is
+// unset.
+func loadNameTemplate() (*template.Template, error) {
+	text := os.Getenv("SECRET_NAME_TEMPLATE")
+	if text == "" {
+		return nil, nil
+	}
+	if os.Getenv("SECRET_NAME_PREFIX") != "" || os.Getenv("SECRET_NAME_SUFFIX") != "" {
+		return nil, fmt.Errorf("cannot be combined with SECRET_NAME_PREFIX or SECRET_NAME_SUFFIX")
+	}
+	if os.Getenv("SECRET_TO_WRITE") != "" {
+		return nil, fmt.Errorf("cannot be combined with SECRET_TO_WRITE")
+	}
+	tmpl, err := template.New("SECRET_NAME_TEMPLATE").Funcs(nameTemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// renderSecretName executes tmpl with data and checks that the result is
+// a valid Secret name.
+func renderSecretName(tmpl *template.Template, data secretNameData) (string, error) {
+	data.Now = data.Now.UTC()
+	data.Date = data.Now.Format(time.DateOnly)
+
+	var name strings.Builder
+	if err := tmpl.Execute(&name, data); err != nil {
+		return "", fmt.Errorf("failed to render secret name: %w", err)
+	}
+	if errs := validation.IsDNS1123Subdomain(name.String()); len(errs) > 0 {
+		return "", fmt.Errorf("rendered secret name %q is invalid: %s", redact.Name(name.String()), strings.Join(errs, ", "))
+	}
+	return name.String(), nil
+}
+
+// secretNameFor returns the name of the Secret for the subdirectory dir
+// in per-directory mode, or for the file key in per-file mode, from
+// SECRET_NAME_TEMPLATE or else from SECRET_NAME_PREFIX and
+// SECRET_NAME_SUFFIX.
+func (fss *FileSecretSync) secretNameFor(dir, file string) (string, error) {
+	if fss.nameTemplate == nil {
+		return fss.secretNamePrefix + dir + file + fss.secretNameSuffix, nil
+	}
+	if dir == "" {
+		dir = filepath.Base(fss.folderPath)
+	}
+	return renderSecretName(fss.nameTemplate, secretNameData{
+		Dir:       dir,
+		File:      file,
+		Namespace: fss.namespace,
+		Now:       fss.nameTime,
+	})
+}
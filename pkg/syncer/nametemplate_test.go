@@ -0,0 +1,123 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+)
+
+func TestRenderSecretName(t *testing.T) {
+	t.Setenv("CLUSTER", "prod")
+	now := time.Date(2024, 3, 9, 23, 30, 0, 0, time.FixedZone("CET", 3600))
+
+	tests := []struct {
+		name     string
+		template string
+		data     secretNameData
+		want     string
+		wantErr  string
+	}{
+		{"directory", "certs-{{ .Dir }}", secretNameData{Dir: "team-a"}, "certs-team-a", ""},
+		{"file and namespace", "{{ .Namespace }}-{{ .File }}", secretNameData{Namespace: "apps", File: "token"}, "apps-token", ""},
+		{"environment", `{{ env "CLUSTER" }}-creds`, secretNameData{}, "prod-creds", ""},
+		{"date in UTC", "backup-{{ .Date }}", secretNameData{}, "backup-2024-03-09", ""},
+		{"time format", `backup-{{ .Now.Format "200601021504" }}`, secretNameData{}, "backup-202403092230", ""},
+		{"functions", `{{ lower .Dir | replace "_" "-" }}`, secretNameData{Dir: "Team_A"}, "team-a", ""},
+		{"invalid name", "{{ .Dir }}", secretNameData{Dir: "Team_A"}, "", "is invalid"},
+		{"empty name", "{{ .File }}", secretNameData{}, "", "is invalid"},
+		{"unknown field", "{{ .Folder }}", secretNameData{}, "", "failed to render"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := template.Must(template.New("test").Funcs(nameTemplateFuncs).Parse(tt.template))
+			tt.data.Now = now
+			got, err := renderSecretName(tmpl, tt.data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("renderSecretName() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLoadNameTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantNil bool
+		wantErr bool
+	}{
+		{"unset", map[string]string{}, true, false},
+		{"valid", map[string]string{"SECRET_NAME_TEMPLATE": "certs-{{ .Dir }}"}, false, false},
+		{"parse error", map[string]string{"SECRET_NAME_TEMPLATE": "certs-{{ .Dir"}, false, true},
+		{"with prefix", map[string]string{"SECRET_NAME_TEMPLATE": "{{ .Dir }}", "SECRET_NAME_PREFIX": "tenant-"}, false, true},
+		{"with secret", map[string]string{"SECRET_NAME_TEMPLATE": "{{ .Dir }}", "SECRET_TO_WRITE": "app"}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"SECRET_NAME_TEMPLATE", "SECRET_NAME_PREFIX", "SECRET_NAME_SUFFIX", "SECRET_TO_WRITE"} {
+				t.Setenv(name, tt.env[name])
+			}
+			tmpl, err := loadNameTemplate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadNameTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (tmpl == nil) != tt.wantNil {
+				t.Errorf("Expected nil template: %v, got %v", tt.wantNil, tmpl)
+			}
+		})
+	}
+}
+
+func TestSecretNameFor(t *testing.T) {
+	fss := &FileSecretSync{folderPath: "/data/certs", secretNamePrefix: "tenant-", secretNameSuffix: "-creds"}
+	if name, _ := fss.secretNameFor("team-a", ""); name != "tenant-team-a-creds" {
+		t.Errorf("Expected prefix and suffix around the directory, got %q", name)
+	}
+	if name, _ := fss.secretNameFor("", "token"); name != "tenant-token-creds" {
+		t.Errorf("Expected prefix and suffix around the file, got %q", name)
+	}
+
+	fss = &FileSecretSync{
+		folderPath:   "/data/certs",
+		namespace:    "apps",
+		nameTemplate: template.Must(template.New("test").Parse("{{ .Dir }}-{{ .File }}-{{ .Date }}")),
+		nameTime:     time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC),
+	}
+	if name, err := fss.secretNameFor("", "token"); err != nil || name != "certs-token-2024-03-09" {
+		t.Errorf("Expected the folder name for files, got %q (%v)", name, err)
+	}
+}
+
+func TestSecretNameTemplatePerFileCollision(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "a"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "b"), []byte("b"), 0644)
+
+	fss := &FileSecretSync{
+		folderPath:   tempDir,
+		secretMode:   "per-file",
+		secretKey:    "token",
+		nameTemplate: template.Must(template.New("test").Parse("tokens-{{ .Date }}")),
+		nameTime:     time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC),
+	}
+
+	_, err := fss.collectSecrets(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "files a and b are both synced to secret tokens-2024-03-09") {
+		t.Errorf("Expected files rendering to the same name to fail the sync, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// defaultNATSSubject is the subject change events are published to unless
+// NATS_SUBJECT is set.
+const defaultNATSSubject = "file-secret-sync.changes"
+
+// natsFlushTimeout bounds how long publishing waits for the server to
+// acknowledge an event.
+const natsFlushTimeout = 10 * time.Second
+
+// natsNotifier publishes an event to a NATS subject after every
+// successful sync that changed keys, so services caching credentials know
+// to reload them.
+type natsNotifier struct {
+	conn    *nats.Conn
+	subject string
+}
+
+// newNATSNotifier connects to NATS_URL. It returns nil when NATS_URL is
+// not set.
+func newNATSNotifier() (notifier, error) {
+	url := os.Getenv("NATS_URL")
+	if url == "" {
+		return nil, nil
+	}
+
+	options := []nats.Option{
+		nats.Name("go-file-secret-sync"),
+		// Keep trying in the background rather than failing at startup
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+	}
+	if creds := os.Getenv("NATS_CREDS_FILE"); creds != "" {
+		options = append(options, nats.UserCredentials(creds))
+	}
+	conn, err := nats.Connect(url, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	return &natsNotifier{conn: conn, subject: envOrDefault("NATS_SUBJECT", defaultNATSSubject)}, nil
+}
+
+func (p *natsNotifier) String() string {
+	return "NATS subject " + p.subject
+}
+
+func (p *natsNotifier) notify(ctx context.Context, n syncNotification) error {
+	if n.Error != "" || len(n.ChangedKeys) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	if err := p.conn.Publish(p.subject, body); err != nil {
+		return err
+	}
+	// Make sure the server received it, so failures are retried
+	ctx, cancel := context.WithTimeout(ctx, natsFlushTimeout)
+	defer cancel()
+	return p.conn.FlushWithContext(ctx)
+}
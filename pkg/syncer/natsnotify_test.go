@@ -0,0 +1,105 @@
+package syncer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeNATSServer speaks just enough of the NATS protocol to accept
+// publishes, sending each published message to messages.
+func fakeNATSServer(t *testing.T, messages chan<- string) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				fmt.Fprint(conn, "INFO {\"server_id\":\"fake\",\"version\":\"2.10.0\",\"proto\":1,\"max_payload\":1048576}\r\n")
+				reader := bufio.NewReader(conn)
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					fields := strings.Fields(line)
+					if len(fields) == 0 {
+						continue
+					}
+					switch fields[0] {
+					case "PING":
+						fmt.Fprint(conn, "PONG\r\n")
+					case "PUB":
+						var size int
+						fmt.Sscan(fields[len(fields)-1], &size)
+						payload := make([]byte, size+2)
+						if _, err := io.ReadFull(reader, payload); err != nil {
+							return
+						}
+						messages <- fields[1] + " " + string(payload[:size])
+					}
+				}
+			}()
+		}
+	}()
+	return "nats://" + listener.Addr().String()
+}
+
+func TestNATSNotifier(t *testing.T) {
+	messages := make(chan string, 10)
+	t.Setenv("NATS_URL", fakeNATSServer(t, messages))
+	t.Setenv("NATS_SUBJECT", "secrets.changed")
+
+	n, err := newNATSNotifier()
+	if err != nil {
+		t.Fatalf("newNATSNotifier failed: %v", err)
+	}
+	defer n.(*natsNotifier).conn.Close()
+
+	ctx := context.Background()
+	unchanged := syncNotification{Result: "success", Namespace: "default", Secret: "app"}
+	failed := syncNotification{Result: "failure", Namespace: "default", Secret: "app", Error: "API unavailable",
+		ChangedKeys: []notifiedChange{{"app", "ca.pem", "changed"}}}
+	changed := syncNotification{Result: "success", Namespace: "default", Secret: "app",
+		ChangedKeys: []notifiedChange{{"app", "ca.pem", "changed"}}}
+	for _, notification := range []syncNotification{unchanged, failed, changed} {
+		if err := n.notify(ctx, notification); err != nil {
+			t.Fatalf("notify failed: %v", err)
+		}
+	}
+
+	select {
+	case message := <-messages:
+		subject, payload, _ := strings.Cut(message, " ")
+		var event syncNotification
+		if err := json.Unmarshal([]byte(payload), &event); err != nil {
+			t.Fatalf("Failed to decode event: %v", err)
+		}
+		if subject != "secrets.changed" || event.Secret != "app" || len(event.ChangedKeys) != 1 || event.Error != "" {
+			t.Errorf("Unexpected event on %s: %+v", subject, event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the event")
+	}
+	select {
+	case message := <-messages:
+		t.Errorf("Expected only syncs that changed keys to be published, got %s", message)
+	default:
+	}
+}
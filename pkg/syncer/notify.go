@@ -0,0 +1,166 @@
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// notificationQueueSize is how many notifications may wait for delivery
+// before further ones are dropped.
+const notificationQueueSize = 100
+
+// notificationBackoff is the delay before the first retry of a failed
+// delivery. It doubles with every further retry.
+const notificationBackoff = time.Second
+
+// syncNotification is sent to the configured notifiers after every sync.
+type syncNotification struct {
+	Result      string           `json:"result"`
+	Time        time.Time        `json:"time"`
+	Folder      string           `json:"folder"`
+	Namespace   string           `json:"namespace"`
+	Secret      string           `json:"secret,omitempty"`
+	Checksum    string           `json:"checksum,omitempty"`
+	ChangedKeys []notifiedChange `json:"changedKeys,omitempty"`
+	Error       string           `json:"error,omitempty"`
+}
+
+// notifiedChange is a key that changed in one of the Secrets of a sync.
+type notifiedChange struct {
+	Secret string `json:"secret"`
+	Key    string `json:"key"`
+	Change string `json:"change"`
+}
+
+// notifier delivers sync notifications to an external system.
+type notifier interface {
+	// String describes the destination for logging
+	String() string
+	notify(ctx context.Context, n syncNotification) error
+}
+
+// notificationDispatcher delivers notifications in the background, so
+// slow or unreachable receivers never hold up syncing.
+type notificationDispatcher struct {
+	notifiers []notifier
+	retries   int
+	queue     chan syncNotification
+}
+
+// newNotificationDispatcher creates a dispatcher for the notifiers
+// configured in the environment. It returns nil when there are none.
+func newNotificationDispatcher() (*notificationDispatcher, error) {
+	retries, err := strconv.Atoi(envOrDefault("NOTIFY_RETRIES", "3"))
+	if err != nil || retries < 0 {
+		return nil, fmt.Errorf("invalid NOTIFY_RETRIES %q", os.Getenv("NOTIFY_RETRIES"))
+	}
+
+	notifiers, err := newWebhookNotifiers()
+	if err != nil {
+		return nil, err
+	}
+	chatNotifiers, err := newChatNotifiers()
+	if err != nil {
+		return nil, err
+	}
+	notifiers = append(notifiers, chatNotifiers...)
+	natsNotifier, err := newNATSNotifier()
+	if err != nil {
+		return nil, err
+	}
+	if natsNotifier != nil {
+		notifiers = append(notifiers, natsNotifier)
+	}
+	kafkaNotifier, err := newKafkaNotifier()
+	if err != nil {
+		return nil, err
+	}
+	if kafkaNotifier != nil {
+		notifiers = append(notifiers, kafkaNotifier)
+	}
+	fluxNotifier, err := newFluxNotifier()
+	if err != nil {
+		return nil, err
+	}
+	if fluxNotifier != nil {
+		notifiers = append(notifiers, fluxNotifier)
+	}
+	if len(notifiers) == 0 {
+		return nil, nil
+	}
+	return &notificationDispatcher{
+		notifiers: notifiers,
+		retries:   retries,
+		queue:     make(chan syncNotification, notificationQueueSize),
+	}, nil
+}
+
+// enqueue queues n for delivery without blocking.
+func (d *notificationDispatcher) enqueue(n syncNotification) {
+	select {
+	case d.queue <- n:
+	default:
+		log.Printf("Notification queue is full, dropping notification for %s", n.Folder)
+	}
+}
+
+// run delivers queued notifications until ctx is done.
+func (d *notificationDispatcher) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-d.queue:
+			for _, target := range d.notifiers {
+				if err := d.deliver(ctx, target, n); err != nil {
+					log.Printf("Failed to notify %s: %v", target, err)
+				}
+			}
+		}
+	}
+}
+
+// deliver sends n to target, retrying with exponential backoff.
+func (d *notificationDispatcher) deliver(ctx context.Context, target notifier, n syncNotification) error {
+	backoff := notificationBackoff
+	for attempt := 0; ; attempt++ {
+		err := target.notify(ctx, n)
+		if err == nil || attempt >= d.retries {
+			return err
+		}
+		log.Printf("Retrying notification to %s in %s: %v", target, backoff, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// notifySync queues a notification about the sync that just finished
+// with err.
+func (fss *FileSecretSync) notifySync(err error) {
+	notifications := fss.shared().notifications
+	if notifications == nil {
+		return
+	}
+	n := syncNotification{
+		Result:      "success",
+		Time:        time.Now().UTC(),
+		Folder:      fss.folderPath,
+		Namespace:   fss.namespace,
+		Secret:      fss.secretName,
+		Checksum:    fss.sourceChecksum,
+		ChangedKeys: fss.syncChanges,
+	}
+	if err != nil {
+		n.Result = "failure"
+		n.Error = err.Error()
+	}
+	notifications.enqueue(n)
+}
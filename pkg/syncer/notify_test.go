@@ -0,0 +1,88 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// recordingNotifier fails the first failures notifications and records
+// the rest.
+type recordingNotifier struct {
+	failures int
+	received []syncNotification
+}
+
+func (r *recordingNotifier) String() string {
+	return "recorder"
+}
+
+func (r *recordingNotifier) notify(ctx context.Context, n syncNotification) error {
+	if r.failures > 0 {
+		r.failures--
+		return errors.New("receiver unavailable")
+	}
+	r.received = append(r.received, n)
+	return nil
+}
+
+func TestNotifySync(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "ca.pem"), []byte("CA"), 0644)
+
+	dispatcher := &notificationDispatcher{queue: make(chan syncNotification, notificationQueueSize)}
+	state := newSyncerState()
+	state.notifications = dispatcher
+
+	fss := &FileSecretSync{
+		client:     fake.NewSimpleClientset(),
+		folderPath: tempDir,
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		state:      state,
+	}
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+	fss.folderPath = filepath.Join(tempDir, "missing")
+	fss.syncFiles()
+
+	success, failure := <-dispatcher.queue, <-dispatcher.queue
+	if success.Result != "success" || success.Secret != "test-secret" || success.Namespace != "test-namespace" || success.Checksum == "" {
+		t.Errorf("Unexpected success notification: %+v", success)
+	}
+	if len(success.ChangedKeys) != 1 || success.ChangedKeys[0] != (notifiedChange{"test-secret", "ca.pem", "added"}) {
+		t.Errorf("Expected ca.pem to be added, got %+v", success.ChangedKeys)
+	}
+	if failure.Result != "failure" || failure.Error == "" || len(failure.ChangedKeys) != 0 {
+		t.Errorf("Unexpected failure notification: %+v", failure)
+	}
+}
+
+func TestNotificationRetries(t *testing.T) {
+	testCases := []struct {
+		name      string
+		failures  int
+		retries   int
+		delivered bool
+	}{
+		{"first attempt", 0, 0, true},
+		{"after a retry", 1, 1, true},
+		{"retries exhausted", 2, 1, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			target := &recordingNotifier{failures: tc.failures}
+			d := &notificationDispatcher{retries: tc.retries}
+			err := d.deliver(context.Background(), target, syncNotification{Result: "success"})
+			if delivered := err == nil && len(target.received) == 1; delivered != tc.delivered {
+				t.Errorf("Expected delivered=%v, got error %v and %d notifications", tc.delivered, err, len(target.received))
+			}
+		})
+	}
+}
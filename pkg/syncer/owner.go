@@ -0,0 +1,107 @@
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// loadOwnerReference builds the ownerReference set on managed Secrets from
+// OWNER_KIND and OWNER_NAME, so deleting the owner garbage-collects them.
+// The owner's UID is looked up for Deployments, StatefulSets, DaemonSets
+// and Pods; other kinds, such as custom resources, need OWNER_UID and
+// OWNER_API_VERSION. It returns nil when no owner is configured.
+func loadOwnerReference(ctx context.Context, client kubernetes.Interface, namespace string) (*metav1.OwnerReference, error) {
+	kind := os.Getenv("OWNER_KIND")
+	name := os.Getenv("OWNER_NAME")
+	if kind == "" && name == "" {
+		return nil, nil
+	}
+	if kind == "" || name == "" {
+		return nil, fmt.Errorf("OWNER_KIND and OWNER_NAME must be set together")
+	}
+
+	ref := &metav1.OwnerReference{
+		APIVersion: os.Getenv("OWNER_API_VERSION"),
+		Kind:       kind,
+		Name:       name,
+		UID:        types.UID(os.Getenv("OWNER_UID")),
+	}
+
+	var defaultAPIVersion string
+	var meta *metav1.ObjectMeta
+	switch kind {
+	case "Deployment":
+		defaultAPIVersion = "apps/v1"
+		if ref.UID == "" {
+			obj, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get owner deployment %s: %w", name, err)
+			}
+			meta = &obj.ObjectMeta
+		}
+	case "StatefulSet":
+		defaultAPIVersion = "apps/v1"
+		if ref.UID == "" {
+			obj, err := client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get owner statefulset %s: %w", name, err)
+			}
+			meta = &obj.ObjectMeta
+		}
+	case "DaemonSet":
+		defaultAPIVersion = "apps/v1"
+		if ref.UID == "" {
+			obj, err := client.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get owner daemonset %s: %w", name, err)
+			}
+			meta = &obj.ObjectMeta
+		}
+	case "Pod":
+		defaultAPIVersion = "v1"
+		if ref.UID == "" {
+			obj, err := client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get owner pod %s: %w", name, err)
+			}
+			meta = &obj.ObjectMeta
+		}
+	default:
+		if ref.UID == "" || ref.APIVersion == "" {
+			return nil, fmt.Errorf("OWNER_UID and OWNER_API_VERSION are required for owner kind %s", kind)
+		}
+	}
+
+	if ref.APIVersion == "" {
+		ref.APIVersion = defaultAPIVersion
+	}
+	if meta != nil {
+		ref.UID = meta.UID
+	}
+	return ref, nil
+}
+
+// ownerOutdated reports whether meta lacks the configured ownerReference.
+func (fss *FileSecretSync) ownerOutdated(meta metav1.ObjectMeta) bool {
+	if fss.owner == nil {
+		return false
+	}
+	for _, ref := range meta.OwnerReferences {
+		if ref.UID == fss.owner.UID {
+			return false
+		}
+	}
+	return true
+}
+
+// setOwner adds the configured ownerReference to meta if it is missing.
+func (fss *FileSecretSync) setOwner(meta *metav1.ObjectMeta) {
+	if fss.ownerOutdated(*meta) {
+		meta.OwnerReferences = append(meta.OwnerReferences, *fss.owner)
+	}
+}
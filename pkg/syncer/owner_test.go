@@ -0,0 +1,95 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestLoadOwnerReference(t *testing.T) {
+	client := fake.NewSimpleClientset(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-app", Namespace: "test-namespace", UID: "deployment-uid"},
+	})
+
+	testCases := []struct {
+		name       string
+		env        map[string]string
+		expected   *metav1.OwnerReference
+		shouldFail bool
+	}{
+		{"not configured", map[string]string{}, nil, false},
+		{"deployment lookup", map[string]string{"OWNER_KIND": "Deployment", "OWNER_NAME": "my-app"},
+			&metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "my-app", UID: "deployment-uid"}, false},
+		{"custom resource", map[string]string{"OWNER_KIND": "App", "OWNER_NAME": "my-app", "OWNER_API_VERSION": "example.com/v1", "OWNER_UID": "cr-uid"},
+			&metav1.OwnerReference{APIVersion: "example.com/v1", Kind: "App", Name: "my-app", UID: "cr-uid"}, false},
+		{"custom resource without uid", map[string]string{"OWNER_KIND": "App", "OWNER_NAME": "my-app"}, nil, true},
+		{"missing deployment", map[string]string{"OWNER_KIND": "Deployment", "OWNER_NAME": "missing"}, nil, true},
+		{"name without kind", map[string]string{"OWNER_NAME": "my-app"}, nil, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, name := range []string{"OWNER_KIND", "OWNER_NAME", "OWNER_API_VERSION", "OWNER_UID"} {
+				t.Setenv(name, tc.env[name])
+			}
+
+			ref, err := loadOwnerReference(context.Background(), client, "test-namespace")
+			if tc.shouldFail {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if (ref == nil) != (tc.expected == nil) || (ref != nil && *ref != *tc.expected) {
+				t.Errorf("Expected %+v, got %+v", tc.expected, ref)
+			}
+		})
+	}
+}
+
+func TestSyncFilesSetsOwnerReference(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("test: value"), 0644)
+	data := map[string][]byte{"config.yaml": []byte("test: value")}
+
+	// An up-to-date Secret written before an owner was configured
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-secret",
+			Namespace:   "test-namespace",
+			Annotations: map[string]string{checksumAnnotation: dataChecksum(data)},
+		},
+		Data: data,
+	})
+	owner := &metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "my-app", UID: "deployment-uid"}
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		folderPath: tempDir,
+		owner:      owner,
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := fss.syncFiles(); err != nil {
+			t.Fatalf("syncFiles failed: %v", err)
+		}
+	}
+
+	secret, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if len(secret.OwnerReferences) != 1 || secret.OwnerReferences[0] != *owner {
+		t.Errorf("Expected a single owner reference %+v, got %+v", owner, secret.OwnerReferences)
+	}
+}
@@ -0,0 +1,327 @@
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// stagingSuffix is appended to a Secret's name to name the Secret
+	// changes are staged in.
+	stagingSuffix = "-staging"
+	// approveAnnotation on a staging Secret approves promoting it, when
+	// set to "true" or to the checksum of its data.
+	approveAnnotation = "file-secret-sync/approve"
+)
+
+// promotion tracks staged changes for STAGED_PROMOTION. It is shared by
+// all mappings, so Secrets are identified as namespace/name.
+type promotion struct {
+	// Wait for approval instead of promoting right away
+	approval bool
+	// How often pending promotions are checked for approval
+	interval time.Duration
+
+	mu sync.Mutex
+	// Secrets whose staged data may differ from the live data
+	pending map[string]bool
+	// Secrets approved through the admin API
+	approved map[string]bool
+	// Namespaces whose staging Secrets were loaded into pending
+	loaded map[string]bool
+}
+
+// loadPromotion reads STAGED_PROMOTION, PROMOTION_APPROVAL and
+// PROMOTION_CHECK_INTERVAL, returning nil when staging is disabled.
+func loadPromotion() (*promotion, error) {
+	if os.Getenv("STAGED_PROMOTION") != "true" {
+		return nil, nil
+	}
+	interval, err := time.ParseDuration(envOrDefault("PROMOTION_CHECK_INTERVAL", "30s"))
+	if err != nil || interval <= 0 {
+		return nil, fmt.Errorf("invalid PROMOTION_CHECK_INTERVAL %q", os.Getenv("PROMOTION_CHECK_INTERVAL"))
+	}
+	return &promotion{
+		approval: os.Getenv("PROMOTION_APPROVAL") == "true",
+		interval: interval,
+		pending:  make(map[string]bool),
+		approved: make(map[string]bool),
+	}, nil
+}
+
+// stage marks the Secret as having staged data to promote.
+func (p *promotion) stage(namespace, name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pending[namespace+"/"+name] = true
+}
+
+// done forgets the staged data of the Secret and its approval.
+func (p *promotion) done(namespace, name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.pending, namespace+"/"+name)
+	delete(p.approved, namespace+"/"+name)
+}
+
+// withdraw forgets the approval of the Secret given through the admin
+// API.
+func (p *promotion) withdraw(namespace, name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.approved, namespace+"/"+name)
+}
+
+// pendingNames returns the sorted names of the Secrets in namespace with
+// staged data.
+func (p *promotion) pendingNames(namespace string) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var names []string
+	for id := range p.pending {
+		if ns, name, _ := strings.Cut(id, "/"); ns == namespace {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// approve approves promoting the pending Secret namespace/secret, all
+// pending Secrets of namespace when secret is empty, or all of them when
+// both are empty, and returns how many were approved.
+func (p *promotion) approve(namespace, secret string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	approved := 0
+	for id := range p.pending {
+		ns, name, _ := strings.Cut(id, "/")
+		if (namespace == "" || ns == namespace) && (secret == "" || name == secret) {
+			p.approved[id] = true
+			approved++
+		}
+	}
+	return approved
+}
+
+// loadPending marks the Secrets of the namespace of fss that have a
+// staging Secret written by file-secret-sync as pending, once per
+// namespace, so promotions staged before a restart can be approved
+// through the admin API before the files are synced again.
+func (fss *FileSecretSync) loadPending(ctx context.Context) error {
+	p := fss.promotion
+	p.mu.Lock()
+	loaded := p.loaded[fss.namespace]
+	p.mu.Unlock()
+	if loaded {
+		return nil
+	}
+
+	list, err := fss.client.CoreV1().Secrets(fss.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app.kubernetes.io/managed-by=file-secret-sync",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list staging secrets: %w", err)
+	}
+	names := make(map[string]bool, len(list.Items))
+	for _, secret := range list.Items {
+		names[secret.Name] = true
+		wipe(secret.Data)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.loaded == nil {
+		p.loaded = make(map[string]bool)
+	}
+	p.loaded[fss.namespace] = true
+	for name := range names {
+		live, ok := strings.CutSuffix(name, stagingSuffix)
+		// A live Secret named like a staging Secret has its own
+		if !ok || names[name+stagingSuffix] {
+			continue
+		}
+		p.pending[fss.namespace+"/"+live] = true
+	}
+	return nil
+}
+
+// approves reports whether promoting the staging Secret of the named
+// Secret is approved.
+func (p *promotion) approves(namespace, name string, staging *corev1.Secret) bool {
+	if !p.approval {
+		return true
+	}
+	switch staging.Annotations[approveAnnotation] {
+	case "true", dataChecksum(staging.Data):
+		return true
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.approved[namespace+"/"+name]
+}
+
+// stageSecret writes data to the staging Secret of name, and on to name
+// itself unless promotion waits for approval.
+func (fss *FileSecretSync) stageSecret(ctx context.Context, name string, data map[string][]byte) error {
+	staged := name + stagingSuffix
+	if err := fss.withdrawApproval(ctx, name, data); err != nil {
+		return err
+	}
+	if err := fss.writeSecret(ctx, staged, data); err != nil {
+		return err
+	}
+	if !fss.promotion.approval {
+		return fss.writeSecret(ctx, name, data)
+	}
+	fss.promotion.stage(fss.namespace, name)
+	return nil
+}
+
+// withdrawApproval withdraws the approval of the staging Secret of name
+// when data replaces the content that was approved, so each change needs
+// its own approval.
+func (fss *FileSecretSync) withdrawApproval(ctx context.Context, name string, data map[string][]byte) error {
+	if !fss.promotion.approval {
+		return nil
+	}
+	staged := name + stagingSuffix
+	secrets := fss.client.CoreV1().Secrets(fss.namespace)
+	staging, err := secrets.Get(ctx, staged, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get secret %s: %w", staged, err)
+	}
+	defer wipe(staging.Data)
+	if len(digestData(staging.Data).changes(digestData(data))) == 0 {
+		return nil
+	}
+	fss.promotion.withdraw(fss.namespace, name)
+	if _, ok := staging.Annotations[approveAnnotation]; !ok {
+		return nil
+	}
+
+	log.Printf("Withdrawing approval of secret %s, its content changes", staged)
+	delete(staging.Annotations, approveAnnotation)
+	if _, err := secrets.Update(ctx, staging, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to withdraw approval of secret %s: %w", staged, err)
+	}
+	return nil
+}
+
+// promoteApproved copies the data of every approved staging Secret to
+// its live Secret. Failures are logged and recorded as events, so they do
+// not hold back syncing the files.
+func (fss *FileSecretSync) promoteApproved(ctx context.Context) {
+	if fss.promotion.approval {
+		if err := fss.loadPending(ctx); err != nil {
+			log.Printf("Failed to load pending promotions: %v", err)
+		}
+	}
+	for _, name := range fss.promotion.pendingNames(fss.namespace) {
+		if err := fss.promote(ctx, name); err != nil {
+			log.Printf("Failed to promote secret %s: %v", name, err)
+			fss.recordEvent(ctx, name, corev1.EventTypeWarning, "PromotionFailed", err.Error())
+		}
+	}
+}
+
+// promote copies the data of the staging Secret of name to name once it
+// is approved.
+func (fss *FileSecretSync) promote(ctx context.Context, name string) error {
+	staged := name + stagingSuffix
+	secrets := fss.client.CoreV1().Secrets(fss.namespace)
+	staging, err := secrets.Get(ctx, staged, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		fss.promotion.done(fss.namespace, name)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get secret %s: %w", staged, err)
+	}
+	defer wipe(staging.Data)
+
+	// Nothing to promote when the live Secret already holds the data
+	live, err := secrets.Get(ctx, name, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to get secret %s: %w", name, err)
+	}
+	if err == nil {
+		defer wipe(live.Data)
+		if len(digestData(live.Data).changes(digestData(staging.Data))) == 0 {
+			fss.promotion.done(fss.namespace, name)
+			return nil
+		}
+	}
+
+	if !fss.promotion.approves(fss.namespace, name, staging) {
+		return nil
+	}
+	log.Printf("Promoting secret %s to %s", staged, name)
+	if err := fss.writeSecret(ctx, name, staging.Data); err != nil {
+		return err
+	}
+	fss.promotion.done(fss.namespace, name)
+	fss.recordEvent(ctx, name, corev1.EventTypeNormal, "Promoted", fmt.Sprintf("Data of secret %s was promoted", staged))
+
+	// The next change needs a new approval
+	if _, ok := staging.Annotations[approveAnnotation]; ok {
+		delete(staging.Annotations, approveAnnotation)
+		if _, err := secrets.Update(ctx, staging, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to remove approval of secret %s: %w", staged, err)
+		}
+	}
+	return nil
+}
+
+// approvePromotions approves the pending promotions selected as by
+// promotion.approve, and asks the mappings to promote them. It returns
+// how many promotions were approved.
+func (h *healthState) approvePromotions(namespace, secret string) int {
+	h.mu.Lock()
+	promotions := make(map[*promotion]bool)
+	for fss := range h.mappings {
+		if fss.promotion != nil {
+			promotions[fss.promotion] = true
+		}
+	}
+	h.mu.Unlock()
+
+	approved := 0
+	for p := range promotions {
+		approved += p.approve(namespace, secret)
+	}
+	if approved > 0 {
+		h.triggerSync()
+	}
+	return approved
+}
+
+func (h *healthState) serveApprovePromotion(w http.ResponseWriter, r *http.Request) {
+	namespace, secret := r.URL.Query().Get("namespace"), r.URL.Query().Get("secret")
+	// Mappings in other namespaces may write Secrets of the same name
+	if secret != "" && namespace == "" {
+		http.Error(w, "namespace is required with secret", http.StatusBadRequest)
+		return
+	}
+	n := h.approvePromotions(namespace, secret)
+	if n == 0 {
+		http.Error(w, "no pending promotion", http.StatusNotFound)
+		return
+	}
+	log.Printf("Promotion of %d secrets approved through the admin API", n)
+	writeJSON(w, http.StatusAccepted, map[string]int{"secrets": n})
+}
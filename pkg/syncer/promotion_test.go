@@ -0,0 +1,207 @@
+package syncer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newPromotionTest(approval bool) (*FileSecretSync, *fake.Clientset) {
+	client := fake.NewSimpleClientset()
+	fss := &FileSecretSync{
+		client:    client,
+		namespace: "test-namespace",
+		promotion: &promotion{
+			approval: approval,
+			interval: time.Minute,
+			pending:  make(map[string]bool),
+			approved: make(map[string]bool),
+		},
+	}
+	return fss, client
+}
+
+func TestStageSecretWithoutApproval(t *testing.T) {
+	fss, client := newPromotionTest(false)
+	ctx := context.Background()
+
+	if err := fss.stageSecret(ctx, "app", map[string][]byte{"key": []byte("v1")}); err != nil {
+		t.Fatalf("stageSecret() error: %v", err)
+	}
+	for _, name := range []string{"app-staging", "app"} {
+		secret, err := client.CoreV1().Secrets("test-namespace").Get(ctx, name, metav1.GetOptions{})
+		if err != nil || string(secret.Data["key"]) != "v1" {
+			t.Errorf("Expected %s to hold the new data, got %v (%v)", name, secret, err)
+		}
+	}
+}
+
+func TestPromotionApproval(t *testing.T) {
+	fss, client := newPromotionTest(true)
+	ctx := context.Background()
+	secrets := client.CoreV1().Secrets("test-namespace")
+
+	liveValue := func() string {
+		t.Helper()
+		secret, err := secrets.Get(ctx, "app", metav1.GetOptions{})
+		if err != nil {
+			return ""
+		}
+		return string(secret.Data["key"])
+	}
+	annotate := func(value string) {
+		t.Helper()
+		staging, err := secrets.Get(ctx, "app-staging", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get staging secret: %v", err)
+		}
+		staging.Annotations[approveAnnotation] = value
+		if _, err := secrets.Update(ctx, staging, metav1.UpdateOptions{}); err != nil {
+			t.Fatalf("Failed to annotate staging secret: %v", err)
+		}
+	}
+
+	v1 := map[string][]byte{"key": []byte("v1")}
+	if err := fss.stageSecret(ctx, "app", v1); err != nil {
+		t.Fatalf("stageSecret() error: %v", err)
+	}
+	fss.promoteApproved(ctx)
+	if got := liveValue(); got != "" {
+		t.Fatalf("Expected no live secret before approval, got %q", got)
+	}
+
+	// A checksum of other data does not approve
+	annotate(dataChecksum(map[string][]byte{"key": []byte("other")}))
+	fss.promoteApproved(ctx)
+	if got := liveValue(); got != "" {
+		t.Fatalf("Expected no promotion for a stale checksum, got %q", got)
+	}
+
+	annotate(dataChecksum(v1))
+	fss.promoteApproved(ctx)
+	if got := liveValue(); got != "v1" {
+		t.Fatalf("Expected v1 to be promoted, got %q", got)
+	}
+	if names := fss.promotion.pendingNames("test-namespace"); len(names) != 0 {
+		t.Errorf("Expected no pending promotions, got %v", names)
+	}
+	staging, _ := secrets.Get(ctx, "app-staging", metav1.GetOptions{})
+	if _, ok := staging.Annotations[approveAnnotation]; ok {
+		t.Error("Expected the approval to be removed after promotion")
+	}
+
+	// Staging new content withdraws an approval given before
+	annotate("true")
+	if err := fss.stageSecret(ctx, "app", map[string][]byte{"key": []byte("v2")}); err != nil {
+		t.Fatalf("stageSecret() error: %v", err)
+	}
+	fss.promoteApproved(ctx)
+	if got := liveValue(); got != "v1" {
+		t.Fatalf("Expected v2 to wait for a new approval, got %q", got)
+	}
+	annotate("true")
+	fss.promoteApproved(ctx)
+	if got := liveValue(); got != "v2" {
+		t.Errorf("Expected v2 to be promoted, got %q", got)
+	}
+}
+
+func TestApprovePromotionAPI(t *testing.T) {
+	fss, _ := newPromotionTest(true)
+	fss.syncNow = make(chan struct{}, 1)
+	state := fss.shared()
+	state.health.track(fss)
+
+	mux := state.newHTTPMux()
+	state.registerAdminAPI(mux, "secret-token")
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	approve := func(query string) int {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/api/promote"+query, nil)
+		req.Header.Set("Authorization", "Bearer secret-token")
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatalf("POST /api/promote failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := approve(""); status != http.StatusNotFound {
+		t.Errorf("Expected status %d without pending promotions, got %d", http.StatusNotFound, status)
+	}
+
+	ctx := context.Background()
+	if err := fss.stageSecret(ctx, "app", map[string][]byte{"key": []byte("v1")}); err != nil {
+		t.Fatalf("stageSecret() error: %v", err)
+	}
+	if status := approve("?namespace=test-namespace&secret=other"); status != http.StatusNotFound {
+		t.Errorf("Expected status %d for another secret, got %d", http.StatusNotFound, status)
+	}
+	if status := approve("?namespace=other&secret=app"); status != http.StatusNotFound {
+		t.Errorf("Expected status %d for a secret of the same name in another namespace, got %d", http.StatusNotFound, status)
+	}
+	if status := approve("?secret=app"); status != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a secret without namespace, got %d", http.StatusBadRequest, status)
+	}
+	if status := approve("?namespace=test-namespace&secret=app"); status != http.StatusAccepted {
+		t.Errorf("Expected status %d, got %d", http.StatusAccepted, status)
+	}
+	select {
+	case <-fss.syncNow:
+	default:
+		t.Error("Expected a sync to be requested")
+	}
+
+	// Staging other content withdraws the approval
+	if err := fss.stageSecret(ctx, "app", map[string][]byte{"key": []byte("v2")}); err != nil {
+		t.Fatalf("stageSecret() error: %v", err)
+	}
+	fss.promoteApproved(ctx)
+	if _, err := fss.client.CoreV1().Secrets("test-namespace").Get(ctx, "app", metav1.GetOptions{}); err == nil {
+		t.Fatal("Expected new content to wait for a new approval")
+	}
+	if status := approve("?namespace=test-namespace&secret=app"); status != http.StatusAccepted {
+		t.Errorf("Expected status %d, got %d", http.StatusAccepted, status)
+	}
+
+	fss.promoteApproved(ctx)
+	secret, err := fss.client.CoreV1().Secrets("test-namespace").Get(ctx, "app", metav1.GetOptions{})
+	if err != nil || string(secret.Data["key"]) != "v2" {
+		t.Errorf("Expected the approved data to be promoted, got %v (%v)", secret, err)
+	}
+}
+
+func TestLoadPendingPromotions(t *testing.T) {
+	fss, client := newPromotionTest(true)
+	managed := map[string]string{"app.kubernetes.io/managed-by": "file-secret-sync"}
+	for _, secret := range []*corev1.Secret{
+		{ObjectMeta: metav1.ObjectMeta{Name: "app-staging", Namespace: "test-namespace", Labels: managed}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "db-staging", Namespace: "test-namespace", Labels: managed}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "db-staging-staging", Namespace: "test-namespace", Labels: managed}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "manual-staging", Namespace: "test-namespace"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "other-staging", Namespace: "other-namespace", Labels: managed}},
+	} {
+		client.CoreV1().Secrets(secret.Namespace).Create(context.Background(), secret, metav1.CreateOptions{})
+	}
+
+	if err := fss.loadPending(context.Background()); err != nil {
+		t.Fatalf("loadPending() error: %v", err)
+	}
+	expected := []string{"app", "db-staging"}
+	if got := fss.promotion.pendingNames("test-namespace"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected pending promotions %v after a restart, got %v", expected, got)
+	}
+	if n := fss.promotion.approve("test-namespace", "app"); n != 1 {
+		t.Errorf("Expected the loaded promotion to be approvable, approved %d", n)
+	}
+}
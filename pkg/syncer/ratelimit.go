@@ -0,0 +1,90 @@
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// applyClientRateLimits sets the client-side rate limit of requests to the
+// API server from KUBE_API_QPS and KUBE_API_BURST, keeping client-go's
+// defaults when they are unset.
+func applyClientRateLimits(config *rest.Config) error {
+	if value := os.Getenv("KUBE_API_QPS"); value != "" {
+		qps, err := strconv.ParseFloat(value, 32)
+		if err != nil || qps <= 0 {
+			return fmt.Errorf("invalid KUBE_API_QPS %q", value)
+		}
+		config.QPS = float32(qps)
+	}
+	if value := os.Getenv("KUBE_API_BURST"); value != "" {
+		burst, err := strconv.Atoi(value)
+		if err != nil || burst <= 0 {
+			return fmt.Errorf("invalid KUBE_API_BURST %q", value)
+		}
+		config.Burst = burst
+	}
+	return nil
+}
+
+// newWriteLimiter creates the limiter shared by all writes to the API
+// server from WRITE_RATE_LIMIT, in writes per second. It returns nil when
+// writes are not limited.
+func newWriteLimiter() (flowcontrol.RateLimiter, error) {
+	value := os.Getenv("WRITE_RATE_LIMIT")
+	if value == "" {
+		return nil, nil
+	}
+	limit, err := strconv.ParseFloat(value, 32)
+	if err != nil || limit < 0 {
+		return nil, fmt.Errorf("invalid WRITE_RATE_LIMIT %q", value)
+	}
+	if limit == 0 {
+		return nil, nil
+	}
+	return flowcontrol.NewTokenBucketRateLimiter(float32(limit), 1), nil
+}
+
+// waitForWrite blocks until the write rate limit allows another write.
+func (fss *FileSecretSync) waitForWrite(ctx context.Context) error {
+	if fss.writeLimiter == nil {
+		return nil
+	}
+	if err := fss.writeLimiter.Wait(ctx); err != nil {
+		return fmt.Errorf("failed to wait for the write rate limit: %w", err)
+	}
+	return nil
+}
+
+// newWriteSlots creates the semaphore bounding how many Secrets are written
+// at once across all mappings from MAX_CONCURRENT_WRITES. It returns nil
+// when the number is not limited.
+func newWriteSlots() (chan struct{}, error) {
+	value := envOrDefault("MAX_CONCURRENT_WRITES", "0")
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		return nil, fmt.Errorf("invalid MAX_CONCURRENT_WRITES %q", value)
+	}
+	if limit == 0 {
+		return nil, nil
+	}
+	return make(chan struct{}, limit), nil
+}
+
+// acquireWriteSlot blocks until fewer than MAX_CONCURRENT_WRITES Secrets
+// are being written. The returned function releases the slot.
+func (fss *FileSecretSync) acquireWriteSlot(ctx context.Context) (func(), error) {
+	if fss.writeSlots == nil {
+		return func() {}, nil
+	}
+	select {
+	case fss.writeSlots <- struct{}{}:
+		return func() { <-fss.writeSlots }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("failed to wait for a write slot: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,123 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+func TestApplyClientRateLimits(t *testing.T) {
+	testCases := []struct {
+		name      string
+		qps       string
+		burst     string
+		expectQPS float32
+		expectBur int
+		expectErr bool
+	}{
+		{"defaults", "", "", 0, 0, false},
+		{"configured", "20", "40", 20, 40, false},
+		{"fractional QPS", "0.5", "", 0.5, 0, false},
+		{"invalid QPS", "fast", "", 0, 0, true},
+		{"negative burst", "", "-1", 0, 0, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("KUBE_API_QPS", tc.qps)
+			t.Setenv("KUBE_API_BURST", tc.burst)
+			config := &rest.Config{}
+			err := applyClientRateLimits(config)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("applyClientRateLimits() error = %v, expected error: %v", err, tc.expectErr)
+			}
+			if !tc.expectErr && (config.QPS != tc.expectQPS || config.Burst != tc.expectBur) {
+				t.Errorf("Expected QPS %v and burst %d, got %v and %d", tc.expectQPS, tc.expectBur, config.QPS, config.Burst)
+			}
+		})
+	}
+}
+
+func TestWriteRateLimit(t *testing.T) {
+	t.Setenv("WRITE_RATE_LIMIT", "")
+	if limiter, err := newWriteLimiter(); err != nil || limiter != nil {
+		t.Errorf("Expected no limiter when unset, got %v, %v", limiter, err)
+	}
+	t.Setenv("WRITE_RATE_LIMIT", "-1")
+	if _, err := newWriteLimiter(); err == nil {
+		t.Error("Expected an error for a negative limit")
+	}
+
+	t.Setenv("WRITE_RATE_LIMIT", "10")
+	limiter, err := newWriteLimiter()
+	if err != nil {
+		t.Fatalf("newWriteLimiter failed: %v", err)
+	}
+
+	tempDir := t.TempDir()
+	for _, name := range []string{"a", "b", "c"} {
+		os.MkdirAll(filepath.Join(tempDir, name), 0755)
+		os.WriteFile(filepath.Join(tempDir, name, "key"), []byte(name), 0644)
+	}
+	client := fake.NewSimpleClientset()
+	fss := &FileSecretSync{
+		client:       client,
+		namespace:    "test-namespace",
+		folderPath:   tempDir,
+		secretMode:   "per-directory",
+		writeLimiter: limiter,
+	}
+
+	// Three writes at 10 per second with a burst of one take at least 200ms
+	start := time.Now()
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("Expected writes to be rate limited, took %s", elapsed)
+	}
+
+	secrets, _ := client.CoreV1().Secrets("test-namespace").List(context.Background(), metav1.ListOptions{})
+	if len(secrets.Items) != 3 {
+		t.Errorf("Expected 3 secrets, got %d", len(secrets.Items))
+	}
+}
+
+func TestAcquireWriteSlot(t *testing.T) {
+	t.Setenv("MAX_CONCURRENT_WRITES", "1")
+	slots, err := newWriteSlots()
+	if err != nil {
+		t.Fatalf("newWriteSlots failed: %v", err)
+	}
+	fss := &FileSecretSync{writeSlots: slots}
+
+	release, err := fss.acquireWriteSlot(context.Background())
+	if err != nil {
+		t.Fatalf("acquireWriteSlot failed: %v", err)
+	}
+
+	// The only slot is taken, so a second writer waits until cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := fss.acquireWriteSlot(ctx); err == nil {
+		t.Error("Expected the second writer to wait for the slot")
+	}
+
+	release()
+	release, err = fss.acquireWriteSlot(context.Background())
+	if err != nil {
+		t.Fatalf("Expected the released slot to be available: %v", err)
+	}
+	release()
+
+	t.Setenv("MAX_CONCURRENT_WRITES", "many")
+	if _, err := newWriteSlots(); err == nil {
+		t.Error("Expected an error for an invalid limit")
+	}
+}
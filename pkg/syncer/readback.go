@@ -0,0 +1,36 @@
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// verifyWrite reads the named Secret back and checks that it holds data,
+// detecting mutating webhooks or other writers racing with the sync. A
+// mismatch is logged and recorded as a Warning event.
+func (fss *FileSecretSync) verifyWrite(ctx context.Context, name string, data map[string][]byte) error {
+	secret, err := fss.client.CoreV1().Secrets(fss.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to read back secret %s: %w", name, err)
+	}
+	defer wipe(secret.Data)
+
+	// A paused Secret intentionally holds other data
+	if _, paused := pausedUntil(secret, fss.timers().Now()); paused {
+		return nil
+	}
+
+	if !fss.hasDataChanged(secret.Data, data) {
+		return nil
+	}
+
+	message := fmt.Sprintf("Secret data read back does not match what was written (checksum %s, expected %s)",
+		dataChecksum(secret.Data), dataChecksum(data))
+	log.Printf("Verification of secret %s failed: %s", name, message)
+	fss.recordEvent(ctx, name, corev1.EventTypeWarning, "VerificationFailed", message)
+	return fmt.Errorf("secret %s failed verification: %s", name, message)
+}
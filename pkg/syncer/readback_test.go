@@ -0,0 +1,80 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestSyncFilesVerifyWrites(t *testing.T) {
+	testCases := []struct {
+		name        string
+		mutations   int
+		retries     int
+		expectError bool
+	}{
+		{"matching readback", 0, 0, false},
+		{"mutated once and retried", 1, 1, false},
+		{"mutated without retries", 1, 0, true},
+		{"mutated on every write", 3, 2, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("test: value"), 0644)
+
+			// Simulate a mutating webhook that injects a key on write
+			client := fake.NewSimpleClientset()
+			mutations := tc.mutations
+			mutate := func(action k8stesting.Action) (bool, runtime.Object, error) {
+				if mutations > 0 {
+					mutations--
+					var secret *corev1.Secret
+					switch a := action.(type) {
+					case k8stesting.CreateAction:
+						secret = a.GetObject().(*corev1.Secret)
+					case k8stesting.UpdateAction:
+						secret = a.GetObject().(*corev1.Secret)
+					}
+					injected := map[string][]byte{"injected": []byte("webhook")}
+					for key, value := range secret.Data {
+						injected[key] = value
+					}
+					secret.Data = injected
+				}
+				return false, nil, nil
+			}
+			client.PrependReactor("create", "secrets", mutate)
+			client.PrependReactor("update", "secrets", mutate)
+
+			fss := &FileSecretSync{
+				client:        client,
+				namespace:     "test-namespace",
+				secretName:    "test-secret",
+				folderPath:    tempDir,
+				verifyWrites:  true,
+				verifyRetries: tc.retries,
+			}
+
+			err := fss.syncFiles()
+			if tc.expectError && err == nil {
+				t.Error("Expected verification to fail")
+			} else if !tc.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+
+			events, _ := client.CoreV1().Events("test-namespace").List(context.Background(), metav1.ListOptions{})
+			if tc.mutations > 0 && len(events.Items) == 0 {
+				t.Error("Expected a VerificationFailed event")
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package syncer
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"go-file-secret-sync/pkg/clock"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// pausedUntilAnnotation stops syncs from overwriting a Secret until the
+// given RFC 3339 time, so restored content is not replaced right away.
+const pausedUntilAnnotation = "file-secret-sync/paused-until"
+
+// pausedUntil returns the time until which syncs to secret are paused, and
+// whether that is after now.
+func pausedUntil(secret *corev1.Secret, now time.Time) (time.Time, bool) {
+	value, ok := secret.Annotations[pausedUntilAnnotation]
+	if !ok {
+		return time.Time{}, false
+	}
+	until, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		log.Printf("Ignoring invalid %s annotation on secret %s: %v", pausedUntilAnnotation, secret.Name, err)
+		return time.Time{}, false
+	}
+	return until, now.Before(until)
+}
+
+// newResumeTimer returns the stopped timer of the sync that writes the
+// Secrets a sync left alone because they were paused.
+func newResumeTimer(c clock.Clock) clock.Timer {
+	timer := c.NewTimer(0)
+	<-timer.C() // drain the timer
+	return timer
+}
+
+// notePause records that the current sync left a Secret alone until
+// until.
+func (fss *FileSecretSync) notePause(until time.Time) {
+	if fss.pauseEnds.IsZero() || until.Before(fss.pauseEnds) {
+		fss.pauseEnds = until
+	}
+}
+
+// scheduleResume starts the resume timer for the end of the earliest
+// pause the current sync met. Without a timer, e.g. outside of
+// monitoring, the next sync after the pause writes the Secret.
+func (fss *FileSecretSync) scheduleResume() {
+	if fss.resume == nil {
+		return
+	}
+	delay := fss.pauseEnds.Sub(fss.timers().Now())
+	fss.resume.Reset(delay)
+	log.Printf("Syncing again when the pause ends in %s", delay.Round(time.Second))
+}
+
+// RunRestore implements the restore command, which copies the data of a
+// backup or versioned Secret back into a live Secret:
+//
+//	go-file-secret-sync restore [-from <secret>] [-pause <duration>] [-namespace <namespace>] <secret>
+func RunRestore(args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	from := flags.String("from", "", "Secret to restore from (default <secret>-backup)")
+	pause := flags.Duration("pause", 10*time.Minute, "how long syncs leave the restored Secret alone")
+	namespace := flags.String("namespace", "", "namespace of the Secrets (default the current namespace)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: restore [-from <secret>] [-pause <duration>] [-namespace <namespace>] <secret>")
+	}
+	name := flags.Arg(0)
+	if *from == "" {
+		*from = name + backupSuffix
+	}
+
+	if *namespace == "" {
+		var err error
+		*namespace, err = getCurrentNamespace()
+		if err != nil {
+			return err
+		}
+	}
+
+	client, err := newKubernetesClient()
+	if err != nil {
+		return err
+	}
+
+	return restoreSecret(context.Background(), client, *namespace, name, *from, *pause)
+}
+
+// restoreSecret copies the data of from into name and pauses syncs to name
+// for pause, giving time to fix the source files.
+func restoreSecret(ctx context.Context, client kubernetes.Interface, namespace, name, from string, pause time.Duration) error {
+	secrets := client.CoreV1().Secrets(namespace)
+
+	source, err := secrets.Get(ctx, from, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get secret %s: %w", from, err)
+	}
+	secret, err := secrets.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get secret %s: %w", name, err)
+	}
+	if isImmutable(secret) {
+		return fmt.Errorf("secret %s is immutable and cannot be restored in place", name)
+	}
+
+	secret.Data = source.Data
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+	secret.Annotations[checksumAnnotation] = dataChecksum(source.Data)
+	secret.Annotations[pausedUntilAnnotation] = time.Now().Add(pause).UTC().Format(time.RFC3339)
+
+	if _, err := secrets.Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update secret %s: %w", name, err)
+	}
+	log.Printf("Restored secret %s from %s, syncs paused for %s", name, from, pause)
+	return nil
+}
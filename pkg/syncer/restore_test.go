@@ -0,0 +1,139 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-file-secret-sync/pkg/clock"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRestoreSecretPausesSync(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("bad"), 0644)
+
+	client := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+			Data:       map[string][]byte{"config.yaml": []byte("bad")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-secret-backup", Namespace: "test-namespace"},
+			Data:       map[string][]byte{"config.yaml": []byte("good")},
+		},
+	)
+
+	ctx := context.Background()
+	if err := restoreSecret(ctx, client, "test-namespace", "test-secret", "test-secret-backup", time.Hour); err != nil {
+		t.Fatalf("restoreSecret failed: %v", err)
+	}
+
+	// A sync while paused leaves the restored content in place
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		folderPath: tempDir,
+	}
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+
+	secret, err := client.CoreV1().Secrets("test-namespace").Get(ctx, "test-secret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if string(secret.Data["config.yaml"]) != "good" {
+		t.Errorf("Expected restored content to survive a sync, got %q", secret.Data["config.yaml"])
+	}
+
+	// Once the pause has expired, syncs resume and clear the annotation
+	secret.Annotations[pausedUntilAnnotation] = time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	client.CoreV1().Secrets("test-namespace").Update(ctx, secret, metav1.UpdateOptions{})
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+
+	secret, err = client.CoreV1().Secrets("test-namespace").Get(ctx, "test-secret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if string(secret.Data["config.yaml"]) != "bad" {
+		t.Errorf("Expected sync to resume after the pause, got %q", secret.Data["config.yaml"])
+	}
+	if _, ok := secret.Annotations[pausedUntilAnnotation]; ok {
+		t.Error("Expected pause annotation to be removed")
+	}
+}
+
+func TestRunRestoreUsage(t *testing.T) {
+	if err := RunRestore([]string{}); err == nil {
+		t.Error("Expected an error without a secret name")
+	}
+}
+
+func TestSyncResumesAfterPause(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("fixed"), 0644)
+
+	start := time.Now()
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-secret",
+			Namespace:   "test-namespace",
+			Annotations: map[string]string{pausedUntilAnnotation: start.Add(time.Hour).UTC().Format(time.RFC3339)},
+		},
+		Data: map[string][]byte{"config.yaml": []byte("restored")},
+	})
+	clk := clock.NewFake(start)
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		folderPath: tempDir,
+		appliedTTL: time.Hour,
+		clock:      clk,
+		resume:     newResumeTimer(clk),
+	}
+
+	configValue := func() string {
+		secret, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get secret: %v", err)
+		}
+		return string(secret.Data["config.yaml"])
+	}
+
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+	if got := configValue(); got != "restored" {
+		t.Fatalf("Expected the paused Secret to be left alone, got %q", got)
+	}
+
+	// The sync is repeated once the pause ends, with the files unchanged
+	clk.Advance(30 * time.Minute)
+	select {
+	case <-fss.resume.C():
+		t.Fatal("Expected no sync before the pause ends")
+	default:
+	}
+	clk.Advance(31 * time.Minute)
+	select {
+	case <-fss.resume.C():
+	default:
+		t.Fatal("Expected a sync to be scheduled when the pause ends")
+	}
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+	if got := configValue(); got != "fixed" {
+		t.Errorf("Expected the files to be written after the pause, got %q", got)
+	}
+}
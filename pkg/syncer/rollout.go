@@ -0,0 +1,115 @@
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// restartedAtAnnotation is the pod template annotation set by
+// `kubectl rollout restart`.
+const restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+// restartWorkloads triggers a rolling restart of every Deployment,
+// StatefulSet and DaemonSet in the namespace whose pods use the Secret
+// name, the same way `kubectl rollout restart` does.
+func (fss *FileSecretSync) restartWorkloads(ctx context.Context, name string) error {
+	patch, err := json.Marshal(map[string]any{
+		"spec": map[string]any{
+			"template": map[string]any{
+				"metadata": map[string]any{
+					"annotations": map[string]string{
+						restartedAtAnnotation: time.Now().UTC().Format(time.RFC3339),
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	apps := fss.client.AppsV1()
+
+	deployments, err := apps.Deployments(fss.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list deployments: %w", err)
+	}
+	for _, d := range deployments.Items {
+		if !podSpecUsesSecret(d.Spec.Template.Spec, name) {
+			continue
+		}
+		if _, err := apps.Deployments(fss.namespace).Patch(ctx, d.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return fmt.Errorf("failed to restart deployment %s: %w", d.Name, err)
+		}
+		log.Printf("Restarted deployment %s using secret %s", d.Name, name)
+	}
+
+	statefulSets, err := apps.StatefulSets(fss.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list statefulsets: %w", err)
+	}
+	for _, s := range statefulSets.Items {
+		if !podSpecUsesSecret(s.Spec.Template.Spec, name) {
+			continue
+		}
+		if _, err := apps.StatefulSets(fss.namespace).Patch(ctx, s.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return fmt.Errorf("failed to restart statefulset %s: %w", s.Name, err)
+		}
+		log.Printf("Restarted statefulset %s using secret %s", s.Name, name)
+	}
+
+	daemonSets, err := apps.DaemonSets(fss.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list daemonsets: %w", err)
+	}
+	for _, ds := range daemonSets.Items {
+		if !podSpecUsesSecret(ds.Spec.Template.Spec, name) {
+			continue
+		}
+		if _, err := apps.DaemonSets(fss.namespace).Patch(ctx, ds.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return fmt.Errorf("failed to restart daemonset %s: %w", ds.Name, err)
+		}
+		log.Printf("Restarted daemonset %s using secret %s", ds.Name, name)
+	}
+
+	return nil
+}
+
+// podSpecUsesSecret reports whether spec mounts the Secret name as a
+// volume, or reads it through envFrom or env valueFrom.
+func podSpecUsesSecret(spec corev1.PodSpec, name string) bool {
+	for _, volume := range spec.Volumes {
+		if volume.Secret != nil && volume.Secret.SecretName == name {
+			return true
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.Secret != nil && source.Secret.Name == name {
+					return true
+				}
+			}
+		}
+	}
+
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, container := range containers {
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.SecretRef != nil && envFrom.SecretRef.Name == name {
+				return true
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil && env.ValueFrom.SecretKeyRef.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
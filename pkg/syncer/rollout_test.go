@@ -0,0 +1,104 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPodSpecUsesSecret(t *testing.T) {
+	testCases := []struct {
+		name     string
+		spec     corev1.PodSpec
+		expected bool
+	}{
+		{"volume", corev1.PodSpec{Volumes: []corev1.Volume{{
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "test-secret"}},
+		}}}, true},
+		{"projected volume", corev1.PodSpec{Volumes: []corev1.Volume{{
+			VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{{
+				Secret: &corev1.SecretProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "test-secret"}},
+			}}}},
+		}}}, true},
+		{"envFrom in init container", corev1.PodSpec{InitContainers: []corev1.Container{{
+			EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "test-secret"}}}},
+		}}}, true},
+		{"env valueFrom", corev1.PodSpec{Containers: []corev1.Container{{
+			Env: []corev1.EnvVar{{Name: "PASSWORD", ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "test-secret"}, Key: "password"},
+			}}},
+		}}}, true},
+		{"other secret", corev1.PodSpec{Volumes: []corev1.Volume{{
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "other-secret"}},
+		}}}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := podSpecUsesSecret(tc.spec, "test-secret"); got != tc.expected {
+				t.Errorf("podSpecUsesSecret() = %v, expected %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSyncFilesRolloutRestart(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("test: updated"), 0644)
+
+	podTemplate := func(secretName string) corev1.PodTemplateSpec {
+		return corev1.PodTemplateSpec{Spec: corev1.PodSpec{Volumes: []corev1.Volume{{
+			Name:         "config",
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: secretName}},
+		}}}}
+	}
+
+	client := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+			Data:       map[string][]byte{"config.yaml": []byte("test: value")},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "user", Namespace: "test-namespace"},
+			Spec:       appsv1.DeploymentSpec{Template: podTemplate("test-secret")},
+		},
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "test-namespace"},
+			Spec:       appsv1.StatefulSetSpec{Template: podTemplate("other-secret")},
+		},
+	)
+	fss := &FileSecretSync{
+		client:         client,
+		namespace:      "test-namespace",
+		secretName:     "test-secret",
+		folderPath:     tempDir,
+		rolloutRestart: true,
+	}
+
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+
+	ctx := context.Background()
+	deployment, err := client.AppsV1().Deployments("test-namespace").Get(ctx, "user", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get deployment: %v", err)
+	}
+	if deployment.Spec.Template.Annotations[restartedAtAnnotation] == "" {
+		t.Error("Expected deployment using the secret to be restarted")
+	}
+
+	statefulSet, err := client.AppsV1().StatefulSets("test-namespace").Get(ctx, "unrelated", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get statefulset: %v", err)
+	}
+	if _, ok := statefulSet.Spec.Template.Annotations[restartedAtAnnotation]; ok {
+		t.Error("Expected unrelated statefulset not to be restarted")
+	}
+}
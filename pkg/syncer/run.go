@@ -0,0 +1,265 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"go-file-secret-sync/pkg/clock"
+
+	"google.golang.org/grpc"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Config configures a Syncer. ConfigFromEnvironment reads it, along with
+// every setting without a field here, from the environment variables
+// documented in the README. In a Config built by hand those settings keep
+// their defaults.
+type Config struct {
+	// Folder whose files are synced
+	Folder string
+	// Filesystem Folder and the folders of ConfigFile are read from,
+	// e.g. a fstest.MapFS in tests; nil reads the operating system's.
+	// Folders on it are polled every WATCH_POLL_INTERVAL instead of
+	// watched.
+	FS fs.FS
+	// Namespace and name of the Secret the files are written to
+	Namespace string
+	Secret    string
+	// Client used to write Secrets; the Secret is not written to the
+	// cluster when another target is configured in the environment
+	Client kubernetes.Interface
+
+	// Configuration file mapping several folders to Secrets, replacing
+	// Folder and Secret
+	ConfigFile string
+
+	// Address of the metrics, health and admin HTTP server, and of the
+	// gRPC control API; disabled when empty
+	HTTPAddr string
+	GRPCAddr string
+	// Bearer token required by the admin and control APIs, which are
+	// disabled without it
+	AdminToken string
+	// Serve the status dashboard on the HTTP server
+	Dashboard bool
+	// ConfigMap the status of every mapping is published to
+	StatusConfigMap string
+
+	// Callbacks around every sync
+	Hooks Hooks
+	// Clock of the debounce, polling and retry timers, e.g. a clock.Fake
+	// in tests; nil uses the system clock
+	Clock clock.Clock
+
+	// Settings read from the environment that have no field
+	env           *FileSecretSync
+	notifications *notificationDispatcher
+}
+
+// ConfigFromEnvironment reads the configuration from the environment.
+func ConfigFromEnvironment() (Config, error) {
+	fss, err := newFromEnvironment()
+	if err != nil {
+		return Config{}, err
+	}
+	dispatcher, err := newNotificationDispatcher()
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to configure notifications: %w", err)
+	}
+
+	return Config{
+		Folder:          fss.folderPath,
+		Namespace:       fss.namespace,
+		Secret:          fss.secretName,
+		Client:          fss.client,
+		ConfigFile:      os.Getenv("CONFIG_FILE"),
+		HTTPAddr:        os.Getenv("HTTP_ADDR"),
+		GRPCAddr:        os.Getenv("GRPC_ADDR"),
+		AdminToken:      os.Getenv("ADMIN_TOKEN"),
+		Dashboard:       os.Getenv("DASHBOARD") == "true",
+		StatusConfigMap: os.Getenv("STATUS_CONFIGMAP"),
+		env:             fss,
+		notifications:   dispatcher,
+	}, nil
+}
+
+// defaultSettings returns the settings of a Config built by hand.
+func defaultSettings() *FileSecretSync {
+	return &FileSecretSync{
+		secretMode:      "single",
+		secretKey:       "value",
+		dataFormat:      "files",
+		debounce:        time.Second,
+		debounceMaxWait: 30 * time.Second,
+		pollInterval:    10 * time.Second,
+		appliedTTL:      5 * time.Minute,
+		failurePolicy:   failurePolicyRetry,
+		syncedBy:        syncIdentity(),
+	}
+}
+
+// Syncer syncs a folder, or the folders of a configuration file, to
+// Secrets and keeps them in sync as files change.
+type Syncer struct {
+	cfg Config
+	fss *FileSecretSync
+}
+
+// New creates a Syncer for cfg.
+func New(cfg Config) *Syncer {
+	fss := defaultSettings()
+	if cfg.env != nil {
+		settings := *cfg.env
+		fss = &settings
+	}
+	fss.folderPath = cfg.Folder
+	fss.namespace = cfg.Namespace
+	fss.secretName = cfg.Secret
+	fss.client = cfg.Client
+	fss.hooks = cfg.Hooks
+	fss.reader.FS = cfg.FS
+	fss.clock = cfg.Clock
+
+	// Every Syncer reports, streams and notifies on its own
+	fss.state = newSyncerState()
+	fss.state.health.maxStaleness = fss.maxStaleness
+	fss.state.notifications = cfg.notifications
+	return &Syncer{cfg: cfg, fss: fss}
+}
+
+// Run syncs until ctx is cancelled, and returns nil then. It returns an
+// error when syncing cannot start, or when the failure policy gives up
+// after failed syncs.
+func (s *Syncer) Run(ctx context.Context) error {
+	ctx, exit := context.WithCancelCause(ctx)
+	defer exit(nil)
+
+	fss := s.fss
+	fss.ctx = ctx
+	fss.exit = exit
+	if fss.client == nil && fss.target == nil {
+		return fmt.Errorf("a Kubernetes client is required")
+	}
+
+	state := fss.shared()
+	httpServer, err := state.startHTTPServer(ctx, s.cfg.HTTPAddr, s.cfg.AdminToken, s.cfg.Dashboard, exit)
+	if err != nil {
+		return err
+	}
+	if httpServer != nil {
+		defer shutdownHTTPServer(httpServer)
+	}
+	grpcServer, err := state.startGRPCServer(ctx, s.cfg.GRPCAddr, s.cfg.AdminToken, exit)
+	if err != nil {
+		return err
+	}
+	if grpcServer != nil {
+		defer stopGRPCServer(grpcServer)
+	}
+
+	// Publish the status of all mappings to a ConfigMap
+	if s.cfg.StatusConfigMap != "" {
+		if fss.client == nil {
+			return fmt.Errorf("STATUS_CONFIGMAP requires the kubernetes target")
+		}
+		publisher := newStatusPublisher(fss.client, fss.namespace, s.cfg.StatusConfigMap)
+		state.health.onChange = publisher.trigger
+		go publisher.run(ctx, state.health)
+	}
+
+	// Notify external systems of every sync
+	if state.notifications != nil {
+		go state.notifications.run(ctx)
+	}
+
+	// Mappings from a configuration file replace the single folder
+	if s.cfg.ConfigFile != "" {
+		if err := runConfigFile(fss, s.cfg.ConfigFile); err != nil {
+			return fmt.Errorf("failed to run configuration file: %w", err)
+		}
+		return exitCause(ctx)
+	}
+
+	// Every subfolder feeds the Secret of its namespace
+	if fss.secretMode == "per-namespace" {
+		if err := fss.waitForFolder(); err != nil {
+			return fmt.Errorf("source folder unavailable: %w", err)
+		}
+		log.Printf("Starting per-namespace sync for folder: %s, secret: %s", fss.folderPath, fss.secretName)
+		if err := runNamespaceFanOut(fss); err != nil {
+			return fmt.Errorf("failed to run per-namespace sync: %w", err)
+		}
+		return exitCause(ctx)
+	}
+
+	if err := fss.newTree(); err != nil {
+		return err
+	}
+	defer fss.tree.Close()
+
+	// Report ready once the initial sync succeeded
+	fss.syncNow = make(chan struct{}, 1)
+	state.health.track(fss)
+	fss.initMetrics()
+
+	// The folder may be mounted or created after startup
+	if err := fss.waitForFolder(); err != nil {
+		return fmt.Errorf("source folder unavailable: %w", err)
+	}
+
+	log.Printf("Starting file-to-secret sync for folder: %s, secret: %s/%s", fss.folderPath, fss.namespace, fss.secretName)
+	fss.retry = newSyncRetry(fss.timers(), fss.retryPolicy)
+	fss.resume = newResumeTimer(fss.timers())
+	fss.runSync()
+
+	if err := fss.startMonitoring(); err != nil {
+		return fmt.Errorf("failed to start monitoring: %w", err)
+	}
+	return exitCause(ctx)
+}
+
+// shutdownTimeout is how long open requests may take to finish when Run
+// returns, before they are cut off.
+const shutdownTimeout = 5 * time.Second
+
+// shutdownHTTPServer stops server, waiting up to shutdownTimeout for open
+// requests to finish.
+func shutdownHTTPServer(server *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Failed to shut down the HTTP server gracefully: %v", err)
+		server.Close()
+	}
+}
+
+// stopGRPCServer stops server, waiting up to shutdownTimeout for open
+// calls, such as event streams, to finish.
+func stopGRPCServer(server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(shutdownTimeout):
+		log.Printf("Failed to shut down the gRPC server gracefully: calls still open after %s", shutdownTimeout)
+		server.Stop()
+	}
+}
+
+// exitCause returns the error Run was stopped with, or nil when it was
+// stopped by cancelling its context.
+func exitCause(ctx context.Context) error {
+	if err := context.Cause(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestRunSyncsUntilCancelled(t *testing.T) {
+	folder := t.TempDir()
+	if err := os.WriteFile(filepath.Join(folder, "password"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	client := fake.NewSimpleClientset()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- New(Config{Folder: folder, Namespace: "default", Secret: "embedded", Client: client}).Run(ctx)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		secret, err := client.CoreV1().Secrets("default").Get(context.Background(), "embedded", metav1.GetOptions{})
+		if err == nil {
+			if string(secret.Data["password"]) != "secret" {
+				t.Errorf("Expected password %q, got %q", "secret", secret.Data["password"])
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Secret was not created: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected nil after cancelling, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancelling")
+	}
+}
+
+func TestRunReadsFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"secrets/password":   {Data: []byte("secret"), Mode: 0644},
+		"secrets/tls/ca.crt": {Data: []byte("CA"), Mode: 0644},
+	}
+	client := fake.NewSimpleClientset()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- New(Config{Folder: "secrets", FS: fsys, Namespace: "default", Secret: "embedded", Client: client}).Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		secret, err := client.CoreV1().Secrets("default").Get(context.Background(), "embedded", metav1.GetOptions{})
+		if err == nil {
+			if string(secret.Data["password"]) != "secret" || string(secret.Data["tls.ca.crt"]) != "CA" {
+				t.Errorf("Expected the files of the filesystem, got %v", secret.Data)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Secret was not created: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestRunReturnsFailurePolicyError(t *testing.T) {
+	folder := t.TempDir()
+	if err := os.WriteFile(filepath.Join(folder, "password"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("api unavailable")
+	})
+
+	settings := defaultSettings()
+	settings.failurePolicy = failurePolicyExit
+	cfg := Config{Folder: folder, Namespace: "default", Secret: "embedded", Client: client, env: settings}
+
+	done := make(chan error, 1)
+	go func() { done <- New(cfg).Run(context.Background()) }()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Expected an error from the exit failure policy")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the sync failed")
+	}
+}
+
+func TestRunRequiresClient(t *testing.T) {
+	err := New(Config{Folder: t.TempDir(), Namespace: "default", Secret: "embedded"}).Run(context.Background())
+	if err == nil {
+		t.Error("Expected an error without a Kubernetes client")
+	}
+}
+
+func TestRunServersAreIndependent(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+
+	// A busy address fails Run instead of exiting the process
+	cfg := Config{Folder: t.TempDir(), Namespace: "default", Secret: "embedded", Client: fake.NewSimpleClientset(), HTTPAddr: busy.Addr().String()}
+	if err := New(cfg).Run(context.Background()); err == nil {
+		t.Error("Expected an error when the HTTP address is in use")
+	}
+
+	// Syncers report their own mappings, and free their address on return
+	first, second := New(Config{Namespace: "default", Secret: "first"}), New(Config{Namespace: "default", Secret: "second"})
+	first.fss.shared().health.track(first.fss)
+	if second.fss.shared().health.ready() || len(second.fss.shared().health.mappings) != 0 {
+		t.Error("Expected the second Syncer to track no mappings of the first")
+	}
+	addr := busy.Addr().String()
+	busy.Close()
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		cfg := Config{Folder: t.TempDir(), Namespace: "default", Secret: "embedded", Client: fake.NewSimpleClientset(), HTTPAddr: addr}
+		if err := New(cfg).Run(ctx); err != nil {
+			t.Errorf("Run %d failed: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package syncer
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// secretTypeFor returns the type of the Secrets written with dataFormat.
+func secretTypeFor(dataFormat string) corev1.SecretType {
+	switch dataFormat {
+	case "dockerconfigjson":
+		return corev1.SecretTypeDockerConfigJson
+	case "basic-auth":
+		return corev1.SecretTypeBasicAuth
+	case "ssh-auth":
+		return corev1.SecretTypeSSHAuth
+	default:
+		return corev1.SecretTypeOpaque
+	}
+}
+
+// secretType returns the type of the named Secret: the type set by the
+// folder configuration of its folder, or else the type of DATA_FORMAT.
+func (fss *FileSecretSync) secretType(name string) corev1.SecretType {
+	if base, ok := fss.versionBases[name]; ok {
+		name = base
+	}
+	if secretType, ok := fss.folderTypes[name]; ok {
+		return secretType
+	}
+	return secretTypeFor(fss.dataFormat)
+}
+
+// typeOutdated reports whether secret lacks the type DATA_FORMAT or its
+// folder configuration requires. The type of a Secret cannot be changed,
+// so it must be recreated. Opaque data is written to Secrets of any type,
+// as before types were set.
+func (fss *FileSecretSync) typeOutdated(secret *corev1.Secret) bool {
+	want := fss.secretType(secret.Name)
+	return want != corev1.SecretTypeOpaque && secret.Type != want
+}
@@ -0,0 +1,33 @@
+package syncer
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestTypeOutdated(t *testing.T) {
+	testCases := []struct {
+		name       string
+		dataFormat string
+		current    corev1.SecretType
+		expected   bool
+	}{
+		{"opaque files", "files", corev1.SecretTypeOpaque, false},
+		{"files into a typed Secret", "files", corev1.SecretTypeTLS, false},
+		{"dockerconfigjson into opaque", "dockerconfigjson", corev1.SecretTypeOpaque, true},
+		{"dockerconfigjson up to date", "dockerconfigjson", corev1.SecretTypeDockerConfigJson, false},
+		{"basic-auth into untyped", "basic-auth", "", true},
+		{"basic-auth up to date", "basic-auth", corev1.SecretTypeBasicAuth, false},
+		{"ssh-auth into opaque", "ssh-auth", corev1.SecretTypeOpaque, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fss := &FileSecretSync{dataFormat: tc.dataFormat}
+			if got := fss.typeOutdated(&corev1.Secret{Type: tc.current}); got != tc.expected {
+				t.Errorf("typeOutdated() = %v, expected %v", got, tc.expected)
+			}
+		})
+	}
+}
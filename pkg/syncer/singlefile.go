@@ -0,0 +1,63 @@
+package syncer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"go-file-secret-sync/pkg/source"
+)
+
+// singleFile is the file of FILE_TO_READ, synced on its own by reading and
+// watching its parent directory.
+type singleFile struct {
+	// Directory holding the file
+	folder string
+	// Pattern matching only the file within folder
+	pattern string
+	// Key the content of the file is stored under
+	key string
+}
+
+// loadSingleFile configures syncing the file at path under FILE_KEY, which
+// defaults to the file's name.
+func loadSingleFile(path string) (*singleFile, error) {
+	path = filepath.Clean(path)
+	name := filepath.Base(path)
+	if name == "." || name == ".." || name == string(filepath.Separator) {
+		return nil, fmt.Errorf("invalid FILE_TO_READ %q: not a file", path)
+	}
+	key := envOrDefault("FILE_KEY", name)
+	if errs := validation.IsConfigMapKey(key); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid FILE_KEY %q: %s", key, strings.Join(errs, ", "))
+	}
+	return &singleFile{folder: filepath.Dir(path), pattern: escapeGlob(name), key: key}, nil
+}
+
+// escapeGlob escapes the characters of name that have a meaning in
+// patterns, so the result only matches name itself.
+func escapeGlob(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if strings.ContainsRune(`*?[\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// rekeySingleFile stores the content of the file read in single-file mode
+// under key. Anything but a single file, such as an extracted archive, is
+// left as it is.
+func rekeySingleFile(files map[string]source.File, key string) map[string]source.File {
+	if len(files) != 1 {
+		return files
+	}
+	for _, file := range files {
+		return map[string]source.File{key: file}
+	}
+	return files
+}
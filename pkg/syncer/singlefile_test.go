@@ -0,0 +1,88 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"go-file-secret-sync/pkg/source"
+)
+
+func TestLoadSingleFile(t *testing.T) {
+	testCases := []struct {
+		name      string
+		path      string
+		key       string
+		expected  singleFile
+		expectErr bool
+	}{
+		{"default key", "/etc/app/kubeconfig", "", singleFile{folder: "/etc/app", pattern: "kubeconfig", key: "kubeconfig"}, false},
+		{"custom key", "/etc/app/license.txt", "license", singleFile{folder: "/etc/app", pattern: "license.txt", key: "license"}, false},
+		{"glob characters", "/etc/app/[prod]*.key", "prod.key", singleFile{folder: "/etc/app", pattern: `\[prod]\*.key`, key: "prod.key"}, false},
+		{"root", "/", "", singleFile{}, true},
+		{"invalid key", "/etc/app/kubeconfig", "not/a/key", singleFile{}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("FILE_KEY", tc.key)
+			single, err := loadSingleFile(tc.path)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("loadSingleFile() error = %v, expectErr %v", err, tc.expectErr)
+			}
+			if !tc.expectErr && *single != tc.expected {
+				t.Errorf("Expected %+v, got %+v", tc.expected, *single)
+			}
+		})
+	}
+}
+
+func TestEscapeGlob(t *testing.T) {
+	for _, name := range []string{"plain", "[prod]*.key", "what?", `back\slash`} {
+		if !source.MatchesAny([]string{escapeGlob(name)}, name) {
+			t.Errorf("Expected %q to match itself once escaped", name)
+		}
+	}
+	if source.MatchesAny([]string{escapeGlob("*.key")}, "tls.key") {
+		t.Error("Expected an escaped pattern not to match other names")
+	}
+}
+
+func TestSyncFilesSingleFile(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "kubeconfig"), []byte("apiVersion: v1"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "other"), []byte("unrelated"), 0644)
+	os.MkdirAll(filepath.Join(tempDir, "nested"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "nested", "kubeconfig"), []byte("nested"), 0644)
+
+	t.Setenv("FILE_KEY", "config")
+	single, err := loadSingleFile(filepath.Join(tempDir, "kubeconfig"))
+	if err != nil {
+		t.Fatalf("loadSingleFile failed: %v", err)
+	}
+
+	client := fake.NewSimpleClientset()
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		folderPath: single.folder,
+		fileKey:    single.key,
+		reader:     source.Reader{Filter: source.Filter{Include: []string{single.pattern}, MaxDepth: 1}},
+	}
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+
+	secret, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if len(secret.Data) != 1 || string(secret.Data["config"]) != "apiVersion: v1" {
+		t.Errorf("Expected only the config key, got %v", secret.Data)
+	}
+}
@@ -0,0 +1,60 @@
+package syncer
+
+// requestSync asks the sync worker for a sync. Requests made while a sync
+// is running collapse into exactly one follow-up sync, which sees every
+// change made before it starts. Without a worker, e.g. before monitoring
+// has started, it syncs right away.
+func (fss *FileSecretSync) requestSync() {
+	if fss.syncRequests == nil {
+		fss.runSync()
+		return
+	}
+	select {
+	case fss.syncRequests <- struct{}{}:
+	default:
+		// A sync is already pending
+	}
+}
+
+// startSyncWorker runs requested syncs one at a time in the background,
+// so slow syncs never block handling file events, and at most
+// MAX_SYNCS_PER_MINUTE of them a minute. The returned function stops the
+// worker and waits for a running sync to finish; a pending request is
+// dropped rather than synced.
+func (fss *FileSecretSync) startSyncWorker() func() {
+	requests := make(chan struct{}, 1)
+	fss.syncRequests = requests
+
+	var throttle *syncThrottle
+	if fss.maxSyncsPerMinute > 0 {
+		throttle = &syncThrottle{max: fss.maxSyncsPerMinute}
+	}
+
+	done := make(chan struct{})
+	quit := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-quit:
+				return
+			case <-requests:
+			}
+			// Stopping wins over a request that was pending at the same time
+			select {
+			case <-quit:
+				return
+			default:
+			}
+			if fss.waitForSyncSlot(throttle, quit) {
+				fss.runSync()
+			}
+		}
+	}()
+
+	return func() {
+		fss.syncRequests = nil
+		close(quit)
+		<-done
+	}
+}
@@ -0,0 +1,103 @@
+package syncer
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestSyncRequestsCoalesce(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("v1"), 0644)
+
+	client := fake.NewSimpleClientset()
+	var gets atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	client.PrependReactor("get", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		// Hold the first sync until every request has been made
+		if gets.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+		return false, nil, nil
+	})
+
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		folderPath: tempDir,
+	}
+	stopWorker := fss.startSyncWorker()
+
+	fss.requestSync()
+	<-started
+	for i := 0; i < 5; i++ {
+		fss.requestSync()
+	}
+	close(release)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for gets.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	stopWorker()
+
+	if n := gets.Load(); n != 2 {
+		t.Errorf("Expected the requests during a sync to collapse into one follow-up sync, got %d syncs", n)
+	}
+}
+
+func TestStopSyncWorkerDropsPendingRequest(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("v1"), 0644)
+
+	client := fake.NewSimpleClientset()
+	var gets atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	client.PrependReactor("get", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if gets.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+		return false, nil, nil
+	})
+
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		folderPath: tempDir,
+	}
+	stopWorker := fss.startSyncWorker()
+
+	fss.requestSync()
+	<-started
+	fss.requestSync()
+
+	// Stop while the first sync runs and the follow-up is pending
+	stopped := make(chan struct{})
+	go func() {
+		stopWorker()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("Expected stopping to wait for the running sync")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-stopped
+
+	if n := gets.Load(); n != 1 {
+		t.Errorf("Expected the pending request to be dropped after stopping, got %d syncs", n)
+	}
+}
@@ -0,0 +1,220 @@
+package syncer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-file-secret-sync/pkg/source"
+)
+
+// loadIgnorePatterns returns the patterns of IGNORE_PATTERNS, a
+// comma-separated list replacing the defaults. Setting it to an empty
+// value disables ignoring.
+func loadIgnorePatterns() ([]string, error) {
+	value, ok := os.LookupEnv("IGNORE_PATTERNS")
+	if !ok {
+		return source.DefaultIgnorePatterns, nil
+	}
+
+	patterns := splitPatterns(value)
+	if err := source.ValidatePatterns(patterns); err != nil {
+		return nil, err
+	}
+	return patterns, nil
+}
+
+// splitPatterns splits a comma-separated list of patterns.
+func splitPatterns(value string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(value, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// loadTransforms returns the content transforms configured in the
+// environment, in the order they are applied. SOURCE_ENCODINGS is a
+// comma-separated list of pattern=encoding entries of files that are
+// converted to UTF-8. NORMALIZE_LINE_ENDINGS and TRIM_NEWLINE list the
+// patterns of files whose line endings are normalized and whose trailing
+// newline is stripped.
+func loadTransforms() ([]source.Transform, error) {
+	var transforms []source.Transform
+	for _, entry := range splitPatterns(os.Getenv("SOURCE_ENCODINGS")) {
+		pattern, name, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid SOURCE_ENCODINGS entry %q, expected pattern=encoding", entry)
+		}
+		patterns := []string{strings.TrimSpace(pattern)}
+		if err := source.ValidatePatterns(patterns); err != nil {
+			return nil, fmt.Errorf("invalid SOURCE_ENCODINGS: %w", err)
+		}
+		transcode, err := source.Transcode(patterns, strings.TrimSpace(name))
+		if err != nil {
+			return nil, fmt.Errorf("invalid SOURCE_ENCODINGS: %w", err)
+		}
+		transforms = append(transforms, transcode)
+	}
+	if patterns := splitPatterns(os.Getenv("NORMALIZE_LINE_ENDINGS")); len(patterns) > 0 {
+		if err := source.ValidatePatterns(patterns); err != nil {
+			return nil, fmt.Errorf("invalid NORMALIZE_LINE_ENDINGS: %w", err)
+		}
+		transforms = append(transforms, source.NormalizeLineEndings(patterns))
+	}
+	if patterns := splitPatterns(os.Getenv("TRIM_NEWLINE")); len(patterns) > 0 {
+		if err := source.ValidatePatterns(patterns); err != nil {
+			return nil, fmt.Errorf("invalid TRIM_NEWLINE: %w", err)
+		}
+		transforms = append(transforms, source.TrimNewline(patterns))
+	}
+	return transforms, nil
+}
+
+// loadMaxDepth returns the levels of directories synced from MAX_DEPTH, or
+// 1 when RECURSIVE is false. Zero syncs all of them.
+func loadMaxDepth() (int, error) {
+	maxDepth, err := strconv.Atoi(envOrDefault("MAX_DEPTH", "0"))
+	if err != nil || maxDepth < 0 {
+		return 0, fmt.Errorf("invalid MAX_DEPTH %q", os.Getenv("MAX_DEPTH"))
+	}
+	return resolveMaxDepth(maxDepth, os.Getenv("RECURSIVE") != "false")
+}
+
+// resolveMaxDepth combines a depth limit with the recursive switch, which
+// limits the depth to the top-level of the folder when off.
+func resolveMaxDepth(maxDepth int, recursive bool) (int, error) {
+	if recursive {
+		return maxDepth, nil
+	}
+	if maxDepth > 1 {
+		return 0, fmt.Errorf("a depth of %d conflicts with non-recursive mode", maxDepth)
+	}
+	return 1, nil
+}
+
+// loadBinaryPolicy returns the policy for binary files of BINARY_FILES,
+// with the comma-separated patterns of BINARY_ALLOWLIST.
+func loadBinaryPolicy() (source.BinaryPolicy, error) {
+	policy := source.BinaryPolicy{
+		Mode:  envOrDefault("BINARY_FILES", source.BinaryInclude),
+		Allow: splitPatterns(os.Getenv("BINARY_ALLOWLIST")),
+	}
+	if len(policy.Allow) > 0 && policy.Mode != source.BinaryAllowlist {
+		return policy, fmt.Errorf("BINARY_ALLOWLIST requires BINARY_FILES=%s", source.BinaryAllowlist)
+	}
+	return policy, policy.Validate()
+}
+
+// loadValidation returns the content validation configured in
+// VALIDATE_FORMATS, a comma-separated list of pattern=format entries,
+// VALIDATE_SCHEMAS, a comma-separated list of pattern=file entries naming
+// JSON Schema files, and INVALID_FILES, the policy for files failing their
+// check.
+func loadValidation() (source.Validation, error) {
+	validation := source.Validation{Policy: envOrDefault("INVALID_FILES", source.ValidationBlock)}
+	for _, entry := range splitPatterns(os.Getenv("VALIDATE_FORMATS")) {
+		pattern, format, ok := strings.Cut(entry, "=")
+		if !ok {
+			return validation, fmt.Errorf("invalid VALIDATE_FORMATS entry %q, expected pattern=format", entry)
+		}
+		rule, err := source.FormatRule([]string{strings.TrimSpace(pattern)}, strings.TrimSpace(format))
+		if err != nil {
+			return validation, fmt.Errorf("invalid VALIDATE_FORMATS: %w", err)
+		}
+		validation.Rules = append(validation.Rules, rule)
+	}
+	for _, entry := range splitPatterns(os.Getenv("VALIDATE_SCHEMAS")) {
+		pattern, path, ok := strings.Cut(entry, "=")
+		if !ok {
+			return validation, fmt.Errorf("invalid VALIDATE_SCHEMAS entry %q, expected pattern=file", entry)
+		}
+		path = strings.TrimSpace(path)
+		schema, err := os.ReadFile(path)
+		if err != nil {
+			return validation, fmt.Errorf("failed to read schema: %w", err)
+		}
+		rule, err := source.SchemaRule([]string{strings.TrimSpace(pattern)}, filepath.Base(path), schema)
+		if err != nil {
+			return validation, fmt.Errorf("invalid VALIDATE_SCHEMAS: %w", err)
+		}
+		validation.Rules = append(validation.Rules, rule)
+	}
+	return validation, validation.Validate()
+}
+
+// newURLSource creates a URL source from the environment. SOURCE_URLS is a
+// comma-separated list of URLs, each optionally prefixed with "key=". It
+// returns nil when no URLs are configured.
+func newURLSource() (*source.URLSource, error) {
+	urls := os.Getenv("SOURCE_URLS")
+	if urls == "" {
+		return nil, nil
+	}
+
+	interval, err := time.ParseDuration(envOrDefault("SOURCE_URL_INTERVAL", "5m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SOURCE_URL_INTERVAL: %w", err)
+	}
+
+	httpClient, err := newHTTPClient(os.Getenv("SOURCE_URL_CACERT"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure SOURCE_URL_CACERT: %w", err)
+	}
+
+	u, err := source.NewURLSource(strings.Split(urls, ","), os.Getenv("SOURCE_URL_AUTH_HEADER"), interval, httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SOURCE_URL_AUTH_HEADER: %w", err)
+	}
+	return u, nil
+}
+
+// readFolderContents reads all files below the folder, keyed by their path
+// relative to the folder with separators replaced by dots.
+func (fss *FileSecretSync) readFolderContents() (map[string][]byte, error) {
+	files, err := fss.reader.ReadDir(fss.folderPath)
+	if err != nil {
+		return nil, err
+	}
+	return source.Contents(files), nil
+}
+
+// isIgnoredEvent reports whether a file event for path cannot change the
+// synced data, so it does not need to trigger a sync.
+func (fss *FileSecretSync) isIgnoredEvent(path string) bool {
+	relPath, err := filepath.Rel(fss.folderPath, path)
+	if err != nil || strings.HasPrefix(relPath, "..") {
+		return false
+	}
+	return fss.reader.Filter.Ignores(filepath.ToSlash(relPath))
+}
+
+// sourceFingerprint returns a digest over the path, permissions and
+// content of every file below the folder. It returns "" when the
+// fingerprint cannot stand for the synced data, e.g. with URL sources.
+func (fss *FileSecretSync) sourceFingerprint() (string, error) {
+	if fss.folderPath == "" || fss.urls != nil {
+		return "", nil
+	}
+	return fss.reader.Fingerprint(fss.folderPath)
+}
+
+// sourceUnchanged reports whether fingerprint matches the files of the
+// last successful sync and every Secret written then is still trusted to
+// hold its data, so the files need not be read at all.
+func (fss *FileSecretSync) sourceUnchanged(fingerprint string, now time.Time) bool {
+	if fingerprint == "" || fingerprint != fss.lastFingerprint || len(fss.lastChecksums) == 0 {
+		return false
+	}
+	for name, checksum := range fss.lastChecksums {
+		if !fss.isUnchanged(name, checksum, now) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,299 @@
+package syncer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"go-file-secret-sync/pkg/source"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSourceFingerprint(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "key.pem"), []byte("KEY"), 0600)
+
+	fss := &FileSecretSync{folderPath: tempDir}
+	if fingerprint, err := fss.sourceFingerprint(); err != nil || fingerprint == "" {
+		t.Errorf("Expected a fingerprint of the folder, got %q, %v", fingerprint, err)
+	}
+
+	// URL data is not covered by the fingerprint
+	fss.urls = &source.URLSource{}
+	if fingerprint, _ := fss.sourceFingerprint(); fingerprint != "" {
+		t.Error("Expected no fingerprint with URL sources")
+	}
+}
+
+func TestSyncSkipsReadingUnchangedFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("v1"), 0644)
+
+	fss := &FileSecretSync{
+		client:     fake.NewSimpleClientset(),
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		folderPath: tempDir,
+		appliedTTL: time.Hour,
+	}
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+	fingerprint, _ := fss.sourceFingerprint()
+	if !fss.sourceUnchanged(fingerprint, time.Now()) {
+		t.Error("Expected the files to be unchanged after a sync")
+	}
+
+	// Once the Secret is no longer trusted the files are read again
+	if fss.sourceUnchanged(fingerprint, time.Now().Add(2*time.Hour)) {
+		t.Error("Expected expired Secrets to require reading the files")
+	}
+
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("v2"), 0644)
+	fingerprint, _ = fss.sourceFingerprint()
+	if fss.sourceUnchanged(fingerprint, time.Now()) {
+		t.Error("Expected changed files to be read")
+	}
+}
+
+func TestLoadIgnorePatterns(t *testing.T) {
+	t.Setenv("IGNORE_PATTERNS", "*.log, *.pid")
+	patterns, err := loadIgnorePatterns()
+	if err != nil || len(patterns) != 2 || patterns[1] != "*.pid" {
+		t.Errorf("Expected overridden patterns, got %v, %v", patterns, err)
+	}
+
+	t.Setenv("IGNORE_PATTERNS", "")
+	patterns, err = loadIgnorePatterns()
+	if err != nil || len(patterns) != 0 {
+		t.Errorf("Expected no patterns when disabled, got %v, %v", patterns, err)
+	}
+
+	t.Setenv("IGNORE_PATTERNS", "[")
+	if _, err := loadIgnorePatterns(); err == nil {
+		t.Error("Expected invalid pattern to fail")
+	}
+}
+
+func TestLoadMaxDepth(t *testing.T) {
+	testCases := []struct {
+		name      string
+		maxDepth  string
+		recursive string
+		expected  int
+		expectErr bool
+	}{
+		{"default", "", "", 0, false},
+		{"limited", "3", "", 3, false},
+		{"non-recursive", "", "false", 1, false},
+		{"non-recursive top-level", "1", "false", 1, false},
+		{"deep non-recursive", "2", "false", 0, true},
+		{"negative", "-1", "", 0, true},
+		{"not a number", "deep", "", 0, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("MAX_DEPTH", tc.maxDepth)
+			t.Setenv("RECURSIVE", tc.recursive)
+			maxDepth, err := loadMaxDepth()
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("loadMaxDepth() error = %v, expectErr %v", err, tc.expectErr)
+			}
+			if maxDepth != tc.expected {
+				t.Errorf("Expected %d, got %d", tc.expected, maxDepth)
+			}
+		})
+	}
+}
+
+func TestLoadBinaryPolicy(t *testing.T) {
+	testCases := []struct {
+		name      string
+		mode      string
+		allowlist string
+		expected  source.BinaryPolicy
+		expectErr bool
+	}{
+		{"default", "", "", source.BinaryPolicy{Mode: source.BinaryInclude}, false},
+		{"skip", "skip", "", source.BinaryPolicy{Mode: source.BinarySkip}, false},
+		{"allowlist", "allowlist", "*.p12, *.jks", source.BinaryPolicy{Mode: source.BinaryAllowlist, Allow: []string{"*.p12", "*.jks"}}, false},
+		{"allowlist without mode", "", "*.p12", source.BinaryPolicy{}, true},
+		{"unknown mode", "sometimes", "", source.BinaryPolicy{}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("BINARY_FILES", tc.mode)
+			t.Setenv("BINARY_ALLOWLIST", tc.allowlist)
+			policy, err := loadBinaryPolicy()
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("loadBinaryPolicy() error = %v, expectErr %v", err, tc.expectErr)
+			}
+			if !tc.expectErr && !reflect.DeepEqual(policy, tc.expected) {
+				t.Errorf("Expected %+v, got %+v", tc.expected, policy)
+			}
+		})
+	}
+}
+
+func TestLoadValidation(t *testing.T) {
+	testCases := []struct {
+		name      string
+		formats   string
+		policy    string
+		expected  [][]string
+		expectErr bool
+	}{
+		{"none", "", "", nil, false},
+		{"formats", "*.yaml=yaml, config.json=json", "skip", [][]string{{"*.yaml"}, {"config.json"}}, false},
+		{"missing format", "*.yaml", "", nil, true},
+		{"unknown format", "*.xml=xml", "", nil, true},
+		{"invalid pattern", "[=json", "", nil, true},
+		{"unknown policy", "", "sometimes", nil, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("VALIDATE_FORMATS", tc.formats)
+			t.Setenv("INVALID_FILES", tc.policy)
+			validation, err := loadValidation()
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("loadValidation() error = %v, expectErr %v", err, tc.expectErr)
+			}
+			if tc.expectErr {
+				return
+			}
+			var patterns [][]string
+			for _, rule := range validation.Rules {
+				patterns = append(patterns, rule.Patterns)
+			}
+			if !reflect.DeepEqual(patterns, tc.expected) {
+				t.Errorf("Expected patterns %v, got %v", tc.expected, patterns)
+			}
+		})
+	}
+}
+
+func TestLoadValidationSchemas(t *testing.T) {
+	schemaFile := filepath.Join(t.TempDir(), "app.schema.json")
+	os.WriteFile(schemaFile, []byte(`{"type": "object", "required": ["host"]}`), 0644)
+
+	testCases := []struct {
+		name      string
+		schemas   string
+		expectErr bool
+	}{
+		{"schema", "app/*.yaml=" + schemaFile, false},
+		{"missing file", "*.yaml", true},
+		{"unreadable schema", "*.yaml=" + schemaFile + ".missing", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("VALIDATE_SCHEMAS", tc.schemas)
+			validation, err := loadValidation()
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("loadValidation() error = %v, expectErr %v", err, tc.expectErr)
+			}
+			if tc.expectErr {
+				return
+			}
+			if len(validation.Rules) != 1 || validation.Rules[0].Description != "valid against schema app.schema.json" {
+				t.Fatalf("Unexpected rules %+v", validation.Rules)
+			}
+			if err := validation.Rules[0].Check([]byte("port: 5432\n")); err == nil {
+				t.Error("Expected a document without host to fail the schema")
+			}
+		})
+	}
+}
+
+func TestLoadTransforms(t *testing.T) {
+	testCases := []struct {
+		name        string
+		encodings   string
+		normalize   string
+		trimNewline string
+		expected    [][]string
+		expectErr   bool
+	}{
+		{"none", "", "", "", nil, false},
+		{"trim newline", "", "", "password, *.token", [][]string{{"password", "*.token"}}, false},
+		{"normalize before trimming", "", "*.yaml", "password", [][]string{{"*.yaml"}, {"password"}}, false},
+		{"transcode first", "*.csv=latin1, export/*=utf-16le", "*", "", [][]string{{"*.csv"}, {"export/*"}, {"*"}}, false},
+		{"unknown encoding", "*.csv=klingon", "", "", nil, true},
+		{"encoding without pattern", "latin1", "", "", nil, true},
+		{"invalid trim pattern", "", "", "[", nil, true},
+		{"invalid normalize pattern", "", "[", "", nil, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("SOURCE_ENCODINGS", tc.encodings)
+			t.Setenv("NORMALIZE_LINE_ENDINGS", tc.normalize)
+			t.Setenv("TRIM_NEWLINE", tc.trimNewline)
+			transforms, err := loadTransforms()
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("loadTransforms() error = %v, expectErr %v", err, tc.expectErr)
+			}
+			var patterns [][]string
+			for _, transform := range transforms {
+				patterns = append(patterns, transform.Patterns)
+			}
+			if !reflect.DeepEqual(patterns, tc.expected) {
+				t.Errorf("Expected transforms for %v, got %v", tc.expected, patterns)
+			}
+		})
+	}
+}
+
+func TestIsIgnoredEvent(t *testing.T) {
+	tempDir := t.TempDir()
+	fss := &FileSecretSync{folderPath: tempDir, reader: source.Reader{Filter: source.Filter{Ignore: source.DefaultIgnorePatterns}}}
+
+	if !fss.isIgnoredEvent(filepath.Join(tempDir, ".config.yaml.swp")) {
+		t.Error("Expected swap file event to be ignored")
+	}
+	if fss.isIgnoredEvent(filepath.Join(tempDir, "config.yaml")) {
+		t.Error("Expected config.yaml event not to be ignored")
+	}
+}
+
+func TestSyncFilesWithURLSourceOnly(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("crl-bytes"))
+	}))
+	defer server.Close()
+
+	urls, err := source.NewURLSource([]string{"root.crl=" + server.URL}, "", time.Minute, server.Client())
+	if err != nil {
+		t.Fatalf("NewURLSource failed: %v", err)
+	}
+	client := fake.NewSimpleClientset()
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		urls:       urls,
+	}
+
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+
+	secret, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get created secret: %v", err)
+	}
+	if string(secret.Data["root.crl"]) != "crl-bytes" {
+		t.Errorf("Expected URL body in secret, got %v", secret.Data)
+	}
+}
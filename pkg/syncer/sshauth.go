@@ -0,0 +1,87 @@
+package syncer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"go-file-secret-sync/pkg/redact"
+	"go-file-secret-sync/pkg/source"
+
+	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// sshKnownHostsKey is the conventional key of the known hosts of a
+// kubernetes.io/ssh-auth Secret, as read by Argo CD and Flux.
+const sshKnownHostsKey = "known_hosts"
+
+// sshAuthFiles names the files holding the credentials of a
+// kubernetes.io/ssh-auth Secret.
+type sshAuthFiles struct {
+	privateKey string
+	knownHosts string
+}
+
+// packSSHAuth stores the private key file under ssh-privatekey, and the
+// optional known hosts file under known_hosts, of a kubernetes.io/ssh-auth
+// Secret. Both must parse, and other files are refused, which usually
+// means a file is misnamed.
+func packSSHAuth(files map[string]source.File, names sshAuthFiles) (map[string][]byte, error) {
+	data := make(map[string][]byte)
+	var unexpected []string
+	for key, file := range files {
+		switch key {
+		case names.privateKey:
+			data[corev1.SSHAuthPrivateKey] = file.Content
+		case names.knownHosts:
+			data[sshKnownHostsKey] = file.Content
+		default:
+			unexpected = append(unexpected, redact.Name(key))
+		}
+	}
+	if len(unexpected) > 0 {
+		sort.Strings(unexpected)
+		return nil, fmt.Errorf("unexpected files %s; only %s and %s are synced",
+			strings.Join(unexpected, ", "), redact.Name(names.privateKey), redact.Name(names.knownHosts))
+	}
+
+	privateKey, ok := data[corev1.SSHAuthPrivateKey]
+	if !ok {
+		return nil, fmt.Errorf("private key %s was not found", redact.Name(names.privateKey))
+	}
+	if _, err := ssh.ParseRawPrivateKey(privateKey); err != nil {
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) {
+			return nil, fmt.Errorf("private key %s is protected by a passphrase", redact.Name(names.privateKey))
+		}
+		return nil, fmt.Errorf("private key %s does not parse: %w", redact.Name(names.privateKey), err)
+	}
+	if knownHosts, ok := data[sshKnownHostsKey]; ok {
+		if err := checkKnownHosts(knownHosts); err != nil {
+			return nil, fmt.Errorf("known hosts %s do not parse: %w", redact.Name(names.knownHosts), err)
+		}
+	}
+	return data, nil
+}
+
+// checkKnownHosts reports an error unless content is a known_hosts file
+// with at least one entry.
+func checkKnownHosts(content []byte) error {
+	entries := 0
+	for rest := content; len(rest) > 0; entries++ {
+		var err error
+		_, _, _, _, rest, err = ssh.ParseKnownHosts(rest)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+	if entries == 0 {
+		return fmt.Errorf("no host keys found")
+	}
+	return nil
+}
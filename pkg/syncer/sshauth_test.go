@@ -0,0 +1,115 @@
+package syncer
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"go-file-secret-sync/pkg/source"
+)
+
+// testSSHKey returns a private key in OpenSSH format, the same key
+// protected by a passphrase, and a known_hosts line for its public key.
+func testSSHKey(t *testing.T) (plain, protected, knownHosts []byte) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	encrypted, err := ssh.MarshalPrivateKeyWithPassphrase(priv, "", []byte("secret"))
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatalf("Failed to convert public key: %v", err)
+	}
+	return pem.EncodeToMemory(block), pem.EncodeToMemory(encrypted), append([]byte("github.com "), ssh.MarshalAuthorizedKey(sshPub)...)
+}
+
+func TestPackSSHAuth(t *testing.T) {
+	plain, protected, knownHosts := testSSHKey(t)
+	defaults := sshAuthFiles{privateKey: "ssh-privatekey", knownHosts: "known_hosts"}
+
+	testCases := []struct {
+		name      string
+		names     sshAuthFiles
+		files     map[string][]byte
+		keys      []string
+		expectErr bool
+	}{
+		{"key only", defaults, map[string][]byte{"ssh-privatekey": plain}, []string{"ssh-privatekey"}, false},
+		{"with known hosts", defaults, map[string][]byte{"ssh-privatekey": plain, "known_hosts": knownHosts}, []string{"ssh-privatekey", "known_hosts"}, false},
+		{"explicit names", sshAuthFiles{privateKey: "id_ed25519", knownHosts: "hosts"}, map[string][]byte{"id_ed25519": plain, "hosts": knownHosts}, []string{"ssh-privatekey", "known_hosts"}, false},
+		{"missing key", defaults, map[string][]byte{"known_hosts": knownHosts}, nil, true},
+		{"invalid key", defaults, map[string][]byte{"ssh-privatekey": []byte("not a key")}, nil, true},
+		{"passphrase", defaults, map[string][]byte{"ssh-privatekey": protected}, nil, true},
+		{"invalid known hosts", defaults, map[string][]byte{"ssh-privatekey": plain, "known_hosts": []byte("github.com garbage\n")}, nil, true},
+		{"empty known hosts", defaults, map[string][]byte{"ssh-privatekey": plain, "known_hosts": []byte("# none\n")}, nil, true},
+		{"unexpected file", defaults, map[string][]byte{"ssh-privatekey": plain, "id_rsa.pub": []byte("ssh-rsa AAAA")}, nil, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			files := make(map[string]source.File)
+			for key, content := range tc.files {
+				files[key] = source.File{Path: key, Content: content}
+			}
+			data, err := packSSHAuth(files, tc.names)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("packSSHAuth() error = %v, expectErr %v", err, tc.expectErr)
+			}
+			if len(data) != len(tc.keys) {
+				t.Errorf("Expected keys %v, got %d keys", tc.keys, len(data))
+			}
+			for _, key := range tc.keys {
+				if _, ok := data[key]; !ok {
+					t.Errorf("Expected key %s", key)
+				}
+			}
+		})
+	}
+}
+
+func TestSyncFilesSSHAuth(t *testing.T) {
+	plain, _, _ := testSSHKey(t)
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "ssh-privatekey"), plain, 0600)
+
+	client := fake.NewSimpleClientset()
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		secretName: "git-credentials",
+		folderPath: tempDir,
+		dataFormat: "ssh-auth",
+		sshAuth:    sshAuthFiles{privateKey: "ssh-privatekey", knownHosts: "known_hosts"},
+	}
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+
+	secret, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "git-credentials", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if secret.Type != corev1.SecretTypeSSHAuth {
+		t.Errorf("Expected type %s, got %s", corev1.SecretTypeSSHAuth, secret.Type)
+	}
+	if string(secret.Data[corev1.SSHAuthPrivateKey]) != string(plain) {
+		t.Errorf("Unexpected private key %q", secret.Data[corev1.SSHAuthPrivateKey])
+	}
+}
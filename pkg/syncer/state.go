@@ -0,0 +1,32 @@
+package syncer
+
+// syncerState is the state a Syncer shares between its mappings: what its
+// HTTP and gRPC servers report, the events they stream, the notifications
+// of its syncs and its metrics. Every Syncer has its own, so several can
+// run in one process.
+type syncerState struct {
+	health  *healthState
+	events  *eventBroker
+	metrics *syncMetrics
+	// Delivers the notifications of every sync; nil when no notifier is
+	// configured
+	notifications *notificationDispatcher
+}
+
+func newSyncerState() *syncerState {
+	return &syncerState{
+		health:  newHealthState(),
+		events:  &eventBroker{},
+		metrics: newSyncMetrics(),
+	}
+}
+
+// shared returns the state of the Syncer running fss. Instances created
+// outside of a Syncer, e.g. by commands that do not sync, get their own
+// on first use.
+func (fss *FileSecretSync) shared() *syncerState {
+	if fss.state == nil {
+		fss.state = newSyncerState()
+	}
+	return fss.state
+}
@@ -0,0 +1,159 @@
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// statusKey is the ConfigMap key holding the published status.
+const statusKey = "status.json"
+
+// mappingStatus is the published status of a folder mapping.
+type mappingStatus struct {
+	Folder      string     `json:"folder"`
+	Namespace   string     `json:"namespace"`
+	Secret      string     `json:"secret,omitempty"`
+	Result      string     `json:"result"`
+	Error       string     `json:"error,omitempty"`
+	Keys        int        `json:"keys"`
+	LastAttempt *time.Time `json:"lastAttempt,omitempty"`
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+}
+
+// statuses returns the status of every mapping, sorted by folder and
+// Secret.
+func (h *healthState) statuses() []mappingStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	statuses := make([]mappingStatus, 0, len(h.mappings))
+	for _, m := range h.mappings {
+		statuses = append(statuses, m.status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].less(statuses[j]) })
+	return statuses
+}
+
+// status returns the published status of m.
+func (m *mappingHealth) status() mappingStatus {
+	status := mappingStatus{
+		Folder:    m.folder,
+		Namespace: m.namespace,
+		Secret:    m.secret,
+		Result:    "pending",
+		Error:     m.lastError,
+		Keys:      m.keys,
+	}
+	if !m.lastAttempt.IsZero() {
+		status.Result = "success"
+		if m.lastError != "" {
+			status.Result = "failure"
+		}
+		lastAttempt := m.lastAttempt.UTC()
+		status.LastAttempt = &lastAttempt
+	}
+	if !m.lastSuccess.IsZero() {
+		lastSuccess := m.lastSuccess.UTC()
+		status.LastSuccess = &lastSuccess
+	}
+	return status
+}
+
+// less orders statuses by folder and Secret.
+func (s mappingStatus) less(other mappingStatus) bool {
+	if s.Folder != other.Folder {
+		return s.Folder < other.Folder
+	}
+	return s.Namespace+"/"+s.Secret < other.Namespace+"/"+other.Secret
+}
+
+// statusPublisher writes the status of all mappings to a ConfigMap, giving
+// a single place to check their health without reading logs.
+type statusPublisher struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+	notify    chan struct{}
+}
+
+func newStatusPublisher(client kubernetes.Interface, namespace, name string) *statusPublisher {
+	return &statusPublisher{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+		notify:    make(chan struct{}, 1),
+	}
+}
+
+// trigger requests publishing the status. Requests made while publishing
+// collapse into one.
+func (p *statusPublisher) trigger() {
+	select {
+	case p.notify <- struct{}{}:
+	default:
+	}
+}
+
+// run publishes the status whenever it was triggered, until ctx is done.
+func (p *statusPublisher) run(ctx context.Context, h *healthState) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.notify:
+			if err := p.publish(ctx, h.statuses()); err != nil {
+				log.Printf("Failed to publish status: %v", err)
+			}
+		}
+	}
+}
+
+// publish writes statuses to the ConfigMap, creating it when needed.
+func (p *statusPublisher) publish(ctx context.Context, statuses []mappingStatus) error {
+	content, err := json.MarshalIndent(statuses, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode status: %w", err)
+	}
+
+	configMaps := p.client.CoreV1().ConfigMaps(p.namespace)
+	configMap, err := configMaps.Get(ctx, p.name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      p.name,
+				Namespace: p.namespace,
+				Labels: map[string]string{
+					"app.kubernetes.io/managed-by": "file-secret-sync",
+				},
+			},
+			Data: map[string]string{statusKey: string(content)},
+		}
+		if _, err := configMaps.Create(ctx, configMap, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create status ConfigMap %s: %w", p.name, err)
+		}
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get status ConfigMap %s: %w", p.name, err)
+	}
+
+	if configMap.Data[statusKey] == string(content) {
+		return nil
+	}
+	if configMap.Data == nil {
+		configMap.Data = make(map[string]string)
+	}
+	configMap.Data[statusKey] = string(content)
+	if _, err := configMaps.Update(ctx, configMap, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update status ConfigMap %s: %w", p.name, err)
+	}
+	return nil
+}
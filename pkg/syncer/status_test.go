@@ -0,0 +1,74 @@
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPublishStatus(t *testing.T) {
+	h := &healthState{mappings: make(map[*FileSecretSync]*mappingHealth)}
+	app := &FileSecretSync{folderPath: "/secrets/app", namespace: "default", secretName: "app"}
+	db := &FileSecretSync{folderPath: "/secrets/db", namespace: "database", secretName: "db"}
+	idle := &FileSecretSync{folderPath: "/secrets/idle", namespace: "default", secretName: "idle"}
+	h.track(db)
+	h.track(app)
+	h.track(idle)
+
+	h.recordKeys(app, []string{"ca.pem", "tls.crt", "tls.key"})
+	h.recordSync(app, nil)
+	h.recordSync(db, nil)
+	h.recordSync(db, errors.New("secrets \"db\" is forbidden"))
+
+	client := fake.NewSimpleClientset()
+	p := newStatusPublisher(client, "default", "file-secret-sync-status")
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := p.publish(ctx, h.statuses()); err != nil {
+			t.Fatalf("publish failed: %v", err)
+		}
+	}
+
+	configMap, err := client.CoreV1().ConfigMaps("default").Get(ctx, "file-secret-sync-status", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get status ConfigMap: %v", err)
+	}
+	var statuses []mappingStatus
+	if err := json.Unmarshal([]byte(configMap.Data[statusKey]), &statuses); err != nil {
+		t.Fatalf("Failed to decode status: %v", err)
+	}
+
+	if len(statuses) != 3 {
+		t.Fatalf("Expected 3 mappings, got %v", statuses)
+	}
+	expected := []struct {
+		folder, result, err string
+		keys                int
+		succeeded           bool
+	}{
+		{"/secrets/app", "success", "", 3, true},
+		{"/secrets/db", "failure", `secrets "db" is forbidden`, 0, true},
+		{"/secrets/idle", "pending", "", 0, false},
+	}
+	for i, e := range expected {
+		s := statuses[i]
+		if s.Folder != e.folder || s.Result != e.result || s.Error != e.err || s.Keys != e.keys || (s.LastSuccess != nil) != e.succeeded {
+			t.Errorf("Unexpected status %d: %+v", i, s)
+		}
+	}
+
+	// Publishing an unchanged status does not update the ConfigMap
+	updates := 0
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "update" {
+			updates++
+		}
+	}
+	if updates != 0 {
+		t.Errorf("Expected no update for an unchanged status, got %d", updates)
+	}
+}
@@ -0,0 +1,27 @@
+package syncer
+
+import (
+	"unicode/utf8"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// setSecretData sets the data of secret. With STRING_DATA, UTF-8 values
+// are sent as stringData, so they are readable in audit logs and dry-run
+// output; the API server still stores them in data.
+func (fss *FileSecretSync) setSecretData(secret *corev1.Secret, data map[string][]byte) {
+	if !fss.stringData {
+		secret.Data = data
+		return
+	}
+
+	secret.Data = make(map[string][]byte)
+	secret.StringData = make(map[string]string)
+	for key, value := range data {
+		if utf8.Valid(value) {
+			secret.StringData[key] = string(value)
+		} else {
+			secret.Data[key] = value
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package syncer
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCreateSecretStringData(t *testing.T) {
+	data := map[string][]byte{
+		"config.yaml":  []byte("key: value\n"),
+		"keystore.p12": {0x30, 0x82, 0xff, 0xfe},
+	}
+
+	testCases := []struct {
+		name           string
+		stringData     bool
+		expectedString []string
+		expectedData   []string
+	}{
+		{"disabled", false, nil, []string{"config.yaml", "keystore.p12"}},
+		{"enabled", true, []string{"config.yaml"}, []string{"keystore.p12"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			fss := &FileSecretSync{client: client, namespace: "test-namespace", stringData: tc.stringData}
+			if err := fss.createSecret(context.Background(), "test-secret", data); err != nil {
+				t.Fatalf("createSecret failed: %v", err)
+			}
+
+			// The fake client keeps stringData as sent instead of merging it
+			secret, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Failed to get secret: %v", err)
+			}
+			if len(secret.StringData) != len(tc.expectedString) || len(secret.Data) != len(tc.expectedData) {
+				t.Errorf("Expected stringData %v and data %v, got %v and %v", tc.expectedString, tc.expectedData, secret.StringData, secret.Data)
+			}
+			for _, key := range tc.expectedString {
+				if secret.StringData[key] != string(data[key]) {
+					t.Errorf("Expected stringData %s=%q, got %q", key, data[key], secret.StringData[key])
+				}
+			}
+			for _, key := range tc.expectedData {
+				if string(secret.Data[key]) != string(data[key]) {
+					t.Errorf("Expected data %s=%q, got %q", key, data[key], secret.Data[key])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,1347 @@
+package syncer
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/flowcontrol"
+
+	"go-file-secret-sync/pkg/clock"
+	"go-file-secret-sync/pkg/redact"
+	"go-file-secret-sync/pkg/source"
+	"go-file-secret-sync/pkg/store"
+	"go-file-secret-sync/pkg/watch"
+)
+
+type FileSecretSync struct {
+	client     kubernetes.Interface
+	namespace  string
+	folderPath string
+	secretName string
+	stop       chan struct{}
+	target     store.Target
+	urls       *source.URLSource
+
+	// Reads the files of the folder, filtered and decrypted
+	reader source.Reader
+
+	// Watches the folder, polling trees that cannot be watched
+	tree         *watch.Tree
+	pollInterval time.Duration
+
+	// Quiet period before syncing, and the longest a sync is postponed
+	debounce        time.Duration
+	debounceMaxWait time.Duration
+	// Timers of debouncing, polling and retries; the system clock when nil
+	clock clock.Clock
+
+	// Cancelled on shutdown, aborting syncs and monitoring
+	ctx context.Context
+	// Stops Run with an error, e.g. when the failure policy gives up;
+	// unset outside of Run
+	exit context.CancelCauseFunc
+	// State shared with the other mappings of the Syncer; see shared
+	state *syncerState
+
+	// Limit writes to the API server, shared by all mappings
+	writeLimiter flowcontrol.RateLimiter
+	writeSlots   chan struct{}
+
+	// Checksums of the data last applied to each Secret, and how long
+	// they are trusted without reading the Secret
+	applied    map[string]appliedSecret
+	appliedTTL time.Duration
+
+	// Fingerprint of the files and checksums of the Secrets of the last
+	// successful sync
+	lastFingerprint string
+	lastChecksums   map[string]string
+
+	// Pending sync request for the sync worker
+	syncRequests chan struct{}
+
+	// Sync requested from outside, e.g. through the admin API
+	syncNow chan struct{}
+
+	// Keys changed by the current sync, for notifications
+	syncChanges []notifiedChange
+
+	// Earliest end of a pause of a Secret the current sync left alone,
+	// and the timer syncing again then
+	pauseEnds time.Time
+	resume    clock.Timer
+
+	// Callbacks of programs embedding the syncer
+	hooks Hooks
+
+	// What happens when a sync fails, and the pending retry
+	failurePolicy       string
+	retryPolicy         retryPolicy
+	retry               *syncRetry
+	maxFailures         int
+	consecutiveFailures int
+
+	// How long to wait for the folder to appear at startup
+	folderWait time.Duration
+
+	// Most keys a Secret may hold, or zero for no limit
+	maxKeys int
+	// Most syncs started per minute, or zero for no limit
+	maxSyncsPerMinute int
+	// How long changes may stay unsynced before liveness fails; zero
+	// disables the check
+	maxStaleness time.Duration
+	// Empty the Secret when no files are found, instead of failing
+	allowShrinkToZero bool
+	// Overwrite Secrets whose data was changed by hand
+	forceOverwrite bool
+
+	// Where records of the changes made by writes are kept; nil keeps none
+	audit *auditLog
+
+	// Files read by the current sync, zeroed when it ends
+	readFiles []map[string]source.File
+
+	// Fan-out of the folder into several Secrets
+	secretMode       string
+	secretNamePrefix string
+	secretNameSuffix string
+	secretKey        string
+	// SECRET_NAME_TEMPLATE; nil names Secrets from the prefix and suffix
+	nameTemplate *template.Template
+	// Time SECRET_NAME_TEMPLATE is rendered with in every mode: the
+	// start, so that names do not move while running
+	nameTime time.Time
+	// Namespaces SECRET_MODE=per-namespace may write to, as patterns
+	// matched by MatchesAny; empty allows none
+	allowedNamespaces []string
+	// Key of the file of FILE_TO_READ; empty when syncing a folder
+	fileKey string
+	// Keys that are never written, as patterns matched by MatchesAny
+	denyKeys []string
+	// Read folderConfigFile in synced folders
+	useFolderConfig bool
+	// Types set by folder configurations, by Secret name
+	folderTypes map[string]corev1.SecretType
+	// Secrets named by folder configurations, which only overwrite
+	// Secrets managed by file-secret-sync
+	folderSecrets map[string]bool
+	// Staged changes awaiting promotion; nil writes the live Secrets
+	promotion *promotion
+
+	// Key of the manifest of the synced files; empty adds none
+	manifestKey string
+	// Manifest last written to each Secret
+	manifests map[string]secretManifest
+
+	// Packing of the synced files into Secret data
+	dataFormat string
+	dataKey    string
+	dataBase64 bool
+	// Files holding the credentials with DATA_FORMAT=basic-auth and
+	// ssh-auth
+	basicAuth basicAuthFiles
+	sshAuth   sshAuthFiles
+	// Send UTF-8 values as stringData instead of data
+	stringData bool
+
+	// Annotate the MIME type and the checksum of every key
+	contentTypes bool
+	keyChecksums bool
+
+	// Annotations for restart controllers such as stakater/Reloader
+	reloaderMatch   bool
+	touchAnnotation string
+	rolloutRestart  bool
+	// Annotations for Argo CD, which otherwise flags or prunes the Secret
+	argoCD bool
+
+	// Status annotations: the fingerprint of the files being synced and
+	// who writes the Secrets
+	sourceChecksum string
+	syncedBy       string
+
+	// Identity claiming managed Secrets, and whether a Secret claimed by
+	// another instance is refused or taken over with a warning
+	instanceID       string
+	instanceConflict string
+
+	// Owner of managed Secrets for garbage collection
+	owner *metav1.OwnerReference
+
+	// Create Secrets as immutable, recreating them when content changes
+	immutable bool
+
+	// Create the namespace of a Secret when it does not exist, with
+	// these labels
+	createNamespace bool
+	namespaceLabels map[string]string
+
+	// Hash-suffixed Secret names and pointers to the current version
+	versionedNames bool
+	versionPointer bool
+	versionRetain  int
+	versionHash    string
+	versionBases   map[string]string
+
+	// Copy the previous content to <name>-backup before overwriting it
+	backup bool
+
+	// Read Secrets back after writing them
+	verifyWrites  bool
+	verifyRetries int
+}
+
+// newFromEnvironment configures a FileSecretSync from environment
+// variables.
+func newFromEnvironment() (*FileSecretSync, error) {
+	// Hide file and key names in logs before anything is logged
+	redact.Enable(os.Getenv("REDACT_LOG_NAMES") == "true")
+
+	// Read environment variables; a configuration file provides the
+	// folders and Secrets itself
+	configFile := os.Getenv("CONFIG_FILE")
+	folderToRead := os.Getenv("FOLDER_TO_READ")
+	fileToRead := os.Getenv("FILE_TO_READ")
+	if folderToRead == "" && fileToRead == "" && os.Getenv("SOURCE_URLS") == "" && configFile == "" {
+		return nil, fmt.Errorf("FOLDER_TO_READ environment variable is required")
+	}
+
+	// Sync a single file from its parent directory
+	var single *singleFile
+	if fileToRead != "" {
+		if folderToRead != "" || configFile != "" {
+			return nil, fmt.Errorf("FILE_TO_READ cannot be combined with FOLDER_TO_READ or CONFIG_FILE")
+		}
+		if mode := envOrDefault("SECRET_MODE", "single"); mode != "single" {
+			return nil, fmt.Errorf("FILE_TO_READ is not supported with SECRET_MODE=%s", mode)
+		}
+		var err error
+		single, err = loadSingleFile(fileToRead)
+		if err != nil {
+			return nil, err
+		}
+		folderToRead = single.folder
+	}
+
+	// Select how the folder maps to Secrets
+	secretMode := envOrDefault("SECRET_MODE", "single")
+	switch secretMode {
+	case "single", "per-file":
+	case "per-directory":
+		if (folderToRead == "" && configFile == "") || os.Getenv("SOURCE_URLS") != "" {
+			return nil, fmt.Errorf("SECRET_MODE=per-directory requires FOLDER_TO_READ and does not support SOURCE_URLS")
+		}
+	case "per-namespace":
+		if folderToRead == "" || configFile != "" || os.Getenv("SOURCE_URLS") != "" {
+			return nil, fmt.Errorf("SECRET_MODE=per-namespace requires FOLDER_TO_READ and does not support CONFIG_FILE or SOURCE_URLS")
+		}
+		// Whoever can create a folder must not reach every namespace
+		if os.Getenv("ALLOWED_NAMESPACES") == "" {
+			return nil, fmt.Errorf("SECRET_MODE=per-namespace requires ALLOWED_NAMESPACES; set it to * to allow every namespace")
+		}
+	default:
+		return nil, fmt.Errorf("unknown SECRET_MODE %q", secretMode)
+	}
+
+	// Select how files are packed into Secret data
+	dataFormat := envOrDefault("DATA_FORMAT", "files")
+	var dataKey string
+	switch dataFormat {
+	case "files":
+	case "tar.gz":
+		dataKey = envOrDefault("DATA_KEY", "bundle.tar.gz")
+	case "json":
+		dataKey = envOrDefault("DATA_KEY", "files.json")
+	case "ca-bundle":
+		dataKey = envOrDefault("DATA_KEY", "ca-bundle.crt")
+	case "dockerconfigjson", "basic-auth", "ssh-auth":
+		// The Secret type requires its own keys
+		if os.Getenv("DATA_KEY") != "" {
+			return nil, fmt.Errorf("DATA_KEY cannot be set with DATA_FORMAT=%s", dataFormat)
+		}
+	default:
+		return nil, fmt.Errorf("unknown DATA_FORMAT %q", dataFormat)
+	}
+	if dataFormat != "files" && secretMode == "per-file" {
+		return nil, fmt.Errorf("DATA_FORMAT=%s is not supported with SECRET_MODE=per-file", dataFormat)
+	}
+
+	// Describe the synced files in a key of their own
+	var manifestKey string
+	if os.Getenv("MANIFEST") == "true" {
+		if secretMode == "per-file" {
+			return nil, fmt.Errorf("MANIFEST is not supported with SECRET_MODE=per-file")
+		}
+		manifestKey = envOrDefault("MANIFEST_KEY", "_manifest.json")
+		if errs := validation.IsConfigMapKey(manifestKey); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid MANIFEST_KEY %q: %s", manifestKey, strings.Join(errs, ", "))
+		}
+	}
+
+	// Number of versioned Secrets to keep; 0 keeps all of them
+	versionRetain, err := strconv.Atoi(envOrDefault("VERSION_RETAIN", "0"))
+	if err != nil || versionRetain < 0 {
+		return nil, fmt.Errorf("invalid VERSION_RETAIN %q", os.Getenv("VERSION_RETAIN"))
+	}
+
+	// How versioned names are hashed
+	versionHash := envOrDefault("VERSION_HASH", "checksum")
+	if versionHash != "checksum" && versionHash != "kustomize" {
+		return nil, fmt.Errorf("unknown VERSION_HASH %q", versionHash)
+	}
+
+	// What to do about Secrets claimed by another instance
+	instanceConflict := envOrDefault("INSTANCE_CONFLICT", "refuse")
+	if instanceConflict != "refuse" && instanceConflict != "warn" {
+		return nil, fmt.Errorf("unknown INSTANCE_CONFLICT %q", instanceConflict)
+	}
+
+	// Number of times a write that does not read back correctly is retried
+	verifyRetries, err := strconv.Atoi(envOrDefault("VERIFY_RETRIES", "0"))
+	if err != nil || verifyRetries < 0 {
+		return nil, fmt.Errorf("invalid VERIFY_RETRIES %q", os.Getenv("VERIFY_RETRIES"))
+	}
+
+	debounce, err := time.ParseDuration(envOrDefault("DEBOUNCE", "1s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DEBOUNCE: %w", err)
+	}
+	debounceMaxWait, err := time.ParseDuration(envOrDefault("DEBOUNCE_MAX_WAIT", "30s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DEBOUNCE_MAX_WAIT: %w", err)
+	}
+
+	folderWait, err := time.ParseDuration(envOrDefault("WAIT_FOR_FOLDER", "0s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid WAIT_FOR_FOLDER: %w", err)
+	}
+
+	stabilityInterval, err := time.ParseDuration(envOrDefault("FILE_STABILITY_INTERVAL", "0s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid FILE_STABILITY_INTERVAL: %w", err)
+	}
+
+	pollInterval, err := time.ParseDuration(envOrDefault("WATCH_POLL_INTERVAL", "10s"))
+	if err != nil || pollInterval <= 0 {
+		return nil, fmt.Errorf("invalid WATCH_POLL_INTERVAL %q", os.Getenv("WATCH_POLL_INTERVAL"))
+	}
+
+	appliedTTL, err := time.ParseDuration(envOrDefault("APPLIED_CACHE_TTL", "5m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid APPLIED_CACHE_TTL: %w", err)
+	}
+
+	failurePolicy, err := parseFailurePolicy(os.Getenv("FAILURE_POLICY"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid FAILURE_POLICY: %w", err)
+	}
+
+	retryPolicy, err := loadRetryPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	maxFailures, err := strconv.Atoi(envOrDefault("MAX_CONSECUTIVE_FAILURES", "0"))
+	if err != nil || maxFailures < 0 {
+		return nil, fmt.Errorf("invalid MAX_CONSECUTIVE_FAILURES %q", os.Getenv("MAX_CONSECUTIVE_FAILURES"))
+	}
+
+	maxKeys, err := strconv.Atoi(envOrDefault("MAX_KEYS", "0"))
+	if err != nil || maxKeys < 0 {
+		return nil, fmt.Errorf("invalid MAX_KEYS %q", os.Getenv("MAX_KEYS"))
+	}
+
+	maxSyncsPerMinute, err := strconv.Atoi(envOrDefault("MAX_SYNCS_PER_MINUTE", "0"))
+	if err != nil || maxSyncsPerMinute < 0 {
+		return nil, fmt.Errorf("invalid MAX_SYNCS_PER_MINUTE %q", os.Getenv("MAX_SYNCS_PER_MINUTE"))
+	}
+
+	maxStaleness, err := time.ParseDuration(envOrDefault("MAX_SYNC_STALENESS", "0s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid MAX_SYNC_STALENESS: %w", err)
+	}
+
+	ignore, err := loadIgnorePatterns()
+	if err != nil {
+		return nil, fmt.Errorf("invalid IGNORE_PATTERNS: %w", err)
+	}
+
+	maxDepth, err := loadMaxDepth()
+	if err != nil {
+		return nil, err
+	}
+
+	denyKeys := splitPatterns(os.Getenv("DENY_KEYS"))
+	if err := source.ValidatePatterns(denyKeys); err != nil {
+		return nil, fmt.Errorf("invalid DENY_KEYS: %w", err)
+	}
+	filter := source.Filter{Ignore: ignore, MaxDepth: maxDepth}
+	var fileKey string
+	if single != nil {
+		filter.Include = []string{single.pattern}
+		filter.MaxDepth = 1
+		fileKey = single.key
+	}
+
+	binary, err := loadBinaryPolicy()
+	if err != nil {
+		return nil, fmt.Errorf("invalid binary file policy: %w", err)
+	}
+
+	transforms, err := loadTransforms()
+	if err != nil {
+		return nil, err
+	}
+
+	validation, err := loadValidation()
+	if err != nil {
+		return nil, fmt.Errorf("invalid content validation: %w", err)
+	}
+
+	nameTemplate, err := loadNameTemplate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid SECRET_NAME_TEMPLATE: %w", err)
+	}
+
+	secretToWrite := os.Getenv("SECRET_TO_WRITE")
+	if secretToWrite == "" && nameTemplate == nil && (secretMode == "single" || secretMode == "per-namespace") && configFile == "" {
+		return nil, fmt.Errorf("SECRET_TO_WRITE environment variable is required")
+	}
+
+	// Select where synced data is written
+	targetType := os.Getenv("TARGET")
+	if targetType == "" {
+		targetType = "kubernetes"
+	}
+
+	// Get current namespace from service account
+	namespace, err := getCurrentNamespace()
+	if err != nil {
+		if targetType == "kubernetes" {
+			return nil, fmt.Errorf("failed to get current namespace: %w", err)
+		}
+		log.Printf("Namespace not available, continuing without namespace: %v", err)
+	}
+
+	// Load optional SOPS key material, using the same variables as the
+	// sops CLI
+	sops, err := source.LoadSOPSDecryptor(os.Getenv("SOPS_AGE_KEY_FILE"), os.Getenv("SOPS_AGE_KEY"), os.Getenv("SOPS_PGP_KEY_FILE"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SOPS keys: %w", err)
+	}
+
+	// Load optional GPG key material
+	gpg, err := source.LoadGPGProcessor(os.Getenv("GPG_KEY_FILE"), os.Getenv("GPG_VERIFY_KEYRING"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load GPG keys: %w", err)
+	}
+
+	// Load optional URL source
+	urls, err := newURLSource()
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure URL source: %w", err)
+	}
+
+	writeLimiter, err := newWriteLimiter()
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure write rate limit: %w", err)
+	}
+
+	writeSlots, err := newWriteSlots()
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure concurrent writes: %w", err)
+	}
+
+	audit, err := loadAuditLog(namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure audit log: %w", err)
+	}
+
+	namespaceLabels, err := loadNamespaceLabels()
+	if err != nil {
+		return nil, fmt.Errorf("invalid NAMESPACE_LABELS: %w", err)
+	}
+
+	promotion, err := loadPromotion()
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure staged promotion: %w", err)
+	}
+	if promotion != nil && (targetType != "kubernetes" || os.Getenv("VERSIONED_NAMES") == "true") {
+		return nil, fmt.Errorf("STAGED_PROMOTION requires the kubernetes target and cannot be combined with VERSIONED_NAMES")
+	}
+
+	var clientset kubernetes.Interface
+	var target store.Target
+	var owner *metav1.OwnerReference
+	switch targetType {
+	case "kubernetes":
+		clientset, err = newKubernetesClient()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+
+		// Resolve the optional owner of managed Secrets
+		owner, err = loadOwnerReference(context.Background(), clientset, namespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure owner reference: %w", err)
+		}
+		// Owners must be in the namespace of the objects they own
+		if owner != nil && secretMode == "per-namespace" {
+			return nil, fmt.Errorf("OWNER_KIND is not supported with SECRET_MODE=per-namespace")
+		}
+	case "manifest":
+		target = newManifestTarget(secretTypeFor(dataFormat))
+	case "vault":
+		target, err = newVaultTarget()
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure vault target: %w", err)
+		}
+	case "consul":
+		target, err = newConsulTarget()
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure consul target: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown TARGET %q", targetType)
+	}
+
+	// A single Secret is named once, when starting
+	nameTime := time.Now()
+	if nameTemplate != nil && secretMode == "single" && configFile == "" {
+		secretToWrite, err = renderSecretName(nameTemplate, secretNameData{
+			Dir:       filepath.Base(folderToRead),
+			File:      fileKey,
+			Namespace: namespace,
+			Now:       nameTime,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid SECRET_NAME_TEMPLATE: %w", err)
+		}
+	}
+
+	return &FileSecretSync{
+		client:     clientset,
+		namespace:  namespace,
+		folderPath: folderToRead,
+		secretName: secretToWrite,
+		target:     target,
+		urls:       urls,
+
+		reader: source.Reader{
+			Filter:            filter,
+			Binary:            binary,
+			Transforms:        transforms,
+			Validation:        validation,
+			SOPS:              sops,
+			GPG:               gpg,
+			ExtractArchives:   os.Getenv("EXTRACT_ARCHIVES") == "true",
+			StabilityInterval: stabilityInterval,
+		},
+		pollInterval: pollInterval,
+
+		debounce:        debounce,
+		debounceMaxWait: debounceMaxWait,
+
+		writeLimiter:      writeLimiter,
+		writeSlots:        writeSlots,
+		appliedTTL:        appliedTTL,
+		failurePolicy:     failurePolicy,
+		retryPolicy:       retryPolicy,
+		maxFailures:       maxFailures,
+		maxKeys:           maxKeys,
+		maxSyncsPerMinute: maxSyncsPerMinute,
+		maxStaleness:      maxStaleness,
+		allowShrinkToZero: os.Getenv("ALLOW_SHRINK_TO_ZERO") == "true",
+		forceOverwrite:    os.Getenv("FORCE_OVERWRITE") == "true",
+		audit:             audit,
+		promotion:         promotion,
+		folderWait:        folderWait,
+
+		secretMode:       secretMode,
+		secretNamePrefix: os.Getenv("SECRET_NAME_PREFIX"),
+		secretNameSuffix: os.Getenv("SECRET_NAME_SUFFIX"),
+		secretKey:        envOrDefault("SECRET_KEY", "value"),
+		nameTemplate:     nameTemplate,
+		nameTime:         nameTime,
+		fileKey:          fileKey,
+		denyKeys:         denyKeys,
+		manifestKey:      manifestKey,
+
+		allowedNamespaces: splitPatterns(os.Getenv("ALLOWED_NAMESPACES")),
+		useFolderConfig:   os.Getenv("FOLDER_CONFIG") == "true",
+
+		dataFormat: dataFormat,
+		dataKey:    dataKey,
+		basicAuth: basicAuthFiles{
+			username: envOrDefault("BASIC_AUTH_USERNAME_FILE", corev1.BasicAuthUsernameKey),
+			password: envOrDefault("BASIC_AUTH_PASSWORD_FILE", corev1.BasicAuthPasswordKey),
+		},
+		sshAuth: sshAuthFiles{
+			privateKey: envOrDefault("SSH_PRIVATE_KEY_FILE", corev1.SSHAuthPrivateKey),
+			knownHosts: envOrDefault("SSH_KNOWN_HOSTS_FILE", sshKnownHostsKey),
+		},
+		dataBase64: os.Getenv("DATA_JSON_BASE64") == "true",
+		stringData: os.Getenv("STRING_DATA") == "true",
+
+		contentTypes: os.Getenv("CONTENT_TYPE_ANNOTATIONS") == "true",
+		keyChecksums: os.Getenv("KEY_CHECKSUM_ANNOTATIONS") == "true",
+
+		reloaderMatch:   os.Getenv("RELOADER_MATCH") == "true",
+		touchAnnotation: os.Getenv("TOUCH_ANNOTATION"),
+		syncedBy:        syncIdentity(),
+		rolloutRestart:  os.Getenv("ROLLOUT_RESTART") == "true",
+		argoCD:          os.Getenv("ARGOCD_ANNOTATIONS") == "true",
+
+		instanceID:       os.Getenv("INSTANCE_ID"),
+		instanceConflict: instanceConflict,
+
+		owner:     owner,
+		immutable: os.Getenv("IMMUTABLE") == "true",
+
+		createNamespace: os.Getenv("CREATE_NAMESPACE") == "true",
+		namespaceLabels: namespaceLabels,
+
+		versionedNames: os.Getenv("VERSIONED_NAMES") == "true",
+		versionPointer: os.Getenv("VERSION_POINTER") == "true",
+		versionRetain:  versionRetain,
+		versionHash:    versionHash,
+
+		backup: os.Getenv("BACKUP") == "true",
+
+		verifyWrites:  os.Getenv("VERIFY_WRITES") == "true",
+		verifyRetries: verifyRetries,
+	}, nil
+}
+
+// envOrDefault returns the value of the environment variable name, or def
+// when it is unset or empty.
+func envOrDefault(name, def string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return def
+}
+
+// newHTTPClient creates an HTTP client for talking to external services,
+// trusting only the PEM bundle in caFile when it is set.
+func newHTTPClient(caFile string) (*http.Client, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	if caFile == "" {
+		return client, nil
+	}
+
+	pool, err := loadCertPool(caFile)
+	if err != nil {
+		return nil, err
+	}
+	client.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}
+	return client, nil
+}
+
+// loadCertPool returns a pool of the certificates in the PEM bundle
+// caFile.
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	caBytes, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caBytes) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
+	}
+	return pool, nil
+}
+
+// newKubernetesClient creates a clientset for the cluster Secrets are
+// written to.
+func newKubernetesClient() (kubernetes.Interface, error) {
+	config, err := newClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	if err := applyClientRateLimits(config); err != nil {
+		return nil, err
+	}
+	if err := applyAPITimeout(config); err != nil {
+		return nil, err
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clientset: %w", err)
+	}
+	return client, nil
+}
+
+// serviceAccountNamespaceFile holds the namespace of the pod's service
+// account.
+var serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// getCurrentNamespace returns POD_NAMESPACE, set from the Downward API, or
+// else the namespace of the service account, for setups without the
+// service account namespace file.
+func getCurrentNamespace() (string, error) {
+	if namespace := strings.TrimSpace(os.Getenv("POD_NAMESPACE")); namespace != "" {
+		return namespace, nil
+	}
+
+	// Read namespace from service account token
+	namespaceBytes, err := os.ReadFile(serviceAccountNamespaceFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read namespace: %w", err)
+	}
+	return strings.TrimSpace(string(namespaceBytes)), nil
+}
+
+func (fss *FileSecretSync) syncFiles() (err error) {
+	start := time.Now()
+	fss.syncChanges = nil
+	fss.pauseEnds = time.Time{}
+	fss.emitEvent(eventSyncStarted, nil)
+	defer func() {
+		fss.shared().health.recordSync(fss, err)
+		fss.observeSync(time.Since(start), err)
+		if err != nil {
+			fss.emitEvent(eventSyncFailed, err)
+		} else {
+			fss.emitEvent(eventSyncSucceeded, nil)
+		}
+		fss.notifySync(err)
+		fss.completeHooks(start, err)
+	}()
+
+	// Let embedders hold back the sync
+	if err := fss.startHook(); err != nil {
+		return err
+	}
+
+	ctx := fss.rootContext()
+
+	// Promote approved changes even when the files are unchanged
+	if fss.promotion != nil {
+		fss.promoteApproved(ctx)
+	}
+
+	// Fingerprint the files before reading them, so changes made while
+	// reading make the next sync read them again
+	fingerprint, err := fss.sourceFingerprint()
+	if err != nil {
+		log.Printf("Cannot skip unchanged files: %v", err)
+	}
+	if fss.sourceUnchanged(fingerprint, time.Now()) {
+		log.Printf("Files in %s are unchanged since the last sync", fss.folderPath)
+		return nil
+	}
+
+	fss.sourceChecksum = ""
+	if fingerprint != "" {
+		fss.sourceChecksum = "sha256:" + fingerprint
+	}
+
+	secrets, err := fss.desiredSecrets(ctx)
+	// Zero the plaintext once written, or when the sync fails
+	defer fss.wipeSync(secrets)
+	if err != nil {
+		return err
+	}
+
+	// An empty folder only empties the Secret when allowed, since it
+	// usually means the volume is not mounted
+	if len(secrets) == 0 && fss.allowShrinkToZero {
+		secrets = fss.shrinkToZero()
+	} else if len(secrets) == 0 {
+		if err := fss.guardEmptySource(ctx); err != nil {
+			return err
+		}
+	}
+	if len(secrets) == 0 {
+		log.Printf("No files found in folder: %s", fss.folderPath)
+		return nil
+	}
+
+	// Write every secret even if one fails, so a single bad directory
+	// does not block the others
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Staged changes reach the live Secrets when promoted
+	write := fss.writeSecret
+	if fss.promotion != nil {
+		write = fss.stageSecret
+	}
+
+	var errs []error
+	for _, name := range names {
+		if err := write(ctx, name, secrets[name]); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		// Only point at a version, or prune older ones, once it has been
+		// written
+		base, versioned := fss.versionBases[name]
+		if versioned && fss.versionPointer {
+			if err := fss.writePointer(ctx, base, name); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		if versioned && fss.versionRetain > 0 && fss.target == nil {
+			if err := fss.pruneVersions(ctx, base, name); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return utilerrors.NewAggregate(errs)
+	}
+
+	// A paused Secret is still to be written, so the files must not be
+	// skipped as unchanged once its pause ends
+	if !fss.pauseEnds.IsZero() {
+		fss.scheduleResume()
+		fingerprint = ""
+	}
+
+	fss.observeData(secrets)
+	fss.lastFingerprint = fingerprint
+	fss.lastChecksums = make(map[string]string, len(secrets))
+	for name, data := range secrets {
+		fss.lastChecksums[name] = dataChecksum(data)
+	}
+	return nil
+}
+
+// desiredSecrets returns the Secrets that should exist, keyed by the name
+// they are written under.
+func (fss *FileSecretSync) desiredSecrets(ctx context.Context) (map[string]map[string][]byte, error) {
+	secrets, err := fss.collectSecrets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Guard against a runaway process flooding the folder
+	if err := checkKeyLimit(secrets, fss.maxKeys); err != nil {
+		return nil, err
+	}
+
+	// Content-addressed names, so every change produces a new Secret
+	if fss.versionedNames {
+		secrets = fss.versionSecrets(secrets)
+	}
+	return secrets, nil
+}
+
+// collectSecrets reads all sources and returns the data for every Secret
+// that should be written, keyed by Secret name.
+func (fss *FileSecretSync) collectSecrets(ctx context.Context) (map[string]map[string][]byte, error) {
+	// The root folder is no Secret of its own; see offlineMappings
+	if fss.secretMode == "per-namespace" {
+		return nil, fmt.Errorf("SECRET_MODE=per-namespace is synced through one mapping per namespace folder")
+	}
+
+	secrets := make(map[string]map[string][]byte)
+	fss.folderTypes = make(map[string]corev1.SecretType)
+	fss.folderSecrets = make(map[string]bool)
+
+	if fss.secretMode == "per-directory" {
+		entries, err := fs.ReadDir(fss.reader.FileSystem(), fss.folderPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read folder contents: %w", err)
+		}
+		folders := make(map[string]string, len(entries))
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				log.Printf("Ignoring top-level file in per-directory mode: %s", redact.Name(entry.Name()))
+				continue
+			}
+			name, err := fss.secretNameFor(entry.Name(), "")
+			if err != nil {
+				return nil, err
+			}
+			files, folder, err := fss.readFolder(filepath.Join(fss.folderPath, entry.Name()))
+			if err != nil {
+				fss.reportInvalidFile(ctx, name, err)
+				return nil, fmt.Errorf("failed to read folder contents: %w", err)
+			}
+			name = fss.applyFolderConfig(folder, name)
+			fss.dropDeniedKeys(files)
+			if len(files) == 0 {
+				continue
+			}
+			if other, exists := folders[name]; exists {
+				return nil, fmt.Errorf("folders %s and %s are both synced to secret %s", redact.Name(other), redact.Name(entry.Name()), redact.Name(name))
+			}
+			folders[name] = entry.Name()
+			data, err := fss.packData(files)
+			if err != nil {
+				return nil, err
+			}
+			if fss.manifestKey != "" {
+				if err := fss.addManifest(ctx, name, files, data); err != nil {
+					return nil, err
+				}
+			}
+			secrets[name] = data
+		}
+		return secrets, nil
+	}
+
+	files := make(map[string]source.File)
+	name := fss.secretName
+
+	// Read all files from the folder
+	if fss.folderPath != "" {
+		log.Printf("Reading files from folder: %s", fss.folderPath)
+
+		var err error
+		if fss.fileKey != "" {
+			files, err = fss.reader.ReadDir(fss.folderPath)
+			fss.keepForWipe(files)
+		} else {
+			var folder *folderConfig
+			files, folder, err = fss.readFolder(fss.folderPath)
+			name = fss.applyFolderConfig(folder, name)
+		}
+		if err != nil {
+			fss.reportInvalidFile(ctx, fss.secretName, err)
+			return nil, fmt.Errorf("failed to read folder contents: %w", err)
+		}
+		if fss.fileKey != "" {
+			files = rekeySingleFile(files, fss.fileKey)
+		}
+	}
+
+	// Merge documents fetched from URLs
+	if fss.urls != nil {
+		urlData, err := fss.urls.Fetch(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch URLs: %w", err)
+		}
+		for key, value := range urlData {
+			if _, exists := files[key]; exists {
+				return nil, fmt.Errorf("key %s is provided by both a file and a URL", redact.Name(key))
+			}
+			files[key] = source.File{Path: key, Mode: 0644, Content: value}
+		}
+		fss.keepForWipe(files)
+	}
+
+	// Guard against syncing keys such as private keys by accident
+	fss.dropDeniedKeys(files)
+
+	// One Secret per file, each holding the content under a single key
+	if fss.secretMode == "per-file" {
+		keys := make(map[string]string, len(files))
+		for key, file := range files {
+			name, err := fss.secretNameFor("", key)
+			if err != nil {
+				return nil, err
+			}
+			if other, exists := keys[name]; exists {
+				first, second := min(key, other), max(key, other)
+				return nil, fmt.Errorf("files %s and %s are both synced to secret %s", redact.Name(first), redact.Name(second), redact.Name(name))
+			}
+			keys[name] = key
+			secrets[name] = map[string][]byte{fss.secretKey: file.Content}
+		}
+		return secrets, nil
+	}
+
+	if len(files) > 0 {
+		data, err := fss.packData(files)
+		if err != nil {
+			return nil, err
+		}
+		if fss.manifestKey != "" {
+			if err := fss.addManifest(ctx, name, files, data); err != nil {
+				return nil, err
+			}
+		}
+		secrets[name] = data
+	}
+	return secrets, nil
+}
+
+// writeSecret writes data to the configured target, or creates or updates
+// the named Secret through the Kubernetes API.
+func (fss *FileSecretSync) writeSecret(ctx context.Context, name string, data map[string][]byte) error {
+	if fss.target != nil {
+		return fss.target.WriteSecret(ctx, fss.namespace, name, data)
+	}
+
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fmt.Errorf("invalid secret name %q: %s", name, strings.Join(errs, ", "))
+	}
+
+	// Nothing to do when the same data was applied recently
+	if fss.isUnchanged(name, dataChecksum(data), time.Now()) {
+		log.Printf("Secret %s is unchanged since it was last applied", name)
+		return nil
+	}
+
+	// Bound the Secrets written at once across all mappings
+	release, err := fss.acquireWriteSlot(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	for attempt := 0; ; attempt++ {
+		// A retry replaces what a mutating webhook made of our own write
+		if err := fss.applySecret(ctx, name, data, attempt > 0); err != nil {
+			return err
+		}
+		if !fss.verifyWrites {
+			return nil
+		}
+
+		// Read the Secret back to catch mutating webhooks and racing writers
+		err := fss.verifyWrite(ctx, name, data)
+		if err != nil {
+			fss.forgetApplied(name)
+		}
+		if err == nil || attempt >= fss.verifyRetries {
+			return err
+		}
+		log.Printf("Retrying write of secret %s: %v", name, err)
+	}
+}
+
+// applySecret creates or updates the named Secret through the Kubernetes
+// API. Data changed by hand is only overwritten when force is set.
+func (fss *FileSecretSync) applySecret(ctx context.Context, name string, data map[string][]byte, force bool) error {
+	// Get existing secret
+	secret, err := fss.client.CoreV1().Secrets(fss.namespace).Get(ctx, name, metav1.GetOptions{})
+	if secret != nil {
+		// The live values are no longer needed once the Secret is written
+		defer wipe(secret.Data)
+	}
+
+	if errors.IsNotFound(err) {
+		// Create new secret
+		if err := fss.createSecret(ctx, name, data); err != nil {
+			return err
+		}
+		fss.recordApplied(name, dataChecksum(data), time.Now())
+		fss.emitKeysChanged(name, dataDigests{}.changes(digestData(data)))
+		fss.recordAudit(ctx, name, nil, data)
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get secret %s: %w", name, err)
+	}
+
+	// Leave restored content alone until the pause expires
+	if until, paused := pausedUntil(secret, fss.timers().Now()); paused {
+		log.Printf("Secret %s is paused until %s, skipping", name, until.Format(time.RFC3339))
+		fss.notePause(until)
+		return nil
+	}
+
+	// The files of a folder must not take over any Secret they name
+	if fss.folderSecrets[name] && !fss.forceOverwrite && !managedBySync(secret) {
+		return fmt.Errorf("secret %s named in %s is not managed by file-secret-sync, refusing to overwrite it", name, folderConfigFile)
+	}
+
+	// Another deployment writing the same Secret would undo every write
+	if owner := fss.claimedByOther(secret); owner != "" {
+		if err := fss.reportInstanceConflict(ctx, secret, owner); err != nil {
+			return err
+		}
+	}
+
+	// Update existing secret if data has changed, or to stamp annotations
+	// that are missing, e.g. on Secrets written by an older version
+	changes := digestData(secret.Data).changes(digestData(data))
+	dataChanged := len(changes) > 0
+	// Do not silently overwrite changes made by hand
+	if dataChanged && !force && !fss.forceOverwrite && editedExternally(secret) {
+		return fss.reportExternalEdit(ctx, secret)
+	}
+
+	if dataChanged {
+		log.Printf("Secret %s has %s", name, formatChanges(changes))
+	}
+	immutableOutdated := fss.immutable && !isImmutable(secret)
+	typeOutdated := fss.typeOutdated(secret)
+	if dataChanged || immutableOutdated || typeOutdated || fss.annotationsOutdated(secret.ObjectMeta, data) || fss.ownerOutdated(secret.ObjectMeta) {
+		// Keep the previous content so a bad sync can be undone
+		if dataChanged && fss.backup {
+			if err := fss.backupSecret(ctx, secret); err != nil {
+				return err
+			}
+		}
+		previous := secret.Data
+		// Immutable Secrets only accept metadata changes
+		update := fss.updateSecret
+		if (dataChanged && isImmutable(secret)) || typeOutdated {
+			update = fss.recreateSecret
+		}
+		if err := update(ctx, secret, data); err != nil {
+			return err
+		}
+		fss.recordApplied(name, dataChecksum(data), time.Now())
+		if dataChanged {
+			fss.emitKeysChanged(name, changes)
+			fss.recordAudit(ctx, name, previous, data)
+		}
+		// Pods only see new env values after a restart
+		if dataChanged && fss.rolloutRestart {
+			return fss.restartWorkloads(ctx, name)
+		}
+		return nil
+	}
+
+	fss.recordApplied(name, dataChecksum(data), time.Now())
+	log.Printf("Secret %s is up to date", name)
+	return nil
+}
+
+func (fss *FileSecretSync) createSecret(ctx context.Context, name string, data map[string][]byte) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: fss.namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "file-secret-sync",
+			},
+		},
+		Type: fss.secretType(name),
+	}
+	fss.setSecretData(secret, data)
+	if fss.immutable {
+		secret.Immutable = &fss.immutable
+	}
+	fss.stampAnnotations(&secret.ObjectMeta, data)
+	fss.setOwner(&secret.ObjectMeta)
+	if base, ok := fss.versionBases[name]; ok {
+		secret.Annotations[versionOfAnnotation] = base
+	}
+
+	if err := fss.waitForWrite(ctx); err != nil {
+		return err
+	}
+	_, err := fss.client.CoreV1().Secrets(fss.namespace).Create(ctx, secret, metav1.CreateOptions{})
+	if errors.IsNotFound(err) && fss.createNamespace {
+		// The namespace does not exist yet
+		if err := fss.ensureNamespace(ctx); err != nil {
+			return err
+		}
+		_, err = fss.client.CoreV1().Secrets(fss.namespace).Create(ctx, secret, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create secret: %w", err)
+	}
+
+	log.Printf("Created secret %s with %d files", name, len(data))
+	return nil
+}
+
+func (fss *FileSecretSync) updateSecret(ctx context.Context, secret *corev1.Secret, data map[string][]byte) error {
+	fss.setSecretData(secret, data)
+	if fss.immutable {
+		secret.Immutable = &fss.immutable
+	}
+	fss.stampAnnotations(&secret.ObjectMeta, data)
+	fss.setOwner(&secret.ObjectMeta)
+	delete(secret.Annotations, pausedUntilAnnotation)
+
+	if err := fss.waitForWrite(ctx); err != nil {
+		return err
+	}
+	_, err := fss.client.CoreV1().Secrets(fss.namespace).Update(ctx, secret, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to update secret: %w", err)
+	}
+
+	log.Printf("Updated secret %s with %d files", secret.Name, len(data))
+	return nil
+}
+
+func (fss *FileSecretSync) hasDataChanged(oldData, newData map[string][]byte) bool {
+	if len(oldData) != len(newData) {
+		return true
+	}
+	return len(digestData(oldData).changes(digestData(newData))) > 0
+}
+
+func (fss *FileSecretSync) startMonitoring() error {
+	if fss.folderPath != "" {
+		log.Printf("Starting file system monitoring for: %s", fss.folderPath)
+		if err := fss.addWatches(); err != nil {
+			return err
+		}
+	}
+
+	// Retry failed syncs with backoff
+	if fss.retry == nil {
+		fss.retry = newSyncRetry(fss.timers(), fss.retryPolicy)
+	}
+	if fss.resume == nil {
+		fss.resume = newResumeTimer(fss.timers())
+	}
+
+	// Sync in the background, one sync at a time
+	stopWorker := fss.startSyncWorker()
+	defer stopWorker()
+
+	// Debounce rapid file changes
+	clk := fss.timers()
+	debounceTimer := clk.NewTimer(0)
+	<-debounceTimer.C() // drain the timer
+	var pendingSince time.Time
+
+	// Poll URL sources on their own interval
+	var pollC <-chan time.Time
+	if fss.urls != nil {
+		pollTicker := clk.NewTicker(fss.urls.Interval())
+		defer pollTicker.Stop()
+		pollC = pollTicker.C()
+	}
+
+	// Poll trees that could not be watched
+	var watchPollC <-chan time.Time
+	if fss.folderPath != "" && fss.pollInterval > 0 {
+		watchPollTicker := clk.NewTicker(fss.pollInterval)
+		defer watchPollTicker.Stop()
+		watchPollC = watchPollTicker.C()
+	}
+
+	// Check pending promotions for approval
+	var promoteC <-chan time.Time
+	if fss.promotion != nil && fss.promotion.approval {
+		promoteTicker := clk.NewTicker(fss.promotion.interval)
+		defer promoteTicker.Stop()
+		promoteC = promoteTicker.C()
+	}
+
+	for {
+		select {
+		case <-fss.stop:
+			fss.tree.Close()
+			return nil
+
+		case <-fss.rootContext().Done():
+			log.Println("Shutting down")
+			fss.tree.Close()
+			return nil
+
+		case event, ok := <-fss.tree.Events():
+			if !ok {
+				if fss.stopped() {
+					log.Println("Watcher closed")
+					return nil
+				}
+				log.Println("Watcher closed unexpectedly, re-creating it")
+				if !fss.healWatcher() {
+					return nil
+				}
+				continue
+			}
+
+			// Handle directory creation (need to add new dirs to watcher),
+			// including whole trees renamed into the folder
+			if event.Op&fsnotify.Create == fsnotify.Create {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					log.Printf("Adding new directory to watcher: %s", event.Name)
+					if err := fss.tree.AddDir(event.Name); err != nil {
+						log.Printf("Failed to watch new directory: %v", err)
+					}
+					fss.observePolling()
+				}
+			}
+
+			// Drop watches of directories that were deleted or renamed away
+			if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+				fss.tree.RemoveDir(event.Name)
+				fss.observePolling()
+			}
+
+			// Editor swap files and the like never change the data
+			if fss.isIgnoredEvent(event.Name) {
+				continue
+			}
+
+			log.Printf("File event: %s %s", event.Op, redact.Name(event.Name))
+
+			// Debounce: reset timer on each event, up to the maximum wait
+			now := clk.Now()
+			if pendingSince.IsZero() {
+				pendingSince = now
+				fss.shared().health.markPending(fss, now)
+			}
+			debounceTimer.Reset(watch.DebounceDelay(fss.debounce, fss.debounceMaxWait, pendingSince, now))
+
+		case err, ok := <-fss.tree.Errors():
+			if !ok {
+				if fss.stopped() {
+					log.Println("Watcher error channel closed")
+					return nil
+				}
+				log.Println("Watcher error channel closed unexpectedly, re-creating the watcher")
+			} else if watch.IsOverflow(err) {
+				log.Printf("Watcher event queue overflowed, rescanning %s", fss.folderPath)
+				fss.rescan()
+				continue
+			} else {
+				log.Printf("Watcher error, re-creating the watcher: %v", err)
+			}
+			if !fss.healWatcher() {
+				return nil
+			}
+
+		case <-debounceTimer.C():
+			// Debounce timer expired, sync files
+			pendingSince = time.Time{}
+			log.Println("Debounce timer expired, syncing files...")
+			fss.requestSync()
+
+		case <-fss.syncNow:
+			fss.requestSync()
+
+		case <-fss.retry.timer.C():
+			log.Println("Retrying failed sync...")
+			fss.requestSync()
+
+		case <-fss.resume.C():
+			log.Println("Pause of a Secret ended, syncing files...")
+			fss.requestSync()
+
+		case <-pollC:
+			log.Println("Polling URL sources...")
+			fss.requestSync()
+
+		case <-watchPollC:
+			if fss.tree.PollChanged() {
+				log.Println("Polled files changed, syncing files...")
+				fss.requestSync()
+			}
+
+		case <-promoteC:
+			if len(fss.promotion.pendingNames(fss.namespace)) > 0 {
+				fss.requestSync()
+			}
+		}
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package syncer
 
 import (
 	"context"
@@ -9,6 +9,8 @@ import (
 	"testing"
 	"time"
 
+	"go-file-secret-sync/pkg/clock"
+
 	"github.com/fsnotify/fsnotify"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -57,6 +59,40 @@ func TestGetCurrentNamespace(t *testing.T) {
 	}
 }
 
+func TestGetCurrentNamespacePodNamespace(t *testing.T) {
+	namespaceFile := filepath.Join(t.TempDir(), "namespace")
+	os.WriteFile(namespaceFile, []byte("from-file\n"), 0644)
+
+	testCases := []struct {
+		name         string
+		podNamespace string
+		file         string
+		expected     string
+		expectErr    bool
+	}{
+		{"service account file", "", namespaceFile, "from-file", false},
+		{"POD_NAMESPACE wins", "from-env", namespaceFile, "from-env", false},
+		{"POD_NAMESPACE without file", "from-env", namespaceFile + "_nonexistent", "from-env", false},
+		{"neither", "", namespaceFile + "_nonexistent", "", true},
+	}
+
+	original := serviceAccountNamespaceFile
+	defer func() { serviceAccountNamespaceFile = original }()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("POD_NAMESPACE", tc.podNamespace)
+			serviceAccountNamespaceFile = tc.file
+			namespace, err := getCurrentNamespace()
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("getCurrentNamespace() error = %v, expectErr %v", err, tc.expectErr)
+			}
+			if namespace != tc.expected {
+				t.Errorf("Expected namespace %q, got %q", tc.expected, namespace)
+			}
+		})
+	}
+}
+
 func TestReadFolderContents(t *testing.T) {
 	// Create temporary directory structure
 	tempDir := t.TempDir()
@@ -201,7 +237,7 @@ func TestCreateSecret(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	err := fss.createSecret(ctx, testData)
+	err := fss.createSecret(ctx, fss.secretName, testData)
 	if err != nil {
 		t.Fatalf("createSecret failed: %v", err)
 	}
@@ -396,6 +432,96 @@ func TestSyncFilesWithAPIError(t *testing.T) {
 	}
 }
 
+func TestSyncFilesPerDirectory(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		"tenant-a/password":     "a-secret",
+		"tenant-b/password":     "b-secret",
+		"tenant-b/nested/token": "b-token",
+		"top-level.txt":         "ignored",
+		"Invalid_Name/password": "rejected",
+	}
+	for filePath, content := range testFiles {
+		fullPath := filepath.Join(tempDir, filePath)
+		os.MkdirAll(filepath.Dir(fullPath), 0755)
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	os.MkdirAll(filepath.Join(tempDir, "empty"), 0755)
+
+	client := fake.NewSimpleClientset()
+	fss := &FileSecretSync{
+		client:           client,
+		namespace:        "test-namespace",
+		folderPath:       tempDir,
+		secretMode:       "per-directory",
+		secretNamePrefix: "creds-",
+	}
+
+	// The invalid directory name fails the sync, but the others are written
+	if err := fss.syncFiles(); err == nil {
+		t.Error("Expected syncFiles to report the invalid secret name")
+	}
+
+	ctx := context.Background()
+	secretA, err := client.CoreV1().Secrets("test-namespace").Get(ctx, "creds-tenant-a", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get secret for tenant-a: %v", err)
+	}
+	if string(secretA.Data["password"]) != "a-secret" || len(secretA.Data) != 1 {
+		t.Errorf("Unexpected data for tenant-a: %v", secretA.Data)
+	}
+
+	secretB, err := client.CoreV1().Secrets("test-namespace").Get(ctx, "creds-tenant-b", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get secret for tenant-b: %v", err)
+	}
+	if string(secretB.Data["nested.token"]) != "b-token" {
+		t.Errorf("Unexpected data for tenant-b: %v", secretB.Data)
+	}
+
+	secrets, _ := client.CoreV1().Secrets("test-namespace").List(ctx, metav1.ListOptions{})
+	if len(secrets.Items) != 2 {
+		t.Errorf("Expected 2 secrets, got %d", len(secrets.Items))
+	}
+}
+
+func TestSyncFilesPerFile(t *testing.T) {
+	tempDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tempDir, "db"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "license"), []byte("LICENSE-KEY"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "db", "password"), []byte("s3cr3t"), 0644)
+
+	client := fake.NewSimpleClientset()
+	fss := &FileSecretSync{
+		client:           client,
+		namespace:        "test-namespace",
+		folderPath:       tempDir,
+		secretMode:       "per-file",
+		secretNameSuffix: "-secret",
+		secretKey:        "token",
+	}
+
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"license-secret":     "LICENSE-KEY",
+		"db.password-secret": "s3cr3t",
+	}
+	for name, value := range expected {
+		secret, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get secret %s: %v", name, err)
+		}
+		if len(secret.Data) != 1 || string(secret.Data["token"]) != value {
+			t.Errorf("Unexpected data for %s: %v", name, secret.Data)
+		}
+	}
+}
+
 func TestMainEnvironmentVariables(t *testing.T) {
 	// Test missing folder_to_read
 	os.Unsetenv("FOLDER_TO_READ")
@@ -535,3 +661,57 @@ func BenchmarkHasDataChanged(b *testing.B) {
 		fss.hasDataChanged(oldData, newData)
 	}
 }
+
+func TestStartMonitoringDebounce(t *testing.T) {
+	tempDir := t.TempDir()
+	client := fake.NewSimpleClientset()
+	clk := clock.NewFake(time.Now())
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		folderPath: tempDir,
+		tree:       newTestTree(t, tempDir),
+		stop:       make(chan struct{}),
+		debounce:   time.Second,
+		clock:      clk,
+	}
+
+	// Watch the folder before starting, so no event is missed
+	if err := fss.addWatches(); err != nil {
+		t.Fatalf("addWatches failed: %v", err)
+	}
+	done := make(chan error)
+	go func() { done <- fss.startMonitoring() }()
+	defer func() {
+		fss.stopMonitoring()
+		<-done
+	}()
+	synced := func() bool {
+		_, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{})
+		return err == nil
+	}
+
+	// Rename the file into the folder, so it arrives as a single event
+	staged := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(staged, []byte("v1"), 0644)
+	os.Rename(staged, filepath.Join(tempDir, "config.yaml"))
+
+	// The event starts the debounce timer, which holds the sync back for
+	// the quiet period
+	clk.BlockUntil(1)
+	clk.Advance(fss.debounce - time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	if synced() {
+		t.Fatal("Expected no sync before the quiet period passed")
+	}
+
+	clk.Advance(time.Millisecond)
+	deadline := time.Now().Add(5 * time.Second)
+	for !synced() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the sync after the quiet period")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,69 @@
+package syncer
+
+import (
+	"fmt"
+	"os"
+
+	"go-file-secret-sync/pkg/store"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// newManifestTarget creates a manifest target from the environment.
+// MANIFEST_OUTPUT selects a directory; when empty or "-" manifests are
+// written to stdout. STRING_DATA also applies to manifests.
+func newManifestTarget(secretType corev1.SecretType) *store.Manifest {
+	outputDir := os.Getenv("MANIFEST_OUTPUT")
+	if outputDir == "-" {
+		outputDir = ""
+	}
+	return store.NewManifest(store.ManifestConfig{
+		OutputDir:  outputDir,
+		StringData: os.Getenv("MANIFEST_STRING_DATA") == "true" || os.Getenv("STRING_DATA") == "true",
+		SortKeys:   os.Getenv("MANIFEST_SORT_KEYS") == "true",
+		Type:       secretType,
+	})
+}
+
+// newVaultTarget creates a Vault target from the environment.
+func newVaultTarget() (*store.Vault, error) {
+	if os.Getenv("VAULT_ADDR") == "" {
+		return nil, fmt.Errorf("VAULT_ADDR environment variable is required for the vault target")
+	}
+	if os.Getenv("VAULT_ROLE") == "" && os.Getenv("VAULT_TOKEN") == "" {
+		return nil, fmt.Errorf("VAULT_ROLE or VAULT_TOKEN environment variable is required for the vault target")
+	}
+
+	httpClient, err := newHTTPClient(os.Getenv("VAULT_CACERT"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure VAULT_CACERT: %w", err)
+	}
+
+	return store.NewVault(store.VaultConfig{
+		Addr:       os.Getenv("VAULT_ADDR"),
+		Mount:      os.Getenv("VAULT_KV_MOUNT"),
+		PathPrefix: os.Getenv("VAULT_PATH_PREFIX"),
+		Namespace:  os.Getenv("VAULT_NAMESPACE"),
+		AuthPath:   os.Getenv("VAULT_AUTH_PATH"),
+		Role:       os.Getenv("VAULT_ROLE"),
+		Token:      os.Getenv("VAULT_TOKEN"),
+		HTTPClient: httpClient,
+	})
+}
+
+// newConsulTarget creates a Consul target from the environment, using the
+// same variables as the consul CLI where they exist.
+func newConsulTarget() (*store.Consul, error) {
+	httpClient, err := newHTTPClient(os.Getenv("CONSUL_CACERT"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure CONSUL_CACERT: %w", err)
+	}
+
+	return store.NewConsul(store.ConsulConfig{
+		Addr:       os.Getenv("CONSUL_HTTP_ADDR"),
+		Prefix:     os.Getenv("CONSUL_KV_PREFIX"),
+		Token:      os.Getenv("CONSUL_HTTP_TOKEN"),
+		Datacenter: os.Getenv("CONSUL_DATACENTER"),
+		HTTPClient: httpClient,
+	}), nil
+}
@@ -0,0 +1,58 @@
+package syncer
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-file-secret-sync/pkg/store"
+)
+
+func TestSyncFilesToManifestTarget(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("a: 1\nb: 2\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	var stdout bytes.Buffer
+	fss := &FileSecretSync{
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		folderPath: tempDir,
+		target:     store.NewManifest(store.ManifestConfig{Stdout: &stdout, StringData: true}),
+	}
+
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "name: test-secret\n  namespace: test-namespace\n") {
+		t.Errorf("Expected the Secret manifest in stdout, got:\n%s", stdout.String())
+	}
+}
+
+func TestNewVaultTargetFromEnvironment(t *testing.T) {
+	testCases := []struct {
+		name  string
+		env   map[string]string
+		valid bool
+	}{
+		{"role", map[string]string{"VAULT_ADDR": "https://vault:8200", "VAULT_ROLE": "sync"}, true},
+		{"token", map[string]string{"VAULT_ADDR": "https://vault:8200", "VAULT_TOKEN": "s.token"}, true},
+		{"missing address", map[string]string{"VAULT_ROLE": "sync"}, false},
+		{"missing credentials", map[string]string{"VAULT_ADDR": "https://vault:8200"}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, name := range []string{"VAULT_ADDR", "VAULT_ROLE", "VAULT_TOKEN"} {
+				t.Setenv(name, tc.env[name])
+			}
+			_, err := newVaultTarget()
+			if (err == nil) != tc.valid {
+				t.Errorf("Expected valid=%v, got %v", tc.valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package syncer
+
+import (
+	"log"
+	"time"
+)
+
+// syncThrottle limits how many syncs start per minute, so a folder whose
+// files change all the time, such as one a log file was placed in by
+// mistake, cannot flood the API server with writes.
+type syncThrottle struct {
+	max int
+	// Start times of the syncs within the last minute, oldest first
+	starts []time.Time
+}
+
+// delay returns how long to wait at now before the next sync may start.
+func (t *syncThrottle) delay(now time.Time) time.Duration {
+	cutoff := now.Add(-time.Minute)
+	for len(t.starts) > 0 && !t.starts[0].After(cutoff) {
+		t.starts = t.starts[1:]
+	}
+	if len(t.starts) < t.max {
+		return 0
+	}
+	return t.starts[0].Add(time.Minute).Sub(now)
+}
+
+// record notes that a sync started at now.
+func (t *syncThrottle) record(now time.Time) {
+	t.starts = append(t.starts, now)
+}
+
+// waitForSyncSlot blocks until t allows another sync, and records it. Sync
+// requests made meanwhile collapse into the sync that follows. It returns
+// false when quit is closed first.
+func (fss *FileSecretSync) waitForSyncSlot(t *syncThrottle, quit <-chan struct{}) bool {
+	if t == nil {
+		return true
+	}
+	clk := fss.timers()
+	if wait := t.delay(clk.Now()); wait > 0 {
+		log.Printf("Throttling syncs: %d started in the last minute, next sync in %s", len(t.starts), wait.Round(time.Second))
+		timer := clk.NewTimer(wait)
+		select {
+		case <-timer.C():
+		case <-quit:
+			timer.Stop()
+			return false
+		}
+	}
+	t.record(clk.Now())
+	return true
+}
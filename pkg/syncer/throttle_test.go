@@ -0,0 +1,101 @@
+package syncer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-file-secret-sync/pkg/clock"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestSyncThrottleDelay(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		starts   []time.Duration
+		at       time.Duration
+		expected time.Duration
+	}{
+		{"no syncs", nil, 0, 0},
+		{"below the limit", []time.Duration{0}, 10 * time.Second, 0},
+		{"at the limit", []time.Duration{0, 20 * time.Second}, 30 * time.Second, 30 * time.Second},
+		{"oldest expired", []time.Duration{0, 20 * time.Second}, time.Minute, 0},
+		{"waits for the oldest", []time.Duration{5 * time.Second, 50 * time.Second}, 55 * time.Second, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			throttle := &syncThrottle{max: 2}
+			for _, offset := range tt.starts {
+				throttle.record(start.Add(offset))
+			}
+			if delay := throttle.delay(start.Add(tt.at)); delay != tt.expected {
+				t.Errorf("Expected a delay of %s, got %s", tt.expected, delay)
+			}
+		})
+	}
+}
+
+func TestSyncWorkerThrottle(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("v1"), 0644)
+
+	client := fake.NewSimpleClientset()
+	synced := make(chan struct{}, 10)
+	client.PrependReactor("get", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		synced <- struct{}{}
+		return false, nil, nil
+	})
+
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	fss := &FileSecretSync{
+		client:            client,
+		namespace:         "test-namespace",
+		secretName:        "test-secret",
+		folderPath:        tempDir,
+		clock:             clk,
+		maxSyncsPerMinute: 2,
+	}
+	stopWorker := fss.startSyncWorker()
+	waitForSync := func() {
+		t.Helper()
+		select {
+		case <-synced:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for a sync")
+		}
+	}
+
+	// Two syncs are allowed right away
+	fss.requestSync()
+	waitForSync()
+	fss.requestSync()
+	waitForSync()
+
+	// The third waits for the oldest to leave the window, and the
+	// requests made meanwhile collapse into one sync after it
+	fss.requestSync()
+	clk.BlockUntil(1)
+	for i := 0; i < 5; i++ {
+		fss.requestSync()
+	}
+	if len(synced) != 0 {
+		t.Fatal("Expected the third sync to be throttled")
+	}
+	clk.Advance(time.Minute)
+	waitForSync()
+	waitForSync()
+
+	// Stopping does not wait for the throttle
+	fss.requestSync()
+	clk.BlockUntil(1)
+	stopWorker()
+	if len(synced) != 0 {
+		t.Errorf("Expected no sync after stopping, got %d", len(synced))
+	}
+}
@@ -0,0 +1,242 @@
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// maxSecretSize is the largest total size of keys and values the API
+// server accepts in a Secret.
+const maxSecretSize = 1 << 20
+
+// apiAccess is a verb on a resource that syncing needs permission for.
+type apiAccess struct {
+	verb     string
+	group    string
+	resource string
+	// Cluster-scoped resources are checked outside of any namespace
+	cluster bool
+}
+
+func (a apiAccess) String() string {
+	if a.group != "" {
+		return a.verb + " " + a.resource + "." + a.group
+	}
+	return a.verb + " " + a.resource
+}
+
+// requiredAccess returns every permission syncing needs in a namespace
+// with the current settings.
+func (fss *FileSecretSync) requiredAccess() []apiAccess {
+	access := []apiAccess{
+		{verb: "get", resource: "secrets"},
+		{verb: "create", resource: "secrets"},
+		{verb: "update", resource: "secrets"},
+		{verb: "create", resource: "events"},
+	}
+	if fss.immutable {
+		access = append(access, apiAccess{verb: "delete", resource: "secrets"})
+	}
+	if fss.versionedNames && fss.versionRetain > 0 {
+		access = append(access, apiAccess{verb: "list", resource: "secrets"}, apiAccess{verb: "delete", resource: "secrets"})
+	}
+	if fss.rolloutRestart {
+		for _, resource := range []string{"deployments", "statefulsets", "daemonsets"} {
+			access = append(access, apiAccess{verb: "list", group: "apps", resource: resource}, apiAccess{verb: "patch", group: "apps", resource: resource})
+		}
+	}
+	if fss.promotion != nil && fss.promotion.approval {
+		access = append(access, apiAccess{verb: "list", resource: "secrets"})
+	}
+	if fss.createNamespace {
+		access = append(access, apiAccess{verb: "create", resource: "namespaces", cluster: true})
+	}
+	return access
+}
+
+// checkAccess asks the API server with SelfSubjectAccessReviews whether
+// the service account has every permission syncing needs in namespace,
+// and returns a problem for each one that is denied.
+func (fss *FileSecretSync) checkAccess(ctx context.Context, namespace string) []string {
+	var problems []string
+	for _, access := range fss.requiredAccess() {
+		attributes := &authorizationv1.ResourceAttributes{
+			Verb:     access.verb,
+			Group:    access.group,
+			Resource: access.resource,
+		}
+		if !access.cluster {
+			attributes.Namespace = namespace
+		}
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: attributes},
+		}
+		result, err := fss.client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("namespace %s: failed to check permission to %s: %v", namespace, access, err))
+			continue
+		}
+		if !result.Status.Allowed {
+			problems = append(problems, fmt.Sprintf("namespace %s: not allowed to %s", namespace, access))
+		}
+	}
+	return problems
+}
+
+// checkSecrets returns a problem for every Secret with an invalid name, an
+// invalid key or more data than the API server accepts.
+func checkSecrets(secrets map[string]map[string][]byte) []string {
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	for _, name := range names {
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			problems = append(problems, fmt.Sprintf("secret %s: invalid name: %s", name, strings.Join(errs, ", ")))
+		}
+		data := secrets[name]
+		keys := make([]string, 0, len(data))
+		for key := range data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		size := 0
+		for _, key := range keys {
+			if errs := validation.IsConfigMapKey(key); len(errs) > 0 {
+				problems = append(problems, fmt.Sprintf("secret %s: invalid key %q: %s", name, key, strings.Join(errs, ", ")))
+			}
+			size += len(key) + len(data[key])
+		}
+		if size > maxSecretSize {
+			problems = append(problems, fmt.Sprintf("secret %s: %d bytes of data exceed the limit of %d bytes", name, size, maxSecretSize))
+		}
+	}
+	return problems
+}
+
+// validateMapping checks that the folder of fss exists and that its files
+// make valid Secrets, and returns every problem found.
+func (fss *FileSecretSync) validateMapping(ctx context.Context) []string {
+	if fss.folderPath != "" {
+		info, err := fs.Stat(fss.reader.FileSystem(), fss.folderPath)
+		if err != nil {
+			return []string{fmt.Sprintf("folder %s: %v", fss.folderPath, err)}
+		}
+		if !info.IsDir() {
+			return []string{fmt.Sprintf("folder %s: not a directory", fss.folderPath)}
+		}
+	}
+
+	// Reading without a client records no events for invalid files
+	offline := *fss
+	offline.client = nil
+	secrets, err := offline.desiredSecrets(ctx)
+	if err != nil && fss.folderPath == "" {
+		return []string{fmt.Sprintf("sources: %v", err)}
+	} else if err != nil {
+		return []string{fmt.Sprintf("folder %s: %v", fss.folderPath, err)}
+	}
+	return checkSecrets(secrets)
+}
+
+// offlineMappings returns a copy of fss for every mapping that a sync
+// would run: those of the configuration file at configFile, one per
+// namespace in per-namespace mode, or fss itself. Commands that inspect
+// the files without syncing use it to see what a sync would write.
+func (fss *FileSecretSync) offlineMappings(configFile string) ([]*FileSecretSync, error) {
+	var cfg *syncConfig
+	base := *fss
+	switch {
+	case configFile != "":
+		var err error
+		if cfg, err = loadConfigFile(configFile, fss.secretMode); err != nil {
+			return nil, err
+		}
+	case fss.secretMode == "per-namespace":
+		var err error
+		if cfg, err = newNamespaceFanOut(fss).mappings(fss.reader.FileSystem()); err != nil {
+			return nil, fmt.Errorf("folder %s: %w", fss.folderPath, err)
+		}
+		base.secretMode = "single"
+	default:
+		return []*FileSecretSync{fss}, nil
+	}
+
+	mappings := make([]*FileSecretSync, 0, len(cfg.Mappings))
+	for _, m := range cfg.Mappings {
+		mapping := forMapping(&base, m)
+		mappings = append(mappings, &mapping)
+	}
+	return mappings, nil
+}
+
+// validate checks everything a sync depends on and returns every problem
+// found, rather than stopping at the first.
+func (fss *FileSecretSync) validate(ctx context.Context, configFile string) []string {
+	mappings, err := fss.offlineMappings(configFile)
+	if err != nil {
+		return []string{fmt.Sprintf("configuration: %v", err)}
+	}
+
+	var problems []string
+	namespaces := make(map[string]bool)
+	for _, mapping := range mappings {
+		problems = append(problems, mapping.validateMapping(ctx)...)
+		namespaces[mapping.namespace] = true
+	}
+
+	// Other targets have no Kubernetes RBAC to check
+	if fss.target == nil {
+		sorted := make([]string, 0, len(namespaces))
+		for namespace := range namespaces {
+			sorted = append(sorted, namespace)
+		}
+		sort.Strings(sorted)
+		for _, namespace := range sorted {
+			problems = append(problems, fss.checkAccess(ctx, namespace)...)
+		}
+	}
+	return problems
+}
+
+// printProblems writes one line per problem to w.
+func printProblems(w io.Writer, problems []string) {
+	for _, problem := range problems {
+		fmt.Fprintln(w, problem)
+	}
+}
+
+// RunValidate implements the validate command. It checks the
+// configuration, the folders, the files and the permissions a sync needs
+// without writing anything, prints every problem found and returns the
+// exit code: 0 when nothing was found and 1 otherwise.
+func RunValidate() int {
+	fss, err := newFromEnvironment()
+	if err != nil {
+		printProblems(os.Stdout, []string{fmt.Sprintf("configuration: %v", err)})
+		return 1
+	}
+
+	problems := fss.validate(context.Background(), os.Getenv("CONFIG_FILE"))
+	if len(problems) > 0 {
+		printProblems(os.Stdout, problems)
+		return 1
+	}
+
+	log.Printf("Configuration is valid")
+	return 0
+}
@@ -0,0 +1,156 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestCheckSecrets(t *testing.T) {
+	tests := []struct {
+		name     string
+		secrets  map[string]map[string][]byte
+		expected []string
+	}{
+		{
+			name:    "valid",
+			secrets: map[string]map[string][]byte{"app": {"config.yaml": []byte("a: b")}},
+		},
+		{
+			name:     "invalid key",
+			secrets:  map[string]map[string][]byte{"app": {"bad key": []byte("x"), "good": []byte("y")}},
+			expected: []string{`secret app: invalid key "bad key"`},
+		},
+		{
+			name:     "invalid name",
+			secrets:  map[string]map[string][]byte{"App_1": {"key": []byte("x")}},
+			expected: []string{"secret App_1: invalid name"},
+		},
+		{
+			name:     "too large",
+			secrets:  map[string]map[string][]byte{"big": {"blob": make([]byte, maxSecretSize)}},
+			expected: []string{"secret big: 1048580 bytes of data exceed the limit of 1048576 bytes"},
+		},
+		{
+			name:    "exactly at the limit",
+			secrets: map[string]map[string][]byte{"big": {"blob": make([]byte, maxSecretSize-4)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := checkSecrets(tt.secrets)
+			if len(problems) != len(tt.expected) {
+				t.Fatalf("Expected %d problems, got %v", len(tt.expected), problems)
+			}
+			for i, prefix := range tt.expected {
+				if !strings.HasPrefix(problems[i], prefix) {
+					t.Errorf("Expected problem %q to start with %q", problems[i], prefix)
+				}
+			}
+		})
+	}
+}
+
+// allowAccess answers SelfSubjectAccessReviews, denying the permissions
+// in denied, keyed as "namespace/verb resource".
+func allowAccess(client *fake.Clientset, denied map[string]bool) {
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		attributes := review.Spec.ResourceAttributes
+		key := attributes.Namespace + "/" + attributes.Verb + " " + attributes.Resource
+		review.Status.Allowed = !denied[key]
+		return true, review, nil
+	})
+}
+
+func TestValidate(t *testing.T) {
+	tempDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tempDir, "app"), 0755)
+	os.MkdirAll(filepath.Join(tempDir, "db"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "app", "config.yaml"), []byte("test: value"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "db", "password"), make([]byte, maxSecretSize+1), 0644)
+
+	client := fake.NewSimpleClientset()
+	allowAccess(client, map[string]bool{"test-namespace/update secrets": true})
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		folderPath: tempDir,
+		secretMode: "per-directory",
+	}
+
+	problems := fss.validate(context.Background(), "")
+	expected := []string{
+		"secret db: 1048585 bytes of data exceed the limit of 1048576 bytes",
+		"namespace test-namespace: not allowed to update secrets",
+	}
+	if !reflect.DeepEqual(problems, expected) {
+		t.Errorf("Expected problems %v, got %v", expected, problems)
+	}
+
+	// Validating never writes
+	for _, action := range client.Actions() {
+		if action.GetResource().Resource != "selfsubjectaccessreviews" {
+			t.Errorf("Unexpected action %s %s", action.GetVerb(), action.GetResource().Resource)
+		}
+	}
+}
+
+func TestValidateConfigFile(t *testing.T) {
+	tempDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tempDir, "app"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "app", "config.yaml"), []byte("test: value"), 0644)
+	configFile := filepath.Join(tempDir, "config.yaml")
+	os.WriteFile(configFile, []byte(`mappings:
+  - folder: `+filepath.Join(tempDir, "app")+`
+    secret: app
+    namespace: apps
+  - folder: `+filepath.Join(tempDir, "missing")+`
+    secret: missing
+    namespace: other
+`), 0644)
+
+	client := fake.NewSimpleClientset()
+	allowAccess(client, map[string]bool{"other/create events": true})
+	fss := &FileSecretSync{
+		client:          client,
+		namespace:       "default",
+		secretMode:      "single",
+		createNamespace: true,
+	}
+
+	problems := fss.validate(context.Background(), configFile)
+	if len(problems) != 2 {
+		t.Fatalf("Expected 2 problems, got %v", problems)
+	}
+	if !strings.HasPrefix(problems[0], "folder "+filepath.Join(tempDir, "missing")+": ") {
+		t.Errorf("Expected the missing folder to be reported, got %q", problems[0])
+	}
+	if problems[1] != "namespace other: not allowed to create events" {
+		t.Errorf("Expected denied events in other, got %q", problems[1])
+	}
+
+	// Creating namespaces is checked cluster-wide
+	checked := 0
+	for _, action := range client.Actions() {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		if review.Spec.ResourceAttributes.Resource == "namespaces" {
+			checked++
+			if review.Spec.ResourceAttributes.Namespace != "" {
+				t.Errorf("Expected namespaces to be checked cluster-wide, got %q", review.Spec.ResourceAttributes.Namespace)
+			}
+		}
+	}
+	if checked != 2 {
+		t.Errorf("Expected creating namespaces to be checked for both namespaces, got %d", checked)
+	}
+}
@@ -0,0 +1,25 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"go-file-secret-sync/pkg/source"
+)
+
+// reportInvalidFile surfaces a file that failed validation while reading
+// the data of the named Secret, counting it and recording a Warning event.
+// Other errors are left alone.
+func (fss *FileSecretSync) reportInvalidFile(ctx context.Context, name string, err error) {
+	var invalid *source.ValidationError
+	if !errors.As(err, &invalid) {
+		return
+	}
+	fss.shared().metrics.invalidFiles.With(fss.metricLabels()).Inc()
+	// Other targets have no Kubernetes API to record events in
+	if fss.client != nil {
+		fss.recordEvent(ctx, name, corev1.EventTypeWarning, "InvalidFile", invalid.Error())
+	}
+}
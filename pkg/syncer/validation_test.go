@@ -0,0 +1,51 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"go-file-secret-sync/pkg/source"
+)
+
+func TestSyncFilesInvalidFile(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "config.json"), []byte(`{"host": `), 0644)
+	os.WriteFile(filepath.Join(tempDir, "password"), []byte("hunter2"), 0644)
+
+	rule, err := source.FormatRule([]string{"*.json"}, source.FormatJSON)
+	if err != nil {
+		t.Fatalf("FormatRule failed: %v", err)
+	}
+	client := fake.NewSimpleClientset()
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		folderPath: tempDir,
+		reader:     source.Reader{Validation: source.Validation{Rules: []source.ValidationRule{rule}}},
+	}
+
+	if err := fss.syncFiles(); err == nil {
+		t.Fatal("Expected the invalid file to fail the sync")
+	}
+
+	if _, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{}); err == nil {
+		t.Error("Expected no secret to be written")
+	}
+	if count := testutil.ToFloat64(fss.shared().metrics.invalidFiles.With(fss.metricLabels())); count != 1 {
+		t.Errorf("Expected 1 invalid file, got %v", count)
+	}
+	events, _ := client.CoreV1().Events("test-namespace").List(context.Background(), metav1.ListOptions{})
+	if len(events.Items) != 1 || events.Items[0].Reason != "InvalidFile" {
+		t.Fatalf("Expected an InvalidFile event, got %+v", events.Items)
+	}
+	if events.Items[0].InvolvedObject.Name != "test-secret" {
+		t.Errorf("Expected the event on test-secret, got %s", events.Items[0].InvolvedObject.Name)
+	}
+}
@@ -0,0 +1,135 @@
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// keyChange describes how a single key of a Secret differs from the files.
+type keyChange struct {
+	key    string
+	change string // "added", "removed" or "changed"
+}
+
+// secretDrift describes how a live Secret differs from the files.
+type secretDrift struct {
+	name    string
+	missing bool
+	live    map[string][]byte
+	desired map[string][]byte
+	changes []keyChange
+}
+
+// compareData returns the keys that would change when live is replaced by
+// desired, sorted by key.
+func compareData(live, desired map[string][]byte) []keyChange {
+	return digestData(live).changes(digestData(desired))
+}
+
+// detectDrift compares every Secret the files map to with the live Secret
+// in the cluster and returns those that differ, sorted by name.
+func (fss *FileSecretSync) detectDrift(ctx context.Context) ([]secretDrift, error) {
+	secrets, err := fss.desiredSecrets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var drifts []secretDrift
+	for _, name := range names {
+		desired := secrets[name]
+		secret, err := fss.client.CoreV1().Secrets(fss.namespace).Get(ctx, name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			drifts = append(drifts, secretDrift{name: name, missing: true, desired: desired, changes: compareData(nil, desired)})
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to get secret %s: %w", name, err)
+		}
+		if changes := compareData(secret.Data, desired); len(changes) > 0 {
+			drifts = append(drifts, secretDrift{name: name, live: secret.Data, desired: desired, changes: changes})
+		}
+	}
+	return drifts, nil
+}
+
+// detectMappingDrift runs detectDrift for every mapping a sync would run
+// with the configuration file at configFile, and returns the drifts of
+// all of them. Secrets outside the namespace of fss are named
+// namespace/name.
+func (fss *FileSecretSync) detectMappingDrift(ctx context.Context, configFile string) ([]secretDrift, error) {
+	mappings, err := fss.offlineMappings(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []secretDrift
+	for _, mapping := range mappings {
+		drifts, err := mapping.detectDrift(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for i := range drifts {
+			if mapping.namespace != fss.namespace {
+				drifts[i].name = mapping.namespace + "/" + drifts[i].name
+			}
+		}
+		all = append(all, drifts...)
+	}
+	return all, nil
+}
+
+// printDriftReport writes a key-level report of drifts to w. Values are
+// never printed.
+func printDriftReport(w io.Writer, drifts []secretDrift) {
+	for _, drift := range drifts {
+		if drift.missing {
+			fmt.Fprintf(w, "secret %s: missing (%d keys)\n", drift.name, len(drift.desired))
+			continue
+		}
+		fmt.Fprintf(w, "secret %s: %d keys differ\n", drift.name, len(drift.changes))
+		for _, change := range drift.changes {
+			fmt.Fprintf(w, "  %s (%s)\n", change.key, change.change)
+		}
+	}
+}
+
+// RunVerify implements the verify command. It compares the files against
+// the live Secrets using the same configuration as a sync, prints a report
+// of the keys that differ and returns the exit code: 0 when everything is
+// in sync and 1 on drift or failure.
+func RunVerify() int {
+	fss, err := newFromEnvironment()
+	if err != nil {
+		log.Printf("Invalid configuration: %v", err)
+		return 1
+	}
+	if fss.target != nil {
+		log.Printf("verify only supports the kubernetes target")
+		return 1
+	}
+
+	drifts, err := fss.detectMappingDrift(context.Background(), os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		log.Printf("Verify failed: %v", err)
+		return 1
+	}
+	if len(drifts) > 0 {
+		printDriftReport(os.Stdout, drifts)
+		return 1
+	}
+
+	log.Printf("All secrets are in sync")
+	return 0
+}
@@ -0,0 +1,107 @@
+package syncer
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDetectDrift(t *testing.T) {
+	tempDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tempDir, "app"), 0755)
+	os.MkdirAll(filepath.Join(tempDir, "db"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "app", "config.yaml"), []byte("test: updated"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "app", "new.txt"), []byte("new"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "db", "password"), []byte("s3cr3t"), 0644)
+
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test-namespace"},
+		Data: map[string][]byte{
+			"config.yaml": []byte("test: value"),
+			"old.txt":     []byte("old"),
+		},
+	})
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		folderPath: tempDir,
+		secretMode: "per-directory",
+	}
+
+	drifts, err := fss.detectDrift(context.Background())
+	if err != nil {
+		t.Fatalf("detectDrift failed: %v", err)
+	}
+
+	var report bytes.Buffer
+	printDriftReport(&report, drifts)
+	expected := `secret app: 3 keys differ
+  config.yaml (changed)
+  new.txt (added)
+  old.txt (removed)
+secret db: missing (1 keys)
+`
+	if report.String() != expected {
+		t.Errorf("Expected report:\n%s\ngot:\n%s", expected, report.String())
+	}
+	if bytes.Contains(report.Bytes(), []byte("s3cr3t")) || bytes.Contains(report.Bytes(), []byte("updated")) {
+		t.Error("Report must not contain secret values")
+	}
+
+	// After a sync there is no drift left
+	if err := fss.syncFiles(); err != nil {
+		t.Fatalf("syncFiles failed: %v", err)
+	}
+	drifts, err = fss.detectDrift(context.Background())
+	if err != nil {
+		t.Fatalf("detectDrift failed: %v", err)
+	}
+	if len(drifts) != 0 {
+		t.Errorf("Expected no drift after sync, got %+v", drifts)
+	}
+}
+
+func TestDetectMappingDriftConfigFile(t *testing.T) {
+	tempDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tempDir, "app"), 0755)
+	os.MkdirAll(filepath.Join(tempDir, "db"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "app", "config.yaml"), []byte("test: value"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "db", "password"), []byte("s3cr3t"), 0644)
+	configFile := filepath.Join(tempDir, "config.yaml")
+	os.WriteFile(configFile, []byte(`mappings:
+  - folder: `+filepath.Join(tempDir, "app")+`
+    secret: app
+  - folder: `+filepath.Join(tempDir, "db")+`
+    secret: db
+    namespace: database
+`), 0644)
+
+	// Only the Secret of the first mapping exists
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test-namespace"},
+		Data:       map[string][]byte{"config.yaml": []byte("test: value")},
+	})
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		secretMode: "single",
+	}
+
+	drifts, err := fss.detectMappingDrift(context.Background(), configFile)
+	if err != nil {
+		t.Fatalf("detectMappingDrift failed: %v", err)
+	}
+
+	var report bytes.Buffer
+	printDriftReport(&report, drifts)
+	expected := "secret database/db: missing (1 keys)\n"
+	if report.String() != expected {
+		t.Errorf("Expected report:\n%s\ngot:\n%s", expected, report.String())
+	}
+}
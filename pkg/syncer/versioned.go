@@ -0,0 +1,90 @@
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// versionOfAnnotation records the base name a hash-suffixed Secret was
+// derived from.
+const versionOfAnnotation = "file-secret-sync/version-of"
+
+// versionHashLength is the number of hex digits of the content checksum
+// appended to versioned Secret names, the same length kustomize uses.
+const versionHashLength = 10
+
+// versionedName returns name suffixed with a hash of data, kustomize-style.
+func versionedName(name string, data map[string][]byte) string {
+	hash := strings.TrimPrefix(dataChecksum(data), "sha256:")
+	return name + "-" + hash[:versionHashLength]
+}
+
+// versionSecrets renames every Secret to its hash-suffixed name and
+// remembers the base names for annotating and pointing at them.
+func (fss *FileSecretSync) versionSecrets(secrets map[string]map[string][]byte) map[string]map[string][]byte {
+	versioned := make(map[string]map[string][]byte, len(secrets))
+	fss.versionBases = make(map[string]string, len(secrets))
+	for name, data := range secrets {
+		vname := versionedName(name, data)
+		if fss.versionHash == "kustomize" {
+			vname = name + "-" + kustomizeHash(name, fss.secretType(name), data)
+		}
+		versioned[vname] = data
+		fss.versionBases[vname] = name
+	}
+	return versioned
+}
+
+// writePointer writes the Secret base holding the name of its current
+// hash-suffixed version under the key "name".
+func (fss *FileSecretSync) writePointer(ctx context.Context, base, current string) error {
+	return fss.writeSecret(ctx, base, map[string][]byte{"name": []byte(current)})
+}
+
+// pruneVersions deletes all but the newest versionRetain versions of base,
+// always keeping current, so old versions remain available for rollbacks
+// without growing without bound.
+func (fss *FileSecretSync) pruneVersions(ctx context.Context, base, current string) error {
+	list, err := fss.client.CoreV1().Secrets(fss.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app.kubernetes.io/managed-by=file-secret-sync",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list versions of secret %s: %w", base, err)
+	}
+
+	var older []metav1.ObjectMeta
+	for _, secret := range list.Items {
+		if secret.Annotations[versionOfAnnotation] == base && secret.Name != current {
+			older = append(older, secret.ObjectMeta)
+		}
+	}
+
+	// Newest first; the current version takes one of the retained slots
+	sort.Slice(older, func(i, j int) bool {
+		if !older[i].CreationTimestamp.Equal(&older[j].CreationTimestamp) {
+			return older[j].CreationTimestamp.Before(&older[i].CreationTimestamp)
+		}
+		return older[i].Name > older[j].Name
+	})
+	if len(older) < fss.versionRetain {
+		return nil
+	}
+
+	for _, meta := range older[fss.versionRetain-1:] {
+		if err := fss.waitForWrite(ctx); err != nil {
+			return err
+		}
+		preconditions := metav1.Preconditions{UID: &meta.UID}
+		err := fss.client.CoreV1().Secrets(fss.namespace).Delete(ctx, meta.Name, metav1.DeleteOptions{Preconditions: &preconditions})
+		if err != nil {
+			return fmt.Errorf("failed to delete old version %s: %w", meta.Name, err)
+		}
+		log.Printf("Deleted old version %s of secret %s", meta.Name, base)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestVersionedName(t *testing.T) {
+	data := map[string][]byte{"config.yaml": []byte("test: value")}
+	name := versionedName("test-secret", data)
+
+	if !strings.HasPrefix(name, "test-secret-") || len(name) != len("test-secret-")+versionHashLength {
+		t.Errorf("Unexpected versioned name %q", name)
+	}
+	if versionedName("test-secret", data) != name {
+		t.Error("Expected versioned name to be deterministic")
+	}
+	if versionedName("test-secret", map[string][]byte{"config.yaml": []byte("test: updated")}) == name {
+		t.Error("Expected versioned name to change with the content")
+	}
+}
+
+func TestSyncFilesVersionedNames(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("test: value"), 0644)
+
+	client := fake.NewSimpleClientset()
+	fss := &FileSecretSync{
+		client:         client,
+		namespace:      "test-namespace",
+		secretName:     "test-secret",
+		folderPath:     tempDir,
+		versionedNames: true,
+		versionPointer: true,
+	}
+
+	ctx := context.Background()
+	var versions []string
+	for _, content := range []string{"test: value", "test: updated"} {
+		os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte(content), 0644)
+		if err := fss.syncFiles(); err != nil {
+			t.Fatalf("syncFiles failed: %v", err)
+		}
+
+		pointer, err := client.CoreV1().Secrets("test-namespace").Get(ctx, "test-secret", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get pointer secret: %v", err)
+		}
+		current := string(pointer.Data["name"])
+
+		secret, err := client.CoreV1().Secrets("test-namespace").Get(ctx, current, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get versioned secret %q: %v", current, err)
+		}
+		if string(secret.Data["config.yaml"]) != content {
+			t.Errorf("Expected %q in %s, got %q", content, current, secret.Data["config.yaml"])
+		}
+		if secret.Annotations[versionOfAnnotation] != "test-secret" {
+			t.Errorf("Expected version-of annotation, got %v", secret.Annotations)
+		}
+		versions = append(versions, current)
+	}
+
+	if versions[0] == versions[1] {
+		t.Error("Expected a new versioned secret after the content changed")
+	}
+	if _, err := client.CoreV1().Secrets("test-namespace").Get(ctx, versions[0], metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected previous version to be kept: %v", err)
+	}
+}
+
+func TestPruneVersions(t *testing.T) {
+	oldVersion := func(name string, age time.Duration) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "test-namespace",
+			Labels:            map[string]string{"app.kubernetes.io/managed-by": "file-secret-sync"},
+			Annotations:       map[string]string{versionOfAnnotation: "test-secret"},
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+		}}
+	}
+	other := oldVersion("other-secret-0000000000", 4*time.Hour)
+	other.Annotations[versionOfAnnotation] = "other-secret"
+
+	client := fake.NewSimpleClientset(
+		oldVersion("test-secret-1111111111", 3*time.Hour),
+		oldVersion("test-secret-2222222222", 2*time.Hour),
+		oldVersion("test-secret-3333333333", time.Hour),
+		oldVersion("test-secret-current000", 0),
+		other,
+	)
+	fss := &FileSecretSync{client: client, namespace: "test-namespace", versionRetain: 2}
+
+	if err := fss.pruneVersions(context.Background(), "test-secret", "test-secret-current000"); err != nil {
+		t.Fatalf("pruneVersions failed: %v", err)
+	}
+
+	list, err := client.CoreV1().Secrets("test-namespace").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("Failed to list secrets: %v", err)
+	}
+	var remaining []string
+	for _, secret := range list.Items {
+		remaining = append(remaining, secret.Name)
+	}
+	expected := []string{"other-secret-0000000000", "test-secret-3333333333", "test-secret-current000"}
+	if strings.Join(remaining, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected remaining secrets %v, got %v", expected, remaining)
+	}
+}
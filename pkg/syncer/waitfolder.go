@@ -0,0 +1,48 @@
+package syncer
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"time"
+)
+
+// folderCheckInterval is how often waitForFolder checks for the folder.
+const folderCheckInterval = 500 * time.Millisecond
+
+// waitForFolder waits up to folderWait for the folder to appear, for
+// volumes that are mounted late or folders created by another container.
+func (fss *FileSecretSync) waitForFolder() error {
+	if fss.folderPath == "" || fss.folderWait <= 0 {
+		return nil
+	}
+
+	deadline := time.Now().Add(fss.folderWait)
+	logged := false
+	for {
+		info, err := fs.Stat(fss.reader.FileSystem(), fss.folderPath)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", fss.folderPath)
+			}
+			if logged {
+				log.Printf("Folder %s appeared", fss.folderPath)
+			}
+			return nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to check %s: %w", fss.folderPath, err)
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return fmt.Errorf("%s did not appear within %s", fss.folderPath, fss.folderWait)
+		}
+		if !logged {
+			log.Printf("Waiting up to %s for %s to appear", fss.folderWait, fss.folderPath)
+			logged = true
+		}
+		time.Sleep(min(folderCheckInterval, remaining))
+	}
+}
@@ -0,0 +1,40 @@
+package syncer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWaitForFolder(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "file"), []byte("x"), 0644)
+
+	testCases := []struct {
+		name      string
+		folder    string
+		wait      time.Duration
+		createdIn time.Duration
+		expectErr bool
+	}{
+		{"existing folder", tempDir, time.Second, 0, false},
+		{"no wait configured", filepath.Join(tempDir, "missing"), 0, 0, false},
+		{"appears in time", filepath.Join(tempDir, "late"), 5 * time.Second, 100 * time.Millisecond, false},
+		{"never appears", filepath.Join(tempDir, "never"), 200 * time.Millisecond, 0, true},
+		{"not a directory", filepath.Join(tempDir, "file"), time.Second, 0, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.createdIn > 0 {
+				time.AfterFunc(tc.createdIn, func() { os.Mkdir(tc.folder, 0755) })
+			}
+			fss := &FileSecretSync{folderPath: tc.folder, folderWait: tc.wait}
+			err := fss.waitForFolder()
+			if (err != nil) != tc.expectErr {
+				t.Errorf("waitForFolder() error = %v, expected error: %v", err, tc.expectErr)
+			}
+		})
+	}
+}
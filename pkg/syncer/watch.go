@@ -0,0 +1,114 @@
+package syncer
+
+import (
+	"log"
+	"time"
+
+	"go-file-secret-sync/pkg/clock"
+	"go-file-secret-sync/pkg/redact"
+	"go-file-secret-sync/pkg/watch"
+)
+
+// maxHealBackoff caps the delay between attempts to re-create the watcher.
+const maxHealBackoff = 1 * time.Minute
+
+// newTree creates the watcher of the folder, polling trees that cannot be
+// watched for the files that are synced. Folders on a filesystem other
+// than the operating system's are always polled.
+func (fss *FileSecretSync) newTree() error {
+	if fss.reader.FS != nil {
+		fss.tree = watch.NewPolled(fss.folderPath, fss.reader.FileStates)
+	} else {
+		tree, err := watch.New(fss.folderPath, fss.reader.FileStates)
+		if err != nil {
+			return err
+		}
+		fss.tree = tree
+	}
+
+	// Directories below the depth limit are neither synced nor watched.
+	// In per-directory mode the limit applies below each subdirectory.
+	fss.tree.MaxDepth = fss.reader.Filter.MaxDepth
+	if fss.secretMode == "per-directory" && fss.tree.MaxDepth > 0 {
+		fss.tree.MaxDepth++
+	}
+	return nil
+}
+
+// addWatches adds the folder and all of its subdirectories to the watcher.
+func (fss *FileSecretSync) addWatches() error {
+	err := fss.tree.Add()
+	fss.observePolling()
+	return err
+}
+
+// observePolling updates the number of polled directories after watches
+// were added or removed.
+func (fss *FileSecretSync) observePolling() {
+	fss.shared().metrics.polledDirectories.With(fss.metricLabels()).Set(float64(fss.tree.Polled()))
+}
+
+// rescan recovers from lost events by watching the whole folder again,
+// which picks up directories created while events were lost, and forcing
+// a full sync.
+func (fss *FileSecretSync) rescan() {
+	fss.shared().metrics.watcherOverflows.With(fss.metricLabels()).Inc()
+	if err := fss.addWatches(); err != nil {
+		log.Printf("Failed to re-add watches after overflow: %v", redact.Error(err))
+	}
+	fss.requestSync()
+}
+
+// stopped reports whether monitoring was asked to stop, or the process is
+// shutting down.
+func (fss *FileSecretSync) stopped() bool {
+	select {
+	case <-fss.stop:
+		return true
+	case <-fss.rootContext().Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// timers returns the clock of the monitoring loop.
+func (fss *FileSecretSync) timers() clock.Clock {
+	if fss.clock == nil {
+		return clock.Real
+	}
+	return fss.clock
+}
+
+// stopMonitoring makes startMonitoring close the watcher and return.
+func (fss *FileSecretSync) stopMonitoring() {
+	close(fss.stop)
+}
+
+// healWatcher replaces a broken watcher with a new one watching the whole
+// folder again, retrying with backoff until it succeeds or monitoring is
+// stopped. Events may have been lost, so it forces a full sync afterwards.
+// It reports whether monitoring should continue.
+func (fss *FileSecretSync) healWatcher() bool {
+	backoff := time.Second
+	for !fss.stopped() {
+		err := fss.tree.Recreate()
+		fss.observePolling()
+		if err == nil {
+			log.Printf("Re-created file watcher for %s", redact.Name(fss.folderPath))
+			fss.requestSync()
+			return true
+		}
+
+		log.Printf("Failed to re-create file watcher, retrying in %s: %v", backoff, redact.Error(err))
+		timer := fss.timers().NewTimer(backoff)
+		select {
+		case <-fss.stop:
+		case <-fss.rootContext().Done():
+		case <-timer.C():
+		}
+		timer.Stop()
+		backoff = min(backoff*2, maxHealBackoff)
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-file-secret-sync/pkg/source"
+	"go-file-secret-sync/pkg/watch"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestTree(t *testing.T, folder string) *watch.Tree {
+	t.Helper()
+
+	tree, err := watch.New(folder, (&source.Reader{}).FileStates)
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %v", err)
+	}
+	t.Cleanup(func() { tree.Close() })
+	return tree
+}
+
+func TestStartMonitoringHealsWatcher(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("v1"), 0644)
+
+	client := fake.NewSimpleClientset()
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		folderPath: tempDir,
+		tree:       newTestTree(t, tempDir),
+		stop:       make(chan struct{}),
+		debounce:   50 * time.Millisecond,
+	}
+
+	done := make(chan error)
+	go func() { done <- fss.startMonitoring() }()
+
+	waitForData := func(expected string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			secret, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{})
+			if err == nil && string(secret.Data["config.yaml"]) == expected {
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatalf("Timed out waiting for config.yaml=%q", expected)
+	}
+
+	// Breaking the watcher re-creates it and forces a full sync
+	time.Sleep(50 * time.Millisecond)
+	fss.tree.Close()
+	waitForData("v1")
+
+	// The new watcher picks up further changes
+	os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("v2"), 0644)
+	waitForData("v2")
+
+	fss.stopMonitoring()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("startMonitoring returned error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("startMonitoring did not return after stopMonitoring")
+	}
+}
+
+func TestRescanAfterOverflow(t *testing.T) {
+	tempDir := t.TempDir()
+	client := fake.NewSimpleClientset()
+	fss := &FileSecretSync{
+		client:     client,
+		namespace:  "test-namespace",
+		secretName: "test-secret",
+		folderPath: tempDir,
+		tree:       newTestTree(t, tempDir),
+	}
+	if err := fss.addWatches(); err != nil {
+		t.Fatalf("addWatches failed: %v", err)
+	}
+
+	// A directory and file created while events were lost
+	os.MkdirAll(filepath.Join(tempDir, "nested"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "nested", "config.yaml"), []byte("v1"), 0644)
+
+	before := testutil.ToFloat64(fss.shared().metrics.watcherOverflows.With(fss.metricLabels()))
+	fss.rescan()
+
+	if testutil.ToFloat64(fss.shared().metrics.watcherOverflows.With(fss.metricLabels())) != before+1 {
+		t.Error("Expected overflow counter to be incremented")
+	}
+	secret, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{})
+	if err != nil || string(secret.Data["nested.config.yaml"]) != "v1" {
+		t.Errorf("Expected rescan to sync missed files, got %v, %v", secret, err)
+	}
+	watched := false
+	for _, path := range fss.tree.WatchList() {
+		if path == filepath.Join(tempDir, "nested") {
+			watched = true
+		}
+	}
+	if !watched {
+		t.Error("Expected rescan to watch the new directory")
+	}
+}
+
+func TestNewTreeMaxDepth(t *testing.T) {
+	testCases := []struct {
+		secretMode string
+		maxDepth   int
+		expected   int
+	}{
+		{"single", 0, 0},
+		{"single", 1, 1},
+		{"per-directory", 0, 0},
+		{"per-directory", 1, 2},
+	}
+
+	for _, tc := range testCases {
+		fss := &FileSecretSync{
+			folderPath: t.TempDir(),
+			secretMode: tc.secretMode,
+			reader:     source.Reader{Filter: source.Filter{MaxDepth: tc.maxDepth}},
+		}
+		if err := fss.newTree(); err != nil {
+			t.Fatalf("newTree failed: %v", err)
+		}
+		fss.tree.Close()
+		if fss.tree.MaxDepth != tc.expected {
+			t.Errorf("%s with depth %d: expected the tree depth %d, got %d", tc.secretMode, tc.maxDepth, tc.expected, fss.tree.MaxDepth)
+		}
+	}
+}
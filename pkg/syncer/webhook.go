@@ -0,0 +1,100 @@
+package syncer
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// webhookSignatureHeader carries the HMAC-SHA256 signature of the body,
+// in the format used by GitHub webhooks.
+const webhookSignatureHeader = "X-Signature-256"
+
+// webhookNotifier POSTs notifications as JSON to a URL.
+type webhookNotifier struct {
+	url        string
+	secret     []byte
+	httpClient *http.Client
+}
+
+// newWebhookNotifiers creates a notifier for every URL in WEBHOOK_URLS,
+// signing with WEBHOOK_SECRET when set.
+func newWebhookNotifiers() ([]notifier, error) {
+	urls := os.Getenv("WEBHOOK_URLS")
+	if urls == "" {
+		return nil, nil
+	}
+
+	httpClient, err := newHTTPClient(os.Getenv("WEBHOOK_CACERT"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure WEBHOOK_CACERT: %w", err)
+	}
+
+	var notifiers []notifier
+	for _, url := range strings.Split(urls, ",") {
+		url = strings.TrimSpace(url)
+		if url == "" {
+			continue
+		}
+		notifiers = append(notifiers, &webhookNotifier{
+			url:        url,
+			secret:     []byte(os.Getenv("WEBHOOK_SECRET")),
+			httpClient: httpClient,
+		})
+	}
+	return notifiers, nil
+}
+
+func (w *webhookNotifier) String() string {
+	return w.url
+}
+
+func (w *webhookNotifier) notify(ctx context.Context, n syncNotification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	header := http.Header{}
+	if len(w.secret) > 0 {
+		header.Set(webhookSignatureHeader, signPayload(w.secret, body))
+	}
+	return postJSON(ctx, w.httpClient, w.url, body, header)
+}
+
+// postJSON POSTs the JSON body to url, failing on responses other than
+// 2xx.
+func postJSON(ctx context.Context, httpClient *http.Client, url string, body []byte, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// signPayload returns the "sha256=<hex>" HMAC signature of body.
+func signPayload(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,55 @@
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	var received syncNotification
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		signature = r.Header.Get(webhookSignatureHeader)
+		if signature != signPayload([]byte("hook-secret"), body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.Unmarshal(body, &received)
+	}))
+	defer server.Close()
+
+	t.Setenv("WEBHOOK_URLS", server.URL+", ")
+	t.Setenv("WEBHOOK_SECRET", "hook-secret")
+	notifiers, err := newWebhookNotifiers()
+	if err != nil {
+		t.Fatalf("newWebhookNotifiers failed: %v", err)
+	}
+	if len(notifiers) != 1 {
+		t.Fatalf("Expected one notifier, got %d", len(notifiers))
+	}
+
+	n := syncNotification{
+		Result:      "success",
+		Namespace:   "test-namespace",
+		Secret:      "test-secret",
+		Checksum:    "sha256:abc",
+		ChangedKeys: []notifiedChange{{"test-secret", "ca.pem", "changed"}},
+	}
+	if err := notifiers[0].notify(context.Background(), n); err != nil {
+		t.Fatalf("notify failed: %v", err)
+	}
+	if received.Secret != "test-secret" || received.Checksum != "sha256:abc" || len(received.ChangedKeys) != 1 {
+		t.Errorf("Unexpected payload: %+v", received)
+	}
+
+	// A receiver rejecting the signature fails the delivery
+	wrong := &webhookNotifier{url: server.URL, secret: []byte("other-secret"), httpClient: server.Client()}
+	if err := wrong.notify(context.Background(), n); err == nil {
+		t.Error("Expected notify to fail on a non-2xx response")
+	}
+}
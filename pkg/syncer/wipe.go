@@ -0,0 +1,42 @@
+package syncer
+
+import (
+	"go-file-secret-sync/pkg/source"
+)
+
+// Syncs zero the byte slices holding file contents and Secret values once
+// they are done with them, so plaintext credentials do not linger in
+// memory until the garbage collector reuses it, and are less likely to end
+// up in core dumps. Strings made from values, as for STRING_DATA, cannot
+// be zeroed.
+
+// wipe zeroes every value of data.
+func wipe(data map[string][]byte) {
+	for _, value := range data {
+		clear(value)
+	}
+}
+
+// wipeFiles zeroes the content of every file.
+func wipeFiles(files map[string]source.File) {
+	for _, file := range files {
+		clear(file.Content)
+	}
+}
+
+// keepForWipe records files read by the current sync, so they are zeroed
+// when it ends even if it fails before writing them.
+func (fss *FileSecretSync) keepForWipe(files map[string]source.File) {
+	fss.readFiles = append(fss.readFiles, files)
+}
+
+// wipeSync zeroes the data written by a sync and every file it read.
+func (fss *FileSecretSync) wipeSync(secrets map[string]map[string][]byte) {
+	for _, data := range secrets {
+		wipe(data)
+	}
+	for _, files := range fss.readFiles {
+		wipeFiles(files)
+	}
+	fss.readFiles = nil
+}
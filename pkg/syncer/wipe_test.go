@@ -0,0 +1,91 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// capturingTarget keeps the data it is given without copying it.
+type capturingTarget struct {
+	data []map[string][]byte
+}
+
+func (c *capturingTarget) WriteSecret(ctx context.Context, namespace, name string, data map[string][]byte) error {
+	c.data = append(c.data, data)
+	return nil
+}
+
+func TestSyncFilesWipesData(t *testing.T) {
+	testCases := []struct {
+		name       string
+		dataFormat string
+	}{
+		{"files", "files"},
+		{"json", "json"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			os.WriteFile(filepath.Join(tempDir, "password"), []byte("hunter2"), 0644)
+
+			target := &capturingTarget{}
+			fss := &FileSecretSync{
+				target:     target,
+				namespace:  "test-namespace",
+				secretName: "test-secret",
+				folderPath: tempDir,
+				dataFormat: tc.dataFormat,
+				dataKey:    "data.json",
+			}
+			if err := fss.syncFiles(); err != nil {
+				t.Fatalf("syncFiles failed: %v", err)
+			}
+
+			if len(target.data) != 1 {
+				t.Fatalf("Expected one write, got %d", len(target.data))
+			}
+			for key, value := range target.data[0] {
+				for _, b := range value {
+					if b != 0 {
+						t.Errorf("Expected key %s to be zeroed after the sync, got %q", key, value)
+						break
+					}
+				}
+			}
+			if fss.readFiles != nil {
+				t.Errorf("Expected no files kept after the sync, got %d", len(fss.readFiles))
+			}
+		})
+	}
+}
+
+func TestSyncFilesWipeKeepsSecret(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "password"), []byte("v1"), 0644)
+
+	client := fake.NewSimpleClientset()
+	fss := &FileSecretSync{client: client, namespace: "test-namespace", secretName: "test-secret", folderPath: tempDir}
+	for _, content := range []string{"v2", ""} {
+		if err := fss.syncFiles(); err != nil {
+			t.Fatalf("syncFiles failed: %v", err)
+		}
+		if content != "" {
+			os.WriteFile(filepath.Join(tempDir, "password"), []byte(content), 0644)
+		}
+	}
+
+	// Zeroing happens after the write, so the Secret holds the content
+	secret, err := client.CoreV1().Secrets("test-namespace").Get(context.Background(), "test-secret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if string(secret.Data["password"]) != "v2" {
+		t.Errorf("Expected %q, got %q", "v2", secret.Data["password"])
+	}
+}
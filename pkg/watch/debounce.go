@@ -0,0 +1,28 @@
+package watch
+
+import "time"
+
+// DefaultDebounce is the quiet period used when none is configured.
+const DefaultDebounce = 1 * time.Second
+
+// DebounceDelay returns how long to wait before syncing, given that events
+// have been arriving since pendingSince. Every event restarts the quiet
+// period, but never beyond maxWait after the first event, so a steady
+// stream of writes cannot postpone the sync indefinitely. A quiet period
+// of 0 uses DefaultDebounce, a maxWait of 0 does not limit the wait.
+func DebounceDelay(quiet, maxWait time.Duration, pendingSince, now time.Time) time.Duration {
+	delay := quiet
+	if delay <= 0 {
+		delay = DefaultDebounce
+	}
+	if maxWait > 0 {
+		remaining := maxWait - now.Sub(pendingSince)
+		if remaining < 0 {
+			remaining = 0
+		}
+		if remaining < delay {
+			delay = remaining
+		}
+	}
+	return delay
+}
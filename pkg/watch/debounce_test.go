@@ -0,0 +1,32 @@
+package watch
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDebounceDelay(t *testing.T) {
+	start := time.Now()
+
+	testCases := []struct {
+		name     string
+		debounce time.Duration
+		maxWait  time.Duration
+		elapsed  time.Duration
+		expected time.Duration
+	}{
+		{"default quiet period", 0, 0, 0, DefaultDebounce},
+		{"configured quiet period", 3 * time.Second, 0, time.Minute, 3 * time.Second},
+		{"well before max wait", 2 * time.Second, 10 * time.Second, time.Second, 2 * time.Second},
+		{"capped by max wait", 2 * time.Second, 10 * time.Second, 9 * time.Second, time.Second},
+		{"past max wait", 2 * time.Second, 10 * time.Second, 15 * time.Second, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := DebounceDelay(tc.debounce, tc.maxWait, start, start.Add(tc.elapsed)); got != tc.expected {
+				t.Errorf("DebounceDelay() = %v, expected %v", got, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+// Package watch watches a folder tree for changes with fsnotify, falling
+// back to polling directories that cannot be watched, and debounces the
+// resulting events.
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"maps"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/fsnotify/fsnotify"
+
+	"go-file-secret-sync/pkg/redact"
+	"go-file-secret-sync/pkg/source"
+)
+
+// StatesFunc returns the state of every file below root that should be
+// polled for changes, keyed by path.
+type StatesFunc func(root string) (map[string]source.FileState, error)
+
+// Tree watches a folder and every directory below it. Directories that
+// cannot be watched because the inotify watch limit was reached are
+// polled instead, using the file states returned by states.
+type Tree struct {
+	// Levels of directories watched, 1 for only root itself; 0 watches
+	// all of them
+	MaxDepth int
+
+	root    string
+	states  StatesFunc
+	watcher *fsnotify.Watcher
+	polled  map[string]map[string]source.FileState
+}
+
+// New creates a Tree for root. Nothing is watched until Add is called.
+func New(root string, states StatesFunc) (*Tree, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	return &Tree{root: root, states: states, watcher: watcher}, nil
+}
+
+// NewPolled creates a Tree that polls root instead of watching it, for
+// folders on filesystems fsnotify cannot watch, like an in-memory fs.FS.
+// Its Events and Errors never deliver anything.
+func NewPolled(root string, states StatesFunc) *Tree {
+	return &Tree{root: root, states: states}
+}
+
+// Events returns the events of the current watcher. It changes when the
+// watcher is re-created, so it must be called again after Recreate.
+func (t *Tree) Events() <-chan fsnotify.Event {
+	if t.watcher == nil {
+		return nil
+	}
+	return t.watcher.Events
+}
+
+// Errors returns the errors of the current watcher, like Events.
+func (t *Tree) Errors() <-chan error {
+	if t.watcher == nil {
+		return nil
+	}
+	return t.watcher.Errors
+}
+
+// WatchList returns the directories that are watched.
+func (t *Tree) WatchList() []string {
+	if t.watcher == nil {
+		return nil
+	}
+	return t.watcher.WatchList()
+}
+
+// Polled returns how many directory trees are polled instead of watched.
+func (t *Tree) Polled() int {
+	return len(t.polled)
+}
+
+// Add watches the whole folder, starting over with the directories that
+// are polled.
+func (t *Tree) Add() error {
+	t.polled = nil
+	if t.watcher == nil {
+		t.poll(t.root)
+		return nil
+	}
+	return t.AddDir(t.root)
+}
+
+// AddDir watches dir and all directories below it, e.g. after they were
+// created or renamed into the folder. Trees that cannot be watched because
+// of the inotify watch limit are polled.
+func (t *Tree) AddDir(dir string) error {
+	if t.watcher == nil {
+		return nil
+	}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if !t.descends(path) {
+			return filepath.SkipDir
+		}
+		if err := t.watcher.Add(path); err != nil {
+			if !IsWatchLimit(err) {
+				return err
+			}
+			t.poll(path)
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add %s to watcher: %w", dir, err)
+	}
+	return nil
+}
+
+// descends reports whether the directory at path is within MaxDepth of
+// the root. Directories outside of the root are always watched.
+func (t *Tree) descends(path string) bool {
+	if t.MaxDepth <= 0 || !isBelow(path, t.root) || path == t.root {
+		return true
+	}
+	rel, err := filepath.Rel(t.root, path)
+	if err != nil {
+		return true
+	}
+	return strings.Count(filepath.ToSlash(rel), "/")+1 < t.MaxDepth
+}
+
+// RemoveDir stops watching or polling dir and all directories below it,
+// after they were deleted or renamed away.
+func (t *Tree) RemoveDir(dir string) {
+	for _, path := range t.WatchList() {
+		if isBelow(path, dir) {
+			// Deleted directories may already have lost their watch
+			t.watcher.Remove(path)
+			log.Printf("Removed watch for %s", redact.Name(path))
+		}
+	}
+	for path := range t.polled {
+		if isBelow(path, dir) {
+			delete(t.polled, path)
+		}
+	}
+}
+
+// Recreate replaces the watcher with a new one watching the whole folder,
+// after the old one broke or lost events.
+func (t *Tree) Recreate() error {
+	if t.watcher == nil {
+		return t.Add()
+	}
+	t.watcher.Close()
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	t.watcher = watcher
+	if err := t.Add(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Close stops watching.
+func (t *Tree) Close() error {
+	if t.watcher == nil {
+		return nil
+	}
+	return t.watcher.Close()
+}
+
+// PollChanged reports whether any file in a polled tree changed since the
+// last call.
+func (t *Tree) PollChanged() bool {
+	changed := false
+	for root, previous := range t.polled {
+		current, err := t.states(root)
+		if err != nil {
+			log.Printf("Failed to poll %s: %v", redact.Name(root), redact.Error(err))
+			continue
+		}
+		if !maps.Equal(previous, current) {
+			changed = true
+		}
+		t.polled[root] = current
+	}
+	return changed
+}
+
+// poll falls back to polling dir and everything below it.
+func (t *Tree) poll(dir string) {
+	if _, ok := t.polled[dir]; ok {
+		return
+	}
+	states, err := t.states(dir)
+	if err != nil {
+		log.Printf("Failed to read %s for polling: %v", redact.Name(dir), redact.Error(err))
+	}
+	if t.polled == nil {
+		t.polled = make(map[string]map[string]source.FileState)
+	}
+	t.polled[dir] = states
+	if t.watcher == nil {
+		log.Printf("Polling %s for changes", redact.Name(dir))
+		return
+	}
+	log.Printf("Reached the inotify watch limit, polling %s instead; raise the fs.inotify.max_user_watches sysctl to watch it", redact.Name(dir))
+}
+
+// isBelow reports whether path is dir or inside it.
+func isBelow(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// IsOverflow reports whether a watcher error means that events were lost
+// because the kernel's event queue overflowed.
+func IsOverflow(err error) bool {
+	return errors.Is(err, fsnotify.ErrEventOverflow)
+}
+
+// IsWatchLimit reports whether adding a watch failed because the user's
+// inotify watch limit was reached.
+func IsWatchLimit(err error) bool {
+	return errors.Is(err, syscall.ENOSPC)
+}
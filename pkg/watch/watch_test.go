@@ -0,0 +1,208 @@
+package watch
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"testing/fstest"
+
+	"go-file-secret-sync/pkg/redact"
+	"go-file-secret-sync/pkg/source"
+)
+
+func newTestTree(t *testing.T, root string) *Tree {
+	t.Helper()
+
+	reader := &source.Reader{}
+	tree, err := New(root, reader.FileStates)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	t.Cleanup(func() { tree.Close() })
+	return tree
+}
+
+func TestAddDirFollowsRenames(t *testing.T) {
+	tempDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tempDir, "old", "nested"), 0755)
+
+	tree := newTestTree(t, tempDir)
+	if err := tree.Add(); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	// Rename the tree and handle it like the monitor loop does
+	os.Rename(filepath.Join(tempDir, "old"), filepath.Join(tempDir, "new"))
+	tree.RemoveDir(filepath.Join(tempDir, "old"))
+	if err := tree.AddDir(filepath.Join(tempDir, "new")); err != nil {
+		t.Fatalf("AddDir failed: %v", err)
+	}
+
+	watched := make(map[string]bool)
+	for _, path := range tree.WatchList() {
+		watched[path] = true
+	}
+	expected := []string{tempDir, filepath.Join(tempDir, "new"), filepath.Join(tempDir, "new", "nested")}
+	if len(watched) != len(expected) {
+		t.Errorf("Expected watches %v, got %v", expected, tree.WatchList())
+	}
+	for _, path := range expected {
+		if !watched[path] {
+			t.Errorf("Expected %s to be watched, got %v", path, tree.WatchList())
+		}
+	}
+}
+
+func TestAddMaxDepth(t *testing.T) {
+	tempDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tempDir, "a", "b", "c"), 0755)
+
+	testCases := []struct {
+		maxDepth int
+		expected int
+	}{
+		{0, 4},
+		{1, 1},
+		{2, 2},
+		{3, 3},
+	}
+
+	for _, tc := range testCases {
+		tree := newTestTree(t, tempDir)
+		tree.MaxDepth = tc.maxDepth
+		if err := tree.Add(); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		if watched := tree.WatchList(); len(watched) != tc.expected {
+			t.Errorf("MaxDepth %d: expected %d watches, got %v", tc.maxDepth, tc.expected, watched)
+		}
+	}
+}
+
+func TestRecreate(t *testing.T) {
+	tempDir := t.TempDir()
+	tree := newTestTree(t, tempDir)
+	if err := tree.Add(); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	// Directories created while the watcher was broken are picked up
+	tree.Close()
+	os.MkdirAll(filepath.Join(tempDir, "nested"), 0755)
+	if err := tree.Recreate(); err != nil {
+		t.Fatalf("Recreate failed: %v", err)
+	}
+	if len(tree.WatchList()) != 2 {
+		t.Errorf("Expected the folder and nested to be watched, got %v", tree.WatchList())
+	}
+}
+
+func TestPollChanged(t *testing.T) {
+	tempDir := t.TempDir()
+	subDir := filepath.Join(tempDir, "certs")
+	os.MkdirAll(filepath.Join(subDir, "nested"), 0755)
+	os.WriteFile(filepath.Join(subDir, "ca.pem"), []byte("v1"), 0644)
+
+	tree := newTestTree(t, tempDir)
+	tree.poll(subDir)
+	tree.poll(subDir)
+	if tree.Polled() != 1 {
+		t.Errorf("Expected 1 polled directory, got %d", tree.Polled())
+	}
+
+	if tree.PollChanged() {
+		t.Error("Expected no change before files were modified")
+	}
+
+	testCases := []struct {
+		name   string
+		change func()
+	}{
+		{"modified", func() { os.WriteFile(filepath.Join(subDir, "ca.pem"), []byte("v2-longer"), 0644) }},
+		{"created in subdirectory", func() { os.WriteFile(filepath.Join(subDir, "nested", "key.pem"), []byte("key"), 0644) }},
+		{"removed", func() { os.Remove(filepath.Join(subDir, "ca.pem")) }},
+	}
+	for _, tc := range testCases {
+		tc.change()
+		if !tree.PollChanged() {
+			t.Errorf("Expected a change after a file was %s", tc.name)
+		}
+		if tree.PollChanged() {
+			t.Errorf("Expected no change on the next poll after a file was %s", tc.name)
+		}
+	}
+
+	tree.RemoveDir(tempDir)
+	if tree.Polled() != 0 {
+		t.Errorf("Expected polling to stop, still polling %v", tree.polled)
+	}
+}
+
+func TestIsWatchLimit(t *testing.T) {
+	if !IsWatchLimit(fmt.Errorf("add watch: %w", syscall.ENOSPC)) {
+		t.Error("Expected ENOSPC to be detected as the watch limit")
+	}
+	if IsWatchLimit(os.ErrNotExist) {
+		t.Error("Expected other errors not to be detected as the watch limit")
+	}
+}
+
+func TestNewPolled(t *testing.T) {
+	fsys := fstest.MapFS{"certs/ca.pem": {Data: []byte("v1")}}
+	reader := &source.Reader{FS: fsys}
+	tree := NewPolled("certs", reader.FileStates)
+	defer tree.Close()
+
+	if err := tree.Add(); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if tree.Polled() != 1 || len(tree.WatchList()) != 0 {
+		t.Errorf("Expected the folder to be polled, not watched, got %d polled and %v watched", tree.Polled(), tree.WatchList())
+	}
+	if tree.Events() != nil || tree.Errors() != nil {
+		t.Error("Expected no event or error channels")
+	}
+
+	if tree.PollChanged() {
+		t.Error("Expected no change before files were modified")
+	}
+	fsys["certs/key.pem"] = &fstest.MapFile{Data: []byte("key")}
+	if !tree.PollChanged() {
+		t.Error("Expected a change after a file was created")
+	}
+
+	if err := tree.Recreate(); err != nil {
+		t.Fatalf("Recreate failed: %v", err)
+	}
+	if tree.Polled() != 1 {
+		t.Errorf("Expected the folder to be polled after Recreate, got %d", tree.Polled())
+	}
+}
+
+func TestPollChangedRedactsNames(t *testing.T) {
+	redact.Enable(true)
+	defer redact.Enable(false)
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	tempDir := t.TempDir()
+	subDir := filepath.Join(tempDir, "payments-db")
+	os.MkdirAll(subDir, 0755)
+	tree := newTestTree(t, tempDir)
+	tree.poll(subDir)
+
+	os.RemoveAll(subDir)
+	tree.PollChanged()
+	if !strings.Contains(logs.String(), "Failed to poll") {
+		t.Fatalf("Expected the failed poll to be logged, got:\n%s", logs.String())
+	}
+	if strings.Contains(logs.String(), "payments-db") {
+		t.Errorf("Expected the folder name to be redacted, got:\n%s", logs.String())
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Set at link time, e.g.
+// go build -ldflags="-X main.version=v1.2.3 -X main.commit=$(git rev-parse HEAD) -X main.buildDate=$(date -u +%FT%TZ)"
+var (
+	version   = "dev"
+	commit    = ""
+	buildDate = ""
+)
+
+func init() {
+	commit, buildDate = vcsInfo(commit, buildDate)
+}
+
+// vcsInfo fills in the commit and build date from the VCS information Go
+// stamps into binaries built from a checkout, when they were not set at
+// link time.
+func vcsInfo(commit, date string) (string, string) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return commit, date
+	}
+	for _, setting := range info.Settings {
+		switch {
+		case setting.Key == "vcs.revision" && commit == "":
+			commit = setting.Value
+		case setting.Key == "vcs.time" && date == "":
+			date = setting.Value
+		}
+	}
+	return commit, date
+}
+
+// versionString describes the build for --version and the startup log.
+func versionString() string {
+	s := "go-file-secret-sync " + version
+	if commit != "" {
+		s += fmt.Sprintf(", commit %s", commit)
+	}
+	if buildDate != "" {
+		s += fmt.Sprintf(", built %s", buildDate)
+	}
+	return s + ", " + runtime.Version()
+}
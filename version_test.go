@@ -0,0 +1,24 @@
+package main
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestVersionString(t *testing.T) {
+	defer func(v, c, d string) { version, commit, buildDate = v, c, d }(version, commit, buildDate)
+
+	testCases := []struct {
+		version, commit, buildDate string
+		expected                   string
+	}{
+		{"v1.2.3", "abc123", "2025-01-01T00:00:00Z", "go-file-secret-sync v1.2.3, commit abc123, built 2025-01-01T00:00:00Z, " + runtime.Version()},
+		{"dev", "", "", "go-file-secret-sync dev, " + runtime.Version()},
+	}
+	for _, tc := range testCases {
+		version, commit, buildDate = tc.version, tc.commit, tc.buildDate
+		if got := versionString(); got != tc.expected {
+			t.Errorf("Expected %q, got %q", tc.expected, got)
+		}
+	}
+}